@@ -19,5 +19,8 @@ func runApp() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if len(os.Args) > 1 {
+		return app.RunCommand(ctx, os.Args[1:])
+	}
 	return app.Run(ctx)
 }
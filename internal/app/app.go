@@ -3,17 +3,20 @@ package app
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
 
 	"github.com/go-chi/chi/v5"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/omekov/dubaicarkzv2/internal/config"
 	"github.com/omekov/dubaicarkzv2/internal/handler"
 	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
 	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+	"github.com/omekov/dubaicarkzv2/pkg/sqlite3"
 )
 
 func Run(ctx context.Context) error {
@@ -22,53 +25,87 @@ func Run(ctx context.Context) error {
 		return err
 	}
 
-	db, err := sql.Open("sqlite3", cfg.SqlitePath)
+	repo, closeDB, err := openRepository(cfg.Database)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	defer closeDB()
+	ext := external.NewExternatClient(cfg.KGDURL, cfg.OpenExchangeRateURL)
 
-	func(db *sql.DB) {
-		var kgdURL string
-		_, err := db.Exec(`CREATE TABLE IF NOT EXISTS kgd_data_migration (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			kgd_url TEXT NOT NULL,
-			created_at TEXT NOT NULL DEFAULT (datetime('now', 'localtime'))
-		);`)
+	// бот нужен до usecase, чтобы пересылать заявки менеджерам; без него сервер
+	// продолжает работать, но уведомления и команды отключены
+	tb, err := NewTelegramBot(cfg.TelegramApiToken, cfg.TelegramChannelID, cfg.TelegramManagersChatID, cfg.TelegramAdminChatID)
+	botReady := err == nil
+	if err != nil {
+		slog.Error("NewTelegramBot", slog.String("err", err.Error()))
+	}
+
+	var notifier usecase.Notifier
+	if botReady {
+		notifier = tb
+	}
+	uc := usecase.NewUseCase(repo, ext, ext, notifier)
+	tb.useCase = uc
+
+	if !repo.SearchIndexEnabled() {
+		slog.Warn("fts5 is not available, search falls back to a full scan; build with -tags sqlite_fts5")
+	}
+
+	var linkPattern *regexp.Regexp
+	if cfg.KGDLinkPattern != "" {
+		linkPattern, err = regexp.Compile(cfg.KGDLinkPattern)
 		if err != nil {
-			slog.Error("Exec", err)
-			return
+			return fmt.Errorf("KGD_LINK_PATTERN -> %v", err)
 		}
+	}
 
-		err = db.QueryRow("SELECT kgd_url FROM kgd_data_migration WHERE kgd_url = ? ORDER BY created_at DESC;", cfg.KGDURL).Scan(&kgdURL)
-		if errors.Is(err, sql.ErrNoRows) {
-			err := startMigrate(db, cfg.KGDURL)
-			if err != nil {
-				slog.Error("StartMigrate", err)
-				return
-			}
-			_, err = db.Exec("INSERT INTO kgd_data_migration (kgd_url) VALUES  (?)", cfg.KGDURL)
-			if err != nil {
-				slog.Error("Exec", err)
-				return
-			}
-		} else {
-			slog.Error("QueryRow", err)
-			return
+	// без бота наблюдатель не запускается, и Trigger после импорта ничего не делает
+	watcher := newPriceWatcher(uc, ext, tb, cfg.WatchInterval, cfg.WatchRateThreshold)
+	refresher := kgdRefresher{
+		repo: repo,
+		rules: kgdRules{
+			MinRows:        cfg.KGDMinRows,
+			MaxPriceChange: cfg.KGDMaxPriceChange,
+			RequiredMarks:  cfg.KGDRequiredMarks,
+		},
+		external:    ext,
+		useCase:     uc,
+		pageURL:     cfg.KGDPageURL,
+		linkPattern: linkPattern,
+		fileURL:     cfg.KGDURL,
+		interval:    cfg.KGDCheckInterval,
+		onImport:    watcher.Trigger,
+	}
+	if botReady {
+		refresher.notify = tb.NotifyAdmins
+	}
+	// неудачная загрузка не трогает справочник, поэтому сервер работает на
+	// прежних данных; без данных вообще считать нечего
+	if _, err := refresher.Check(ctx); err != nil {
+		importID, idErr := repo.GetLastImportID(ctx)
+		if idErr != nil {
+			return fmt.Errorf("GetLastImportID -> %v", idErr)
+		}
+		if importID == 0 {
+			return fmt.Errorf("no KGD data loaded -> %v", err)
 		}
-	}(db)
+		slog.Error("kgdRefresher.Check", slog.String("err", err.Error()))
+	}
 
-	repo, err := repository.NewRepository(db)
-	if err != nil {
-		return fmt.Errorf("repository -> %v", err)
+	if err := uc.RebuildSearchIndex(ctx); err != nil {
+		slog.Error("RebuildSearchIndex", slog.String("err", err.Error()))
+	}
+	// без снимка справочник читается из базы, поэтому ошибка не останавливает сервер
+	if err := uc.RefreshCatalog(ctx); err != nil {
+		slog.Error("RefreshCatalog", slog.String("err", err.Error()))
 	}
-	uc := usecase.NewUseCase(repo)
 
 	r := chi.NewRouter()
 
 	handler.RegisterRoutes(r, handler.Dependencies{
-		AssetsFS: http.Dir(cfg.AssetsDir),
-		UseCase:  uc,
+		AssetsFS:   http.Dir(cfg.AssetsDir),
+		UseCase:    uc,
+		AdminToken: cfg.AdminToken,
 	})
 
 	s := http.Server{
@@ -76,10 +113,37 @@ func Run(ctx context.Context) error {
 		Handler: r,
 	}
 
+	if cfg.PopularityInterval > 0 {
+		go recalculatePopularity(ctx, uc, cfg.PopularityInterval)
+	}
+
+	go refresher.Run(ctx)
+	if cfg.CatalogSyncInterval > 0 {
+		go syncCatalog(ctx, uc, cfg.CatalogSyncInterval, watcher.Trigger)
+	}
+
 	go func() {
-		tb := NewTelegramBot(cfg.TelegramApiToken)
+		if !botReady {
+			return
+		}
+
+		go watcher.Run(ctx)
+
+		if cfg.DigestSchedule != "" {
+			digest, err := newRatesDigest(uc, tb, cfg)
+			if err != nil {
+				slog.Error("newRatesDigest", slog.String("err", err.Error()))
+			} else {
+				go func() {
+					if err := digest.Run(ctx); err != nil {
+						slog.Error("digest.Run", slog.String("err", err.Error()))
+					}
+				}()
+			}
+		}
+
 		if err := tb.Init(); err != nil {
-			slog.Error("tb.Init", err)
+			slog.Error("tb.Init", slog.String("err", err.Error()))
 		}
 	}()
 
@@ -95,3 +159,54 @@ func Run(ctx context.Context) error {
 	}
 	return nil
 }
+
+// openRepository подключается к базе, применяет миграции и возвращает
+// репозиторий и функцию, закрывающую подключения.
+func openRepository(cfg config.Database) (repository.Repo, func(), error) {
+	dialect, dsn, err := repository.ParseDSN(cfg.DatabaseDSN)
+	if err != nil {
+		return repository.Repo{}, nil, err
+	}
+	db, read, err := openDB(dialect, dsn, cfg)
+	if err != nil {
+		return repository.Repo{}, nil, err
+	}
+	closeDB := func() {
+		db.Close()
+		read.Close()
+	}
+
+	if err := migrateUp(db, dialect); err != nil {
+		closeDB()
+		return repository.Repo{}, nil, fmt.Errorf("migrateUp -> %v", err)
+	}
+
+	repo, err := repository.NewRepository(db, read, dialect)
+	if err != nil {
+		closeDB()
+		return repository.Repo{}, nil, fmt.Errorf("repository -> %v", err)
+	}
+	return repo.WithQueryTimeout(cfg.DBQueryTimeout), closeDB, nil
+}
+
+// openDB возвращает пул для записи и пул для чтения. У SQLite это разные пулы
+// к одному файлу в режиме WAL, чтобы импорт не блокировал чтение справочника;
+// у PostgreSQL пул один.
+func openDB(dialect repository.Dialect, dsn string, cfg config.Database) (*sql.DB, *sql.DB, error) {
+	if dialect == repository.DialectSQLite {
+		db, err := sqlite3.Open(dsn, sqlite3.Config{
+			BusyTimeout:  cfg.SQLiteBusyTimeout,
+			MaxReadConns: cfg.SQLiteReadConns,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return db.Write, db.Read, nil
+	}
+
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, db, nil
+}
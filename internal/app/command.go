@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omekov/dubaicarkzv2/internal/config"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const commandUsage = `usage:
+  dubaicarkz kgd status    последние загрузки списка КГД
+  dubaicarkz kgd rollback  вернуть справочник, действовавший до последней загрузки`
+
+// RunCommand выполняет служебную команду вместо запуска сервера. Нужны только
+// настройки базы; запущенный сервер подхватывает изменения сам в течение
+// CATALOG_SYNC_INTERVAL.
+func RunCommand(ctx context.Context, args []string) error {
+	if len(args) != 2 || args[0] != "kgd" {
+		return errors.New(commandUsage)
+	}
+
+	cfg, err := config.GetDatabase()
+	if err != nil {
+		return err
+	}
+	repo, closeDB, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return runKGDCommand(ctx, repo, args[1], os.Stdout)
+}
+
+func runKGDCommand(ctx context.Context, repo repository.Repo, command string, out io.Writer) error {
+	switch command {
+	case "status":
+		for _, status := range []string{repository.KGDImportDone, repository.KGDImportFailed, repository.KGDImportRolledBack} {
+			i, ok, err := repo.GetLastKGDImport(ctx, status)
+			if err != nil {
+				return err
+			}
+			if ok {
+				fmt.Fprintf(out, "%-11s #%d %s %s, строк: %d %s\n", status, i.ID, i.CreatedAt, i.URL, i.Rows, i.Error)
+			}
+		}
+		previous, err := repo.CountPreviousData(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "прежний справочник: строк %d\n", previous)
+		return nil
+	case "rollback":
+		rolledBack, err := repo.RollbackData(ctx)
+		if errors.Is(err, repository.ErrNoPreviousData) {
+			return errors.New("прежнего справочника нет: откат уже был или загрузка была одна")
+		}
+		if err != nil {
+			return fmt.Errorf("RollbackData: %v", err)
+		}
+		fmt.Fprintf(out, "загрузка #%d %s отменена, файл не будет загружен повторно, пока не изменится\n", rolledBack.ID, rolledBack.URL)
+		return nil
+	default:
+		return errors.New(commandUsage)
+	}
+}
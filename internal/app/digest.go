@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/config"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/robfig/cron/v3"
+)
+
+const defaultDigestTemplate = `Курсы валют на {{.Date.Format "02.01.2006"}}
+{{range .Rates}}
+{{.Name}}: {{printf "%.2f" .Value}}{{if .HasPrevious}} ({{change .Change}}, {{percent .ChangePercent}}){{end}}{{end}}
+{{with .Car}}
+{{.Mark}} {{.Model}} {{.Year}}, {{.Volume}} см³
+Под ключ: ~{{amount .TurnkeyAmount}} ₸{{end}}`
+
+var digestFuncs = template.FuncMap{
+	"amount": formatAmount,
+	"change": func(v float64) string {
+		return fmt.Sprintf("%s%.2f", changeSign(v), v)
+	},
+	"percent": func(v float64) string {
+		return fmt.Sprintf("%s%.2f%%", changeSign(v), v)
+	},
+}
+
+func changeSign(v float64) string {
+	if v >= 0 {
+		return "+"
+	}
+	return ""
+}
+
+// ratesDigest по расписанию публикует в канал курсы USD, AED и RUB к тенге
+// с изменением за день и примером цены под ключ для популярного авто.
+type ratesDigest struct {
+	useCase  usecase.UseCase
+	bot      telegramBot
+	schedule string
+	template *template.Template
+	car      usecase.DigestCar
+}
+
+func newRatesDigest(uc usecase.UseCase, bot telegramBot, cfg config.Config) (ratesDigest, error) {
+	text := cfg.DigestTemplate
+	if strings.TrimSpace(text) == "" {
+		text = defaultDigestTemplate
+	}
+
+	tmpl, err := template.New("digest").Funcs(digestFuncs).Parse(text)
+	if err != nil {
+		return ratesDigest{}, fmt.Errorf("DIGEST_TEMPLATE -> %v", err)
+	}
+
+	return ratesDigest{
+		useCase:  uc,
+		bot:      bot,
+		schedule: cfg.DigestSchedule,
+		template: tmpl,
+		car: usecase.DigestCar{
+			Mark:     strings.ToUpper(cfg.DigestCarMark),
+			Model:    strings.ToUpper(cfg.DigestCarModel),
+			Volume:   cfg.DigestCarVolume,
+			PriceUSD: cfg.DigestCarPriceUSD,
+		},
+	}, nil
+}
+
+func (d ratesDigest) Run(ctx context.Context) error {
+	c := cron.New()
+	if _, err := c.AddFunc(d.schedule, func() { d.post(ctx) }); err != nil {
+		return fmt.Errorf("DIGEST_SCHEDULE -> %v", err)
+	}
+
+	c.Start()
+	<-ctx.Done()
+	<-c.Stop().Done()
+	return nil
+}
+
+func (d ratesDigest) post(ctx context.Context) {
+	digest, err := d.useCase.RatesDigest(ctx, time.Now(), d.car)
+	if err != nil {
+		slog.Error("RatesDigest", slog.String("err", err.Error()))
+		return
+	}
+
+	var b strings.Builder
+	if err := d.template.Execute(&b, digest); err != nil {
+		slog.Error("digest template", slog.String("err", err.Error()))
+		return
+	}
+
+	if err := d.bot.SendToChannel(b.String()); err != nil {
+		slog.Error("SendToChannel", slog.String("err", err.Error()))
+	}
+}
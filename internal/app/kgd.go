@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// kgdRefresher подхватывает новый список КГД без перезапуска: при старте и
+// затем раз в interval находит файл, сверяет его по ETag/Last-Modified и хешу
+// с последней загрузкой и, если он изменился, проверяет правилами rules и
+// заменяет справочник. Итог записывается в kgd_data_migration и уходит
+// администраторам.
+type kgdRefresher struct {
+	repo     repository.Repo
+	rules    kgdRules
+	external external.Client
+	useCase  usecase.UseCase
+	// pageURL - страница со ссылкой на свежий файл; пустая - всегда fileURL
+	pageURL string
+	// linkPattern отбирает ссылку на странице, nil - любая ссылка на Excel
+	linkPattern *regexp.Regexp
+	fileURL     string
+	interval    time.Duration
+	// notify отправляет сообщение администраторам, nil - бот недоступен
+	notify func(text string) error
+	// onImport вызывается после удачной загрузки, например чтобы пересчитать подписки
+	onImport func()
+}
+
+// Run проверяет файл раз в interval, при interval = 0 сразу возвращается.
+// Проверку при старте вызывающий делает сам через Check, чтобы решить,
+// можно ли запускать сервер.
+func (r kgdRefresher) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Check(ctx); err != nil {
+				slog.Error("kgdRefresher.Check", slog.String("err", err.Error()))
+			}
+		}
+	}
+}
+
+// Check загружает файл, если он новый, и сообщает, была ли загрузка.
+// Файл, который уже не удалось загрузить или загрузку которого откатили,
+// повторно не загружается, пока не изменится.
+func (r kgdRefresher) Check(ctx context.Context) (bool, error) {
+	fileURL := r.fileURL
+	if r.pageURL != "" {
+		found, err := r.external.FindKGDFileURL(ctx, r.pageURL, r.linkPattern)
+		if err != nil {
+			return false, fmt.Errorf("FindKGDFileURL: %v", err)
+		}
+		fileURL = found
+	}
+
+	last, imported, err := r.repo.GetLastKGDImport(ctx, repository.KGDImportDone)
+	if err != nil {
+		return false, err
+	}
+	var etag, lastModified string
+	if imported && last.URL == fileURL {
+		etag, lastModified = last.ETag, last.LastModified
+	}
+	file, err := r.external.FetchKGDFile(ctx, fileURL, etag, lastModified)
+	if err != nil {
+		return false, fmt.Errorf("FetchKGDFile %s: %v", fileURL, err)
+	}
+	if file.NotModified {
+		return false, nil
+	}
+
+	// тот же файл мог переехать по другому адресу или сервер не отдает ETag
+	sum := sha256.Sum256(file.Body)
+	hash := hex.EncodeToString(sum[:])
+	if imported && last.SHA256 == hash {
+		return false, nil
+	}
+	failed, ok, err := r.repo.GetLastKGDImport(ctx, repository.KGDImportFailed)
+	if err != nil {
+		return false, err
+	}
+	if ok && failed.SHA256 == hash && failed.ID > last.ID {
+		return false, nil
+	}
+	rolledBack, ok, err := r.repo.GetLastKGDImport(ctx, repository.KGDImportRolledBack)
+	if err != nil {
+		return false, err
+	}
+	if ok && rolledBack.SHA256 == hash {
+		return false, nil
+	}
+
+	record, importErr := importKGD(ctx, r.repo, r.rules, file.Body, repository.KGDImport{
+		URL:          fileURL,
+		ETag:         file.ETag,
+		LastModified: file.LastModified,
+		SHA256:       hash,
+	})
+	if importErr != nil {
+		record.Status = repository.KGDImportFailed
+		record.Error = importErr.Error()
+		if _, err := r.repo.CreateKGDImport(ctx, record); err != nil {
+			slog.Error("CreateKGDImport", slog.String("err", err.Error()))
+		}
+		r.notifyAdmins(fmt.Sprintf("Не удалось загрузить список КГД %s, справочник не изменен: %v", fileURL, importErr))
+		return false, fmt.Errorf("importKGD: %v", importErr)
+	}
+
+	slog.Info("kgd imported", slog.String("url", fileURL), slog.Int("rows", record.Rows))
+	if err := r.useCase.RebuildSearchIndex(ctx); err != nil {
+		slog.Error("RebuildSearchIndex", slog.String("err", err.Error()))
+	}
+	if err := r.useCase.RefreshCatalog(ctx); err != nil {
+		slog.Error("RefreshCatalog", slog.String("err", err.Error()))
+	}
+	if r.onImport != nil {
+		r.onImport()
+	}
+	r.notifyAdmins(fmt.Sprintf("Загружен новый список КГД: %s\nСтрок: %d\nОтменить: dubaicarkz kgd rollback", fileURL, record.Rows))
+	return true, nil
+}
+
+func (r kgdRefresher) notifyAdmins(text string) {
+	if r.notify == nil {
+		return
+	}
+	if err := r.notify(text); err != nil {
+		slog.Error("notify admins", slog.String("err", err.Error()))
+	}
+}
+
+// syncCatalog раз в interval подхватывает справочник, замененный другим
+// процессом, и вызывает onChange, например чтобы пересчитать подписки.
+func syncCatalog(ctx context.Context, uc usecase.UseCase, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			synced, err := uc.SyncCatalog(ctx)
+			if err != nil {
+				slog.Error("SyncCatalog", slog.String("err", err.Error()))
+				continue
+			}
+			if synced {
+				slog.Info("catalog synced with database")
+				onChange()
+			}
+		}
+	}
+}
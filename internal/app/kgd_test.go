@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// kgdServer отдает страницу со ссылкой и файл КГД с ETag, как сайт КГД.
+type kgdServer struct {
+	mu        sync.Mutex
+	file      []byte
+	etag      string
+	downloads int
+}
+
+func (s *kgdServer) set(file []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file, s.etag = file, etag
+}
+
+func (s *kgdServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/kgd/":
+		fmt.Fprint(w, `<a href="/docs/rules.pdf">Правила</a> <a href="files/kgd.xlsx">Список</a>`)
+	case "/kgd/files/kgd.xlsx":
+		if r.Header.Get("If-None-Match") == s.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.downloads++
+		w.Header().Set("ETag", s.etag)
+		w.Write(s.file)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestKGDRefresher(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newTestDB(t)
+	server := &kgdServer{}
+	server.set(kgdFile(t, 10), `"v1"`)
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	uc := usecase.NewUseCase(repo, nil, nil, nil)
+	var messages []string
+	triggered := 0
+	refresher := kgdRefresher{
+		repo:     repo,
+		external: external.NewExternatClient("", ""),
+		useCase:  uc,
+		pageURL:  srv.URL + "/kgd/",
+		notify: func(text string) error {
+			messages = append(messages, text)
+			return nil
+		},
+		onImport: func() { triggered++ },
+	}
+	check := func(wantImported, wantErr bool) {
+		t.Helper()
+		imported, err := refresher.Check(ctx)
+		if imported != wantImported || (err != nil) != wantErr {
+			t.Fatalf("Check = %v, %v, want imported %v, error %v", imported, err, wantImported, wantErr)
+		}
+	}
+	rowCount := func() int {
+		t.Helper()
+		rows, err := repo.GetDataRows(ctx, "", "", 0, 0)
+		if err != nil {
+			t.Fatalf("GetDataRows: %v", err)
+		}
+		return len(rows)
+	}
+
+	check(true, false)
+	last, ok, err := repo.GetLastKGDImport(ctx, repository.KGDImportDone)
+	if err != nil || !ok {
+		t.Fatalf("GetLastKGDImport = %v, %v", ok, err)
+	}
+	if last.URL != srv.URL+"/kgd/files/kgd.xlsx" || last.ETag != `"v1"` || last.SHA256 == "" || last.Rows != 10 {
+		t.Errorf("recorded import = %+v", last)
+	}
+	if rowCount() != 10 || triggered != 1 || len(messages) != 1 || !strings.HasPrefix(messages[0], "Загружен") {
+		t.Errorf("after import: %d rows, %d triggers, messages %q", rowCount(), triggered, messages)
+	}
+	if version, _ := uc.GetCatalogTree(ctx, usecase.CatalogFilter{}); version.ImportID != last.ID {
+		t.Errorf("catalog import = %d, want %d", version.ImportID, last.ID)
+	}
+
+	// сервер отвечает 304, файл не скачивается
+	check(false, false)
+	// новый ETag, но содержимое то же - хеш совпал
+	server.set(kgdFile(t, 10), `"v2"`)
+	check(false, false)
+	if server.downloads != 2 || len(messages) != 1 {
+		t.Errorf("unchanged file: %d downloads, messages %q", server.downloads, messages)
+	}
+
+	server.set(kgdFile(t, 12), `"v3"`)
+	check(true, false)
+	if rowCount() != 12 || triggered != 2 || len(messages) != 2 {
+		t.Errorf("after update: %d rows, %d triggers, messages %q", rowCount(), triggered, messages)
+	}
+
+	// битый файл записывается как неудачный, справочник остается прежним
+	server.set([]byte("not an excel file"), `"v4"`)
+	check(false, true)
+	failed, ok, err := repo.GetLastKGDImport(ctx, repository.KGDImportFailed)
+	if err != nil || !ok || failed.Error == "" {
+		t.Fatalf("failed import = %+v, %v, %v", failed, ok, err)
+	}
+	if rowCount() != 12 || len(messages) != 3 || !strings.HasPrefix(messages[2], "Не удалось") {
+		t.Errorf("after a broken file: %d rows, messages %q", rowCount(), messages)
+	}
+	// о том же битом файле второй раз не сообщается
+	check(false, false)
+	if len(messages) != 3 {
+		t.Errorf("broken file reported again: %q", messages)
+	}
+
+	// файл читается, но не проходит проверку - справочник остается прежним
+	refresher.rules = kgdRules{MinRows: 5, RequiredMarks: []string{"TOYOTA"}}
+	server.set(kgdFile(t, 3), `"v5"`)
+	check(false, true)
+	if rowCount() != 12 || len(messages) != 4 || !strings.Contains(messages[3], "не прошел проверку") {
+		t.Errorf("after a rejected file: %d rows, messages %q", rowCount(), messages)
+	}
+
+	// откат возвращает список из 10 строк, а отмененный файл не загружается снова
+	var out strings.Builder
+	if err := runKGDCommand(ctx, repo, "rollback", &out); err != nil {
+		t.Fatalf("kgd rollback: %v", err)
+	}
+	if rowCount() != 10 {
+		t.Errorf("after rollback: %d rows, want 10", rowCount())
+	}
+	if synced, err := uc.SyncCatalog(ctx); err != nil || !synced {
+		t.Errorf("SyncCatalog after rollback = %v, %v", synced, err)
+	}
+	if version, _ := uc.GetCatalogTree(ctx, usecase.CatalogFilter{}); version.ImportID != last.ID {
+		t.Errorf("catalog import after rollback = %d, want %d", version.ImportID, last.ID)
+	}
+	server.set(kgdFile(t, 12), `"v3"`)
+	check(false, false)
+	if rowCount() != 10 {
+		t.Errorf("rolled back file imported again: %d rows", rowCount())
+	}
+	if err := runKGDCommand(ctx, repo, "rollback", &out); err == nil {
+		t.Error("second kgd rollback succeeded")
+	}
+	out.Reset()
+	if err := runKGDCommand(ctx, repo, "status", &out); err != nil || !strings.Contains(out.String(), repository.KGDImportRolledBack) {
+		t.Errorf("kgd status = %q, %v", out.String(), err)
+	}
+}
+
+func TestFindKGDFileURL(t *testing.T) {
+	pages := map[string]string{
+		// свежий список не первым на странице
+		"/dated/": `<a href="/docs/rules.pdf">Правила</a>
+			<a href="files/kgd_01.07.2023.xlsx">Легковые авто (архив)</a>
+			<a href="files/kgd_15.01.2024.xlsx">Легковые авто</a>
+			<a href="files/moto_01.03.2024.xls">Мототехника</a>`,
+		// дата только в тексте ссылки
+		"/text/":  `<a href="files/a.xlsx">Список от <b>01.02.2023</b></a> <a href='files/b.xlsx'>Список 2024-02-01</a>`,
+		"/plain/": `<a href="files/first.xlsx">Список</a> <a href="files/second.xlsx">Список</a>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer srv.Close()
+
+	client := external.NewExternatClient("", "")
+	tests := []struct {
+		page    string
+		pattern *regexp.Regexp
+		want    string
+	}{
+		{"/dated/", nil, "/dated/files/moto_01.03.2024.xls"},
+		{"/dated/", regexp.MustCompile(`(?i)легков`), "/dated/files/kgd_15.01.2024.xlsx"},
+		{"/dated/", regexp.MustCompile(`архив`), "/dated/files/kgd_01.07.2023.xlsx"},
+		{"/text/", nil, "/text/files/b.xlsx"},
+		{"/plain/", nil, "/plain/files/first.xlsx"},
+		{"/plain/", regexp.MustCompile(`second`), "/plain/files/second.xlsx"},
+		{"/plain/", regexp.MustCompile(`грузов`), ""},
+	}
+	for _, tt := range tests {
+		got, err := client.FindKGDFileURL(context.Background(), srv.URL+tt.page, tt.pattern)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("FindKGDFileURL(%s, %v) = %s, want an error", tt.page, tt.pattern, got)
+			}
+			continue
+		}
+		if err != nil || got != srv.URL+tt.want {
+			t.Errorf("FindKGDFileURL(%s, %v) = %s, %v, want %s", tt.page, tt.pattern, got, err, srv.URL+tt.want)
+		}
+	}
+}
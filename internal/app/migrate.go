@@ -2,38 +2,54 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
-	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+	"github.com/omekov/dubaicarkzv2/migrations"
 	"github.com/xuri/excelize/v2"
 )
 
-func startMigrate(db *sql.DB, kgdURL string) error {
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+// migrateUp применяет вшитые SQL-миграции. Вызывается при каждом старте,
+// чтобы новые таблицы появлялись и без загрузки нового списка КГД.
+func migrateUp(db *sql.DB, dialect repository.Dialect) error {
+	var (
+		driver database.Driver
+		err    error
+	)
+	switch dialect {
+	case repository.DialectPostgres:
+		driver, err = postgres.WithInstance(db, &postgres.Config{})
+	default:
+		driver, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+	}
 	if err != nil {
-		log.Fatalf("Не удалось создать драйвер миграции: %v", err)
+		return fmt.Errorf("Не удалось создать драйвер миграции: %v", err)
 	}
 
-	// 3. Создаём источник миграций (например, файловый источник)
-	sourceDriver, err := (&file.File{}).Open("file://../../migrations")
+	// у каждого диалекта свой каталог миграций
+	sourceDriver, err := iofs.New(migrations.FS, string(dialect))
 	if err != nil {
 		return fmt.Errorf("Не удалось открыть источник миграций: %v", err)
 	}
 
-	// 4. Инициализируем объект миграции с использованием драйвера и источника
 	m, err := migrate.NewWithInstance(
-		"file",       // Имя источника
-		sourceDriver, // Экземпляр источника
-		"sqlite3",    // Имя базы данных
-		driver,       // Экземпляр драйвера базы данных
+		"iofs",          // Имя источника
+		sourceDriver,    // Экземпляр источника
+		string(dialect), // Имя базы данных
+		driver,          // Экземпляр драйвера базы данных
 	)
 	if err != nil {
 		return err
@@ -42,109 +58,201 @@ func startMigrate(db *sql.DB, kgdURL string) error {
 	if err != nil && err != migrate.ErrNoChange {
 		return err
 	}
+	return nil
+}
+
+// kgdRules - проверки нового списка КГД перед заменой справочника: битый или
+// обрезанный файл не должен подменить рабочие данные.
+type kgdRules struct {
+	// MinRows - минимальное число строк, 0 - не проверяется
+	MinRows int
+	// MaxPriceChange - допустимое изменение суммарной стоимости моделей, которые
+	// есть и в прежнем справочнике, в процентах; 0 - не проверяется
+	MaxPriceChange float64
+	// RequiredMarks - марки, которые должны быть в списке
+	RequiredMarks []string
+}
 
-	fileBytes, err := downloadFile(kgdURL)
+// check возвращает все нарушенные правила сразу, чтобы администратор видел полную картину.
+func (k kgdRules) check(stats repository.StagingStats) error {
+	var errs []error
+	if stats.Rows < k.MinRows {
+		errs = append(errs, fmt.Errorf("строк %d, ожидалось не меньше %d", stats.Rows, k.MinRows))
+	}
+	missing := make([]string, 0)
+	for _, mark := range k.RequiredMarks {
+		if mark = usecase.NormalizeName(mark); mark != "" && !stats.Marks[mark] {
+			missing = append(missing, mark)
+		}
+	}
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("нет марок: %s", strings.Join(missing, ", ")))
+	}
+	// при первой загрузке сравнивать не с чем
+	if k.MaxPriceChange > 0 && stats.OldAmount > 0 {
+		change := math.Abs(float64(stats.NewAmount-stats.OldAmount)) / float64(stats.OldAmount) * 100
+		if change > k.MaxPriceChange {
+			errs = append(errs, fmt.Errorf("стоимости %d моделей изменились на %.1f%%, допустимо %.1f%%", stats.Matched, change, k.MaxPriceChange))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errKGDRejected, errors.Join(errs...))
+	}
+	return nil
+}
+
+// errKGDRejected - файл прочитан, но не прошел проверки kgdRules.
+var errKGDRejected = errors.New("список КГД не прошел проверку")
+
+// importKGD загружает файл КГД в data_staging, проверяет правилами rules и
+// только затем одной транзакцией подменяет справочник, сохраняя прежний для
+// kgd rollback. При любой ошибке справочник остается прежним. record - запись
+// о загрузке; возвращается с номером и числом строк.
+func importKGD(ctx context.Context, repo repository.Repo, rules kgdRules, file []byte, record repository.KGDImport) (repository.KGDImport, error) {
+	rows, err := parseKGDFile(file)
 	if err != nil {
-		return fmt.Errorf("downloadFile: %v", err)
+		return record, err
+	}
+	if err := repo.LoadStaging(ctx, rows); err != nil {
+		return record, fmt.Errorf("LoadStaging: %v", err)
+	}
+	stats, err := repo.GetStagingStats(ctx)
+	if err != nil {
+		return record, fmt.Errorf("GetStagingStats: %v", err)
+	}
+	if err := rules.check(stats); err != nil {
+		return record, err
 	}
 
-	f, err := excelize.OpenReader(bytes.NewReader(fileBytes))
+	record.Rows = len(rows)
+	record.ID, err = repo.PromoteStaging(ctx, record)
 	if err != nil {
-		return fmt.Errorf("Ошибка при открытии Excel-файла: %v", err)
+		return record, fmt.Errorf("PromoteStaging: %v", err)
 	}
-	defer f.Close()
+	record.Status = repository.KGDImportDone
+	return record, nil
+}
 
-	sheetList := f.GetSheetList()
-	if len(sheetList) == 0 {
-		return fmt.Errorf("Файл не содержит листов")
+// parseKGDFile читает строки из Excel-файла КГД: номер, марка, модель, объем,
+// год, стоимость и необязательный класс транспорта (M1, N1, L). В легковом
+// списке КГД класса нет, там он M1.
+func parseKGDFile(file []byte) ([]repository.KGDRow, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка при открытии Excel-файла: %v", err)
 	}
+	defer f.Close()
 
-	// Выводим все названия листов
-	fmt.Println("Доступные листы в файле:", sheetList)
+	sheetList := f.GetSheetList()
 	if len(sheetList) == 0 {
-		return fmt.Errorf("excel нет листов")
+		return nil, fmt.Errorf("Файл не содержит листов")
 	}
 
-	// Пример чтения данных из файла
 	rows, err := f.GetRows(sheetList[0])
 	if err != nil {
-		return fmt.Errorf("GetRows:%s", err.Error())
+		return nil, fmt.Errorf("GetRows:%s", err.Error())
 	}
 
-	popularMark := map[string]int{"BMW": 1000000, "NISSAN": 1000001, "KIA": 1000002, "VOLKSWAGEN": 1000003, "MERCEDES-BENZ": 1000004, "HYUNDAI": 1000005, "TOYOTA": 1000006}
+	data := make([]repository.KGDRow, 0, len(rows))
 	for i, row := range rows {
-		rate := i + 1
 		if i == 0 {
 			continue
 		}
+		if len(row) < 6 {
+			return nil, fmt.Errorf("строка %d: ожидалось 6 колонок, получено %d", i+1, len(row))
+		}
 		id, err := strconv.Atoi(strings.Trim(strings.Replace(row[0], ",", "", -1), " "))
 		if err != nil {
-			return err
+			return nil, err
 		}
-		mark := strings.Trim(row[1], " ")
-		model := strings.Trim(row[2], " ")
-		motor := strings.Trim(strings.Replace(row[3], ",", "", -1), " ")
-		var volume int
-		if strings.Contains(strings.ToLower(motor), strings.ToLower("Элект")) {
-			volume = 0
-		} else {
-			volume, err = strconv.Atoi(strings.Trim(strings.Replace(row[3], ",", "", -1), " "))
-			if err != nil {
-				return err
-			}
+		mark := usecase.NormalizeName(row[1])
+		variant := usecase.NormalizeName(row[2])
+		motor, err := parseMotor(row[3], variant)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %v", i+1, err)
 		}
 		year, err := strconv.Atoi(strings.Trim(strings.Replace(row[4], ",", "", -1), " "))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		amount, err := strconv.Atoi(strings.Trim(strings.Replace(row[5], ",", "", -1), " "))
 		if err != nil {
-			return err
-		}
-		if _, ok := popularMark[mark]; ok {
-			rate = popularMark[mark]
+			return nil, err
 		}
-		_, err = db.Exec(
-			"INSERT INTO data (id, mark, model, volume, year, amount, popular_rate) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			id,
-			mark,
-			model,
-			volume,
-			year,
-			amount,
-			rate,
-		)
-		if err != nil {
-			return err
+		class := usecase.ClassM1
+		if len(row) > 6 && strings.TrimSpace(row[6]) != "" {
+			class = strings.ToUpper(strings.TrimSpace(row[6]))
+			if class != usecase.ClassM1 && class != usecase.ClassN1 && class != usecase.ClassL {
+				return nil, fmt.Errorf("строка %d: класс транспорта %q, ожидался M1, N1 или L", i+1, row[6])
+			}
 		}
-		// модели которые
-		// models = []string{
-		// 	"camry","corolla", "land cruiser prado", "land cruiser", "rav4", "highlander",
-		// 	"elantra", "sonata", "accent", "tucson", "santa fe", "grandeur", "creata", "palisade",
-		// 	"E200", "E220", "E230", "E280", "E320", "S320", "S500", "190",
-		// 	"Passat",
-		// }
-		// db.QueryContext(ctx, `SELECT year, model`)
+		data = append(data, repository.KGDRow{
+			ID:      id,
+			Mark:    mark,
+			Model:   variant,
+			Variant: variant,
+			Volume:  motor.Volume,
+			Year:    year,
+			Amount:  amount,
+
+			EngineType:      motor.EngineType,
+			BatteryCapacity: motor.BatteryCapacity,
+			Power:           motor.Power,
+			VehicleClass:    class,
+		})
 	}
+	return data, nil
+}
 
-	return nil
+// motor - разобранная колонка двигателя из файла КГД.
+type motor struct {
+	EngineType      string
+	Volume          int
+	BatteryCapacity float64
+	Power           int
 }
 
-func downloadFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+var (
+	batteryPattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:квт\s*\*?\s*ч|квтч|kwh)`)
+	powerPattern   = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:квт|kw)(?:[^\pLч*]|$)`)
+)
+
+// parseMotor разбирает колонку двигателя: объем в см³ либо «Электро» с
+// необязательными емкостью батареи (кВт*ч) и мощностью (кВт). Гибрид
+// отмечается в колонке двигателя или в названии модели, объем у него обычный.
+func parseMotor(cell, variant string) (motor, error) {
+	lower := strings.ToLower(strings.TrimSpace(cell))
+	if strings.Contains(lower, "элект") || strings.Contains(lower, "electric") {
+		m := motor{EngineType: usecase.EngineEV}
+		if match := batteryPattern.FindStringSubmatch(lower); match != nil {
+			m.BatteryCapacity, _ = strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+		}
+		if match := powerPattern.FindStringSubmatch(lower); match != nil {
+			power, _ := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+			m.Power = int(math.Round(power))
+		}
+		return m, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка загрузки файла: статус %d", resp.StatusCode)
+	m := motor{EngineType: usecase.EngineICE}
+	if strings.Contains(lower, "гибрид") || strings.Contains(lower, "hybrid") || isHybridVariant(variant) {
+		m.EngineType = usecase.EngineHybrid
 	}
-	// Читаем содержимое файла в память
-	fileBytes, err := io.ReadAll(resp.Body)
+	volume, err := strconv.Atoi(strings.NewReplacer("гибрид", "", "hybrid", "", ",", "", " ", "", "\u00a0", "").Replace(lower))
 	if err != nil {
-		log.Fatalf("Ошибка при чтении данных файла: %v", err)
+		return motor{}, fmt.Errorf("объем %q: %v", cell, err)
 	}
+	m.Volume = volume
+	return m, nil
+}
 
-	return fileBytes, nil
+// isHybridVariant ищет в названии модели отдельное слово HYBRID, PHEV или HEV.
+func isHybridVariant(variant string) bool {
+	for _, word := range strings.FieldsFunc(variant, func(r rune) bool { return r == ' ' || r == '-' || r == '/' || r == '(' || r == ')' }) {
+		switch word {
+		case "HYBRID", "PHEV", "HEV":
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,246 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+	"github.com/omekov/dubaicarkzv2/pkg/sqlite3"
+	"github.com/xuri/excelize/v2"
+)
+
+// kgdFile собирает xlsx в формате КГД: номер, марка, модель, объем, год, стоимость.
+func kgdFile(t *testing.T, rows int) []byte {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		t.Fatalf("NewStreamWriter: %v", err)
+	}
+	if err := sw.SetRow("A1", []any{"№", "Марка", "Модель", "Объем", "Год", "Стоимость"}); err != nil {
+		t.Fatalf("SetRow: %v", err)
+	}
+	for i := 1; i <= rows; i++ {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		row := []any{i, "TOYOTA", "CAMRY", 2000 + i%4*500, 2000 + i%25, 10000 + i}
+		if err := sw.SetRow(cell, row); err != nil {
+			t.Fatalf("SetRow: %v", err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newTestDB открывает файл SQLite во временном каталоге со всеми миграциями.
+func newTestDB(t *testing.T) (sqlite3.DB, repository.Repo) {
+	t.Helper()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "kgd.db"), sqlite3.Config{})
+	if err != nil {
+		t.Fatalf("sqlite3.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrateUp(db.Write, repository.DialectSQLite); err != nil {
+		t.Fatalf("migrateUp: %v", err)
+	}
+	repo, err := repository.NewRepository(db.Write, db.Read, repository.DialectSQLite)
+	if err != nil {
+		t.Fatalf("NewRepository: %v", err)
+	}
+	return db, repo
+}
+
+// TestImportConcurrentReads проверяет, что во время импорта КГД справочник
+// продолжает отвечать из пула чтения и не получает "database is locked".
+func TestImportConcurrentReads(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+
+	_, repo := newTestDB(t)
+	file := kgdFile(t, 5000)
+	imported := make(chan error, 1)
+	go func() {
+		_, err := importKGD(context.Background(), repo, kgdRules{}, file, repository.KGDImport{})
+		imported <- err
+	}()
+
+	var (
+		wg      sync.WaitGroup
+		done    atomic.Bool
+		reads   atomic.Int64
+		slowest atomic.Int64
+		errs    = make(chan error, 8)
+	)
+	ctx := context.Background()
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() {
+				start := time.Now()
+				if _, err := repo.GetSpecifications(ctx, "TOYOTA", "CAMRY", 2500); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := repo.GetMarks(ctx); err != nil {
+					errs <- err
+					return
+				}
+				reads.Add(1)
+				if d := int64(time.Since(start)); d > slowest.Load() {
+					slowest.Store(d)
+				}
+			}
+		}()
+	}
+
+	err := <-imported
+	done.Store(true)
+	wg.Wait()
+	close(errs)
+
+	if err != nil {
+		t.Fatalf("importKGD: %v", err)
+	}
+	for err := range errs {
+		t.Errorf("read during import: %v", err)
+	}
+	if reads.Load() == 0 {
+		t.Error("no reads completed during import")
+	}
+	t.Logf("reads during import: %d, slowest: %v", reads.Load(), time.Duration(slowest.Load()))
+
+	volumes, err := repo.GetVolumes(ctx, "TOYOTA", "CAMRY")
+	if err != nil {
+		t.Fatalf("GetVolumes: %v", err)
+	}
+	if len(volumes) != 4 {
+		t.Errorf("GetVolumes after import = %v, want 4 volumes", volumes)
+	}
+}
+
+func TestKGDRulesCheck(t *testing.T) {
+	rules := kgdRules{MinRows: 3, MaxPriceChange: 30, RequiredMarks: []string{"toyota", " Hyundai "}}
+	marks := map[string]bool{"TOYOTA": true, "HYUNDAI": true}
+	tests := []struct {
+		name  string
+		stats repository.StagingStats
+		want  []string
+	}{
+		{"ok", repository.StagingStats{Rows: 3, Marks: marks, Matched: 3, OldAmount: 100, NewAmount: 129}, nil},
+		{"first import", repository.StagingStats{Rows: 3, Marks: marks}, nil},
+		{"too few rows", repository.StagingStats{Rows: 2, Marks: marks}, []string{"строк 2"}},
+		{"missing mark", repository.StagingStats{Rows: 3, Marks: map[string]bool{"TOYOTA": true}}, []string{"нет марок: HYUNDAI"}},
+		{"price drop", repository.StagingStats{Rows: 3, Marks: marks, Matched: 3, OldAmount: 100, NewAmount: 50}, []string{"на 50.0%"}},
+		{"all at once", repository.StagingStats{Rows: 1, Marks: map[string]bool{}, Matched: 1, OldAmount: 100, NewAmount: 200}, []string{"строк 1", "TOYOTA, HYUNDAI", "на 100.0%"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.check(tt.stats)
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Fatalf("check = %v, want errors %q", err, tt.want)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, errKGDRejected) {
+				t.Errorf("check = %v, want errKGDRejected", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("check = %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMotor(t *testing.T) {
+	tests := []struct {
+		cell, variant string
+		want          motor
+	}{
+		{" 2,494 ", "CAMRY", motor{EngineType: "ice", Volume: 2494}},
+		{"2494", "CAMRY HYBRID", motor{EngineType: "hybrid", Volume: 2494}},
+		{"1 598 гибрид", "NIRO", motor{EngineType: "hybrid", Volume: 1598}},
+		{"1998", "OUTLANDER PHEV", motor{EngineType: "hybrid", Volume: 1998}},
+		{"1598", "CHEVROLET", motor{EngineType: "ice", Volume: 1598}},
+		{"Электро", "IONIQ 5", motor{EngineType: "ev"}},
+		{"Электро 239 кВт, 77,4 кВт*ч", "IONIQ 5", motor{EngineType: "ev", BatteryCapacity: 77.4, Power: 239}},
+		{"электродвигатель 60kWh 150kW", "ATTO 3", motor{EngineType: "ev", BatteryCapacity: 60, Power: 150}},
+	}
+	for _, tt := range tests {
+		got, err := parseMotor(tt.cell, tt.variant)
+		if err != nil {
+			t.Errorf("parseMotor(%q, %q): %v", tt.cell, tt.variant, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseMotor(%q, %q) = %+v, want %+v", tt.cell, tt.variant, got, tt.want)
+		}
+	}
+
+	if _, err := parseMotor("V8", "G 63"); err == nil {
+		t.Error("parseMotor(V8) = nil error, want a volume error")
+	}
+}
+
+func TestParseKGDFileVehicleClass(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	rows := [][]any{
+		{"№", "Марка", "Модель", "Объем", "Год", "Стоимость", "Класс"},
+		{1, "TOYOTA", "CAMRY", 2500, 2022, 25000},
+		{2, "TOYOTA", "HILUX", 2800, 2021, 30000, " n1 "},
+		{3, "HONDA", "CBR 600", 600, 2020, 9000, "L"},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	parsed, err := parseKGDFile(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseKGDFile: %v", err)
+	}
+	classes := make([]string, 0, len(parsed))
+	for _, row := range parsed {
+		classes = append(classes, row.VehicleClass)
+	}
+	if want := "M1 N1 L"; strings.Join(classes, " ") != want {
+		t.Errorf("classes = %q, want %q", classes, want)
+	}
+
+	f.SetCellValue(sheet, "G4", "M3")
+	buf.Reset()
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if _, err := parseKGDFile(buf.Bytes()); err == nil || !strings.Contains(err.Error(), "строка 4") {
+		t.Errorf("parseKGDFile with class M3 = %v, want a row 4 error", err)
+	}
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+// recalculatePopularity периодически пересчитывает популярность марок и
+// моделей по сохраненным расчетам, первый раз - сразу после старта.
+func recalculatePopularity(ctx context.Context, uc usecase.UseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updated, err := uc.RecalculatePopularity(ctx)
+		if err != nil {
+			slog.Error("RecalculatePopularity", slog.String("err", err.Error()))
+		} else {
+			slog.Info("popularity recalculated", slog.Int64("rows", updated))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,41 +1,149 @@
 package app
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
 )
 
 type telegramBot struct {
-	token     string
-	channelID string
+	bot            *tgbotapi.BotAPI
+	channelID      string
+	managersChatID int64
+	adminChatID    int64
+	useCase        usecase.UseCase
 }
 
-func NewTelegramBot(token string) telegramBot {
-	return telegramBot{
-		token: token,
-	}
-}
+const (
+	// telegramConnectTimeout ограничивает подключение бота при старте: без
+	// него недоступный Telegram задержал бы запуск HTTP-сервера.
+	telegramConnectTimeout = 10 * time.Second
+	// updatesTimeout - long polling getUpdates в секундах; клиенту нужно
+	// ждать ответа чуть дольше.
+	updatesTimeout  = 60
+	telegramTimeout = (updatesTimeout + 30) * time.Second
+)
 
-func (tb telegramBot) Init() error {
-	bot, err := tgbotapi.NewBotAPI(tb.token)
+// NewTelegramBot подключает бота. Служебные сообщения уходят в adminChatID,
+// а если он не задан - в чат менеджеров.
+func NewTelegramBot(token, channelID string, managersChatID, adminChatID int64) (telegramBot, error) {
+	bot, err := tgbotapi.NewBotAPIWithClient(token, &http.Client{Timeout: telegramConnectTimeout})
 	if err != nil {
-		return err
+		return telegramBot{}, err
 	}
+	bot.Client = &http.Client{Timeout: telegramTimeout}
 
 	bot.Debug = true
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
+	return telegramBot{
+		bot:            bot,
+		channelID:      channelID,
+		managersChatID: managersChatID,
+		adminChatID:    cmp.Or(adminChatID, managersChatID),
+	}, nil
+}
+
+// Send отправляет текстовое сообщение в чат.
+func (tb telegramBot) Send(chatID int64, text string) error {
+	_, err := tb.bot.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// SendToChannel отправляет сообщение в канал из TELEGRAM_CHANNEL_ID.
+func (tb telegramBot) SendToChannel(text string) error {
+	if tb.channelID == "" {
+		return errors.New("TELEGRAM_CHANNEL_ID is not set")
+	}
+
+	if chatID, err := strconv.ParseInt(tb.channelID, 10, 64); err == nil {
+		return tb.Send(chatID, text)
+	}
+	_, err := tb.bot.Send(tgbotapi.NewMessageToChannel(tb.channelID, text))
+	return err
+}
+
+// NotifyLead пересылает новую заявку в чат менеджеров.
+func (tb telegramBot) NotifyLead(ctx context.Context, lead usecase.Lead) error {
+	if tb.managersChatID == 0 {
+		return errors.New("TELEGRAM_MANAGERS_CHAT_ID is not set")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Новая заявка #%d\nИмя: %s\nТелефон: %s\n", lead.ID, lead.Name, lead.Phone)
+	if lead.TelegramUser != "" {
+		fmt.Fprintf(&b, "Telegram: @%s\n", lead.TelegramUser)
+	}
+	if lead.Mark != "" {
+		fmt.Fprintf(&b, "Авто: %s %s", lead.Mark, lead.Model)
+		if lead.Volume != 0 {
+			fmt.Fprintf(&b, ", %d см³", lead.Volume)
+		}
+		if lead.Year != 0 {
+			fmt.Fprintf(&b, ", %d", lead.Year)
+		}
+		b.WriteString("\n")
+	}
+	if len(lead.Assessment) > 0 {
+		b.WriteString("Расчет приложен к заявке в админке\n")
+	}
+	return tb.Send(tb.managersChatID, b.String())
+}
+
+// NotifyAdmins отправляет служебное сообщение, например об импорте КГД.
+func (tb telegramBot) NotifyAdmins(text string) error {
+	if tb.adminChatID == 0 {
+		return errors.New("TELEGRAM_ADMIN_CHAT_ID is not set")
+	}
+	return tb.Send(tb.adminChatID, text)
+}
+
+func (tb telegramBot) Init() error {
+	bot := tb.bot
+
 	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	u.Timeout = updatesTimeout
 
 	updates, err := bot.GetUpdatesChan(u)
+	if err != nil {
+		return err
+	}
 
 	for update := range updates {
+		if update.InlineQuery != nil {
+			if err := tb.answerInlineQuery(bot, update.InlineQuery); err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+
 		if update.Message == nil {
 			continue
 		}
 
+		if update.Message.IsCommand() {
+			switch update.Message.Command() {
+			case "watch":
+				tb.reply(update.Message, tb.handleWatch(update.Message))
+				continue
+			case "watches":
+				tb.reply(update.Message, tb.handleWatches(update.Message))
+				continue
+			case "unwatch":
+				tb.reply(update.Message, tb.handleUnwatch(update.Message))
+				continue
+			}
+		}
+
 		// Команда для открытия Web App
 		if update.Message.Text == "/start" {
 			userID := update.Message.From.ID
@@ -61,6 +169,44 @@ func (tb telegramBot) Init() error {
 	return nil
 }
 
+// inlineQueryTimeout - Telegram ждет ответа на inline-запрос несколько секунд,
+// дольше искать нет смысла.
+const inlineQueryTimeout = 10 * time.Second
+
+// answerInlineQuery отвечает на запрос вида "@bot camry 2020 2.5" карточками с
+// ценой. Карточки - простой текст: названия из КГД могут содержать символы
+// разметки, и Telegram отклонил бы весь ответ.
+func (tb telegramBot) answerInlineQuery(bot *tgbotapi.BotAPI, query *tgbotapi.InlineQuery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), inlineQueryTimeout)
+	defer cancel()
+
+	quotes, err := tb.useCase.SearchQuotes(ctx, query.Query)
+	if err != nil {
+		return fmt.Errorf("SearchQuotes -> %v", err)
+	}
+
+	results := make([]interface{}, 0, len(quotes))
+	for i, q := range quotes {
+		title := fmt.Sprintf("%s %s, %d", q.Mark, q.Model, q.Year)
+		description := fmt.Sprintf("%d см³ · КГД $%s · под ключ ~%s ₸", q.Volume, formatAmount(q.Amount), formatAmount(q.TurnkeyAmount))
+		text := fmt.Sprintf(
+			"%s %s\nГод: %d\nОбъем: %d см³\nОценка КГД: $%s\nКурс: %.2f ₸\nПод ключ (оценка, без доставки): %s ₸",
+			q.Mark, q.Model, q.Year, q.Volume, formatAmount(q.Amount), q.Rate, formatAmount(q.TurnkeyAmount),
+		)
+
+		article := tgbotapi.NewInlineQueryResultArticle(strconv.Itoa(i), title, text)
+		article.Description = description
+		results = append(results, article)
+	}
+
+	_, err = bot.AnswerInlineQuery(tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     300,
+	})
+	return err
+}
+
 func (tb telegramBot) isUserSubscribed(bot *tgbotapi.BotAPI, userID int) (bool, error) {
 
 	// Используем метод getChatMember для проверки статуса пользователя в канале
@@ -79,3 +225,21 @@ func (tb telegramBot) isUserSubscribed(bot *tgbotapi.BotAPI, userID int) (bool,
 
 	return false, nil
 }
+
+// formatAmount разбивает сумму на разряды пробелами: 12345678 -> "12 345 678".
+func formatAmount(amount int) string {
+	digits := strconv.Itoa(amount)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
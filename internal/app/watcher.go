@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+)
+
+const watchUsage = "Формат: /watch МАРКА МОДЕЛЬ ОБЪЕМ ГОД [ПОРОГ ₸] [ЦЕНА $]\nНапример: /watch TOYOTA CAMRY 2500 2020 150000"
+
+// priceWatcher пересчитывает подписки после загрузки нового списка КГД
+// и когда курс USD/KZT сдвинулся больше чем на rateThreshold процентов.
+type priceWatcher struct {
+	useCase       usecase.UseCase
+	external      external.Client
+	bot           telegramBot
+	interval      time.Duration
+	rateThreshold float64
+	trigger       chan struct{}
+}
+
+func newPriceWatcher(uc usecase.UseCase, ext external.Client, bot telegramBot, interval time.Duration, rateThreshold float64) priceWatcher {
+	return priceWatcher{
+		useCase:       uc,
+		external:      ext,
+		bot:           bot,
+		interval:      interval,
+		rateThreshold: rateThreshold,
+		trigger:       make(chan struct{}, 1),
+	}
+}
+
+// Trigger просит пересчитать подписки, например после импорта КГД.
+func (w priceWatcher) Trigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (w priceWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var lastRate float64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.trigger:
+			w.check(ctx)
+		case <-ticker.C:
+			currency, err := w.external.GetCurrency(ctx)
+			if err != nil {
+				slog.Error("GetCurrency", slog.String("err", err.Error()))
+				continue
+			}
+
+			rate := currency.Rates.KZT
+			if lastRate == 0 {
+				lastRate = rate
+				continue
+			}
+			if math.Abs(rate-lastRate)/lastRate*100 < w.rateThreshold {
+				continue
+			}
+
+			slog.Info("rate moved", slog.Float64("from", lastRate), slog.Float64("to", rate))
+			lastRate = rate
+			w.check(ctx)
+		}
+	}
+}
+
+func (w priceWatcher) check(ctx context.Context) {
+	changes, err := w.useCase.CheckSubscriptions(ctx)
+	if err != nil {
+		slog.Error("CheckSubscriptions", slog.String("err", err.Error()))
+	}
+
+	for _, c := range changes {
+		s := c.Subscription
+		diff := c.NewAmount - c.OldAmount
+		sign := "+"
+		if diff < 0 {
+			sign = ""
+		}
+		text := fmt.Sprintf(
+			"Цена под ключ изменилась\n%s %s, %d см³, %d\n%s ₸ → %s ₸ (%s%s ₸)",
+			s.Mark, s.Model, s.Volume, s.Year,
+			formatAmount(c.OldAmount), formatAmount(c.NewAmount), sign, formatAmount(diff),
+		)
+		if err := w.bot.Send(s.ChatID, text); err != nil {
+			slog.Error("bot.Send", slog.String("err", err.Error()))
+			continue
+		}
+		if err := w.useCase.ConfirmPriceChange(ctx, c); err != nil {
+			slog.Error("ConfirmPriceChange", slog.String("err", err.Error()))
+		}
+	}
+}
+
+func (tb telegramBot) reply(message *tgbotapi.Message, text string) {
+	if _, err := tb.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text)); err != nil {
+		slog.Error("bot.Send", slog.String("err", err.Error()))
+	}
+}
+
+// handleWatch разбирает "/watch TOYOTA LAND CRUISER 4608 2020 150000 45000":
+// модель - все слова до первого числа, дальше объем, год, порог и цена в долларах.
+func (tb telegramBot) handleWatch(message *tgbotapi.Message) string {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) < 4 {
+		return watchUsage
+	}
+
+	numbersFrom := len(args)
+	for i := 1; i < len(args); i++ {
+		if _, err := strconv.Atoi(args[i]); err == nil {
+			numbersFrom = i
+			break
+		}
+	}
+
+	numbers := make([]int, 0, 4)
+	for _, arg := range args[numbersFrom:] {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return watchUsage
+		}
+		numbers = append(numbers, n)
+	}
+	if numbersFrom == 1 || len(numbers) < 2 || len(numbers) > 4 {
+		return watchUsage
+	}
+
+	s := usecase.Subscription{
+		ChatID: message.Chat.ID,
+		Mark:   args[0],
+		Model:  strings.Join(args[1:numbersFrom], " "),
+		Volume: numbers[0],
+		Year:   numbers[1],
+	}
+	if len(numbers) > 2 {
+		s.Threshold = numbers[2]
+	}
+	if len(numbers) > 3 {
+		s.PriceUSD = numbers[3]
+	}
+
+	s, err := tb.useCase.Watch(context.Background(), s)
+	if errors.Is(err, usecase.ErrNotFound) {
+		return "Такого авто нет в списке КГД"
+	}
+	if err != nil {
+		slog.Error("Watch", slog.String("err", err.Error()))
+		return "Не удалось оформить подписку, попробуйте позже"
+	}
+
+	return fmt.Sprintf(
+		"Подписка #%d оформлена: %s %s, %d см³, %d\nСейчас под ключ: %s ₸. Сообщу, если цена изменится больше чем на %s ₸",
+		s.ID, s.Mark, s.Model, s.Volume, s.Year, formatAmount(s.LastAmount), formatAmount(s.Threshold),
+	)
+}
+
+func (tb telegramBot) handleWatches(message *tgbotapi.Message) string {
+	subscriptions, err := tb.useCase.GetSubscriptions(context.Background(), message.Chat.ID)
+	if err != nil {
+		slog.Error("GetSubscriptions", slog.String("err", err.Error()))
+		return "Не удалось получить подписки, попробуйте позже"
+	}
+	if len(subscriptions) == 0 {
+		return "Подписок нет. " + watchUsage
+	}
+
+	var b strings.Builder
+	for _, s := range subscriptions {
+		fmt.Fprintf(&b, "#%d %s %s, %d см³, %d - %s ₸\n", s.ID, s.Mark, s.Model, s.Volume, s.Year, formatAmount(s.LastAmount))
+	}
+	b.WriteString("Отписаться: /unwatch НОМЕР")
+	return b.String()
+}
+
+func (tb telegramBot) handleUnwatch(message *tgbotapi.Message) string {
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "#"), 10, 64)
+	if err != nil {
+		return "Формат: /unwatch НОМЕР"
+	}
+
+	err = tb.useCase.Unwatch(context.Background(), message.Chat.ID, id)
+	if errors.Is(err, usecase.ErrNotFound) {
+		return "Подписка не найдена"
+	}
+	if err != nil {
+		slog.Error("Unwatch", slog.String("err", err.Error()))
+		return "Не удалось отписаться, попробуйте позже"
+	}
+	return fmt.Sprintf("Подписка #%d удалена", id)
+}
@@ -1,6 +1,28 @@
 package config
 
-import "github.com/caarlos0/env/v6"
+import (
+	"errors"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// Database - настройки подключения к базе. Их же читает служебная команда
+// kgd, которой остальные переменные сервера не нужны.
+type Database struct {
+	// DatabaseDSN - строка подключения к базе: postgres://... для PostgreSQL или
+	// путь к файлу SQLite. Пустое значение - файл из SQLITE_PATH.
+	DatabaseDSN string `env:"DATABASE_DSN"`
+	SqlitePath  string `env:"SQLITE_PATH"`
+
+	// DBQueryTimeout - предельное время одного запроса к базе, 0 - без ограничения.
+	DBQueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT" envDefault:"5s"`
+
+	// SQLiteBusyTimeout - сколько запрос к SQLite ждет блокировку писателя.
+	// SQLiteReadConns - размер пула чтения SQLite, 0 - по числу CPU.
+	SQLiteBusyTimeout time.Duration `env:"SQLITE_BUSY_TIMEOUT" envDefault:"5s"`
+	SQLiteReadConns   int           `env:"SQLITE_READ_CONNS"`
+}
 
 type Config struct {
 	ServerAddr          string `env:"HTTP_PORT" envDefault:":8080"`
@@ -8,7 +30,56 @@ type Config struct {
 	KGDURL              string `env:"KGD_URL,required"`
 	OpenExchangeRateURL string `env:"OPEN_EXCHANGE_RATE_URL,required"`
 	AssetsDir           string `env:"FRONT_FILES_PATH,required"`
-	SqlitePath          string `env:"SQLITE_PATH,required"`
+
+	Database
+
+	// TelegramChannelID - канал для дайджестов и проверки подписки: @username или числовой id.
+	TelegramChannelID string `env:"TELEGRAM_CHANNEL_ID"`
+	// TelegramManagersChatID - чат менеджеров, куда пересылаются заявки.
+	TelegramManagersChatID int64 `env:"TELEGRAM_MANAGERS_CHAT_ID"`
+	// TelegramAdminChatID - чат для служебных сообщений, по умолчанию чат менеджеров.
+	TelegramAdminChatID int64 `env:"TELEGRAM_ADMIN_CHAT_ID"`
+	// AdminToken - Bearer-токен для /api/v1/admin, пустое значение закрывает админку.
+	AdminToken string `env:"ADMIN_TOKEN"`
+
+	// WatchInterval - как часто проверять курс для подписок на изменение цены.
+	WatchInterval time.Duration `env:"WATCH_INTERVAL" envDefault:"1h"`
+	// WatchRateThreshold - изменение курса USD/KZT в процентах, после которого
+	// пересчитываются подписки.
+	WatchRateThreshold float64 `env:"WATCH_RATE_THRESHOLD" envDefault:"1"`
+
+	// DigestSchedule - cron-расписание ежедневного дайджеста курсов, пустое значение выключает его.
+	DigestSchedule string `env:"DIGEST_SCHEDULE" envDefault:"0 9 * * *"`
+	// DigestTemplate - text/template сообщения, по умолчанию используется встроенный шаблон.
+	DigestTemplate    string `env:"DIGEST_TEMPLATE"`
+	DigestCarMark     string `env:"DIGEST_CAR_MARK" envDefault:"TOYOTA"`
+	DigestCarModel    string `env:"DIGEST_CAR_MODEL" envDefault:"CAMRY"`
+	DigestCarVolume   int    `env:"DIGEST_CAR_VOLUME" envDefault:"2500"`
+	DigestCarPriceUSD int    `env:"DIGEST_CAR_PRICE_USD"`
+
+	// PopularityInterval - как часто пересчитывать популярность марок и моделей
+	// по количеству расчетов, нулевое значение оставляет только ручные веса.
+	PopularityInterval time.Duration `env:"POPULARITY_INTERVAL"`
+
+	// KGDPageURL - страница КГД, на которой ищется ссылка на свежий файл;
+	// пустое значение - проверяется сам KGD_URL.
+	KGDPageURL string `env:"KGD_PAGE_URL"`
+	// KGDLinkPattern - регулярное выражение для адреса или текста ссылки на
+	// файл, например "(?i)легков"; пустое значение - любая ссылка на Excel.
+	// Из подходящих ссылок берется самая свежая по дате.
+	KGDLinkPattern string `env:"KGD_LINK_PATTERN"`
+	// KGDCheckInterval - как часто проверять новый список КГД, 0 - только при старте.
+	KGDCheckInterval time.Duration `env:"KGD_CHECK_INTERVAL" envDefault:"6h"`
+	// KGDMinRows - сколько строк должно быть в новом списке как минимум.
+	KGDMinRows int `env:"KGD_MIN_ROWS" envDefault:"1000"`
+	// KGDMaxPriceChange - на сколько процентов в среднем могут измениться
+	// стоимости моделей, которые есть и в прежнем списке.
+	KGDMaxPriceChange float64 `env:"KGD_MAX_PRICE_CHANGE" envDefault:"30"`
+	// KGDRequiredMarks - марки, без которых новый список не загружается.
+	KGDRequiredMarks []string `env:"KGD_REQUIRED_MARKS" envSeparator:"," envDefault:"TOYOTA,HYUNDAI"`
+	// CatalogSyncInterval - как часто сервер сверяет снимок справочника с
+	// последней загрузкой в базе, чтобы подхватить откат командой kgd rollback.
+	CatalogSyncInterval time.Duration `env:"CATALOG_SYNC_INTERVAL" envDefault:"30s"`
 }
 
 func Get() (Config, error) {
@@ -16,7 +87,28 @@ func Get() (Config, error) {
 	if err := cfg.readFromEnvironment(); err != nil {
 		return cfg, err
 	}
-	return cfg, nil
+	err := cfg.Database.resolveDSN()
+	return cfg, err
+}
+
+// GetDatabase читает только настройки базы.
+func GetDatabase() (Database, error) {
+	cfg := Database{}
+	if err := env.Parse(&cfg); err != nil {
+		return cfg, err
+	}
+	err := cfg.resolveDSN()
+	return cfg, err
+}
+
+func (d *Database) resolveDSN() error {
+	if d.DatabaseDSN == "" {
+		d.DatabaseDSN = d.SqlitePath
+	}
+	if d.DatabaseDSN == "" {
+		return errors.New(`environment variable "DATABASE_DSN" or "SQLITE_PATH" should be set`)
+	}
+	return nil
 }
 
 // readFromEnvironment reads the settings from environment variables.
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/xuri/excelize/v2"
+)
+
+// batchUploadLimit ограничивает тело пакетного расчета: списка на сотню машин
+// хватает с большим запасом.
+const batchUploadLimit = 5 << 20
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// batchItemRequest - позиция пакетного расчета. В CSV и XLSX колонки
+// называются так же, как поля JSON.
+type batchItemRequest struct {
+	Mark         string `json:"mark"`
+	Model        string `json:"model"`
+	Volume       int    `json:"volume"`
+	Year         int    `json:"year"`
+	Price        int    `json:"price"`
+	Currency     string `json:"currency"`
+	Destination  string `json:"destination"`
+	Origin       string `json:"origin"`
+	EngineType   string `json:"engine_type"`
+	VehicleClass string `json:"vehicle_class"`
+}
+
+// handlerAssessmentBatch принимает список машин массивом JSON, файлом CSV или
+// XLSX в теле запроса либо в поле file формы multipart.
+func (h homeHandler) handlerAssessmentBatch(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, batchUploadLimit)
+
+	items, err := readBatchItems(r)
+	if err != nil {
+		return err
+	}
+	inputs := make([]usecase.AssessmentInput, 0, len(items))
+	for _, item := range items {
+		inputs = append(inputs, usecase.AssessmentInput{
+			Mark:         item.Mark,
+			Model:        item.Model,
+			Amount:       item.Price,
+			Volume:       item.Volume,
+			Year:         item.Year,
+			Origin:       item.Origin,
+			Currency:     item.Currency,
+			Destination:  item.Destination,
+			EngineType:   item.EngineType,
+			VehicleClass: item.VehicleClass,
+		})
+	}
+
+	results, err := h.useCase.AssessmentBatch(r.Context(), inputs)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, results)
+}
+
+func readBatchItems(r *http.Request) ([]batchItemRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json":
+		items := make([]batchItemRequest, 0)
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			return nil, fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+		}
+		return items, nil
+	case "text/csv":
+		return parseBatchCSV(r.Body)
+	case xlsxContentType:
+		return parseBatchXLSX(r.Body)
+	case "multipart/form-data":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("%w: file -> %v", usecase.ErrInvalidArgument, err)
+		}
+		defer file.Close()
+
+		switch strings.ToLower(path.Ext(header.Filename)) {
+		case ".csv":
+			return parseBatchCSV(file)
+		case ".xlsx":
+			return parseBatchXLSX(file)
+		}
+		return nil, fmt.Errorf("%w: file must be .csv or .xlsx, got %q", usecase.ErrInvalidArgument, header.Filename)
+	}
+	return nil, fmt.Errorf("%w: unsupported content type %s", usecase.ErrInvalidArgument, mediaType)
+}
+
+func parseBatchCSV(body io.Reader) ([]batchItemRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: csv -> %v", usecase.ErrInvalidArgument, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	// Excel в русской локали сохраняет CSV через точку с запятой
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: csv -> %v", usecase.ErrInvalidArgument, err)
+	}
+	return parseBatchRows(rows)
+}
+
+func parseBatchXLSX(body io.Reader) ([]batchItemRequest, error) {
+	f, err := excelize.OpenReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: xlsx -> %v", usecase.ErrInvalidArgument, err)
+	}
+	defer f.Close()
+
+	sheetList := f.GetSheetList()
+	if len(sheetList) == 0 {
+		return nil, fmt.Errorf("%w: xlsx has no sheets", usecase.ErrInvalidArgument)
+	}
+	rows, err := f.GetRows(sheetList[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: xlsx -> %v", usecase.ErrInvalidArgument, err)
+	}
+	return parseBatchRows(rows)
+}
+
+// parseBatchRows разбирает таблицу с заголовком в первой строке. Порядок колонок
+// любой, регистр заголовков не важен, пустые строки пропускаются.
+func parseBatchRows(rows [][]string) ([]batchItemRequest, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", usecase.ErrInvalidArgument)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"mark", "model", "volume", "year", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: no %s column", usecase.ErrInvalidArgument, name)
+		}
+	}
+
+	items := make([]batchItemRequest, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		cell := func(name string) string {
+			column, ok := columns[name]
+			if !ok || column >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[column])
+		}
+		number := func(name string) (int, error) {
+			value := strings.NewReplacer(" ", "", "\u00a0", "", ",", "").Replace(cell(name))
+			if value == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("%w: row %d: %s -> %v", usecase.ErrInvalidArgument, i+2, name, err)
+			}
+			return n, nil
+		}
+
+		item := batchItemRequest{
+			Mark:         cell("mark"),
+			Model:        cell("model"),
+			Currency:     cell("currency"),
+			Destination:  cell("destination"),
+			Origin:       cell("origin"),
+			EngineType:   cell("engine_type"),
+			VehicleClass: cell("vehicle_class"),
+		}
+		var err error
+		if item.Volume, err = number("volume"); err != nil {
+			return nil, err
+		}
+		if item.Year, err = number("year"); err != nil {
+			return nil, err
+		}
+		if item.Price, err = number("price"); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
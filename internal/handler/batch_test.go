@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/xuri/excelize/v2"
+)
+
+// multipartFile собирает форму с файлом в поле file, как ее отправляет браузер.
+func multipartFile(t *testing.T, filename string, data []byte) (string, []byte) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	fw.Write(data)
+	if err := mw.Close(); err != nil {
+		t.Fatalf("multipart Close: %v", err)
+	}
+	return mw.FormDataContentType(), body.Bytes()
+}
+
+func xlsxFile(t *testing.T, rows ...[]any) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("WriteToBuffer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadBatchItems(t *testing.T) {
+	camry := batchItemRequest{Mark: "Toyota", Model: "Camry", Volume: 2500, Year: 2020, Price: 20000}
+	kia := batchItemRequest{Mark: "Kia", Model: "K5", Volume: 2000, Year: 2021, Price: 15000, Currency: "AED", Destination: "Астана"}
+	csvFile := []byte("mark,model,volume,year,price\nToyota,Camry,2500,2020,20000\n")
+	xlsxContent := xlsxFile(t,
+		[]any{"mark", "model", "volume", "year", "price"},
+		[]any{"Toyota", "Camry", 2500, 2020, 20000},
+	)
+
+	type request struct {
+		contentType string
+		body        []byte
+	}
+	multipartRequest := func(filename string, data []byte) request {
+		contentType, body := multipartFile(t, filename, data)
+		return request{contentType, body}
+	}
+
+	tests := []struct {
+		name    string
+		request request
+		want    []batchItemRequest
+		wantErr string
+	}{
+		{
+			name:    "json",
+			request: request{"application/json", []byte(`[{"mark":"Toyota","model":"Camry","volume":2500,"year":2020,"price":20000}]`)},
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "csv with commas",
+			request: request{"text/csv", csvFile},
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "csv with semicolons from Excel",
+			request: request{"text/csv; charset=utf-8", []byte("mark;model;volume;year;price\nToyota;Camry;2 500;2020;20,000\n")},
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "separator is taken from the header",
+			request: request{"text/csv", []byte("mark;model;volume;year;price\nToyota;Camry, Hybrid;2500;2020;20000\n")},
+			want:    []batchItemRequest{{Mark: "Toyota", Model: "Camry, Hybrid", Volume: 2500, Year: 2020, Price: 20000}},
+		},
+		{
+			name:    "bom, column order and header case",
+			request: request{"text/csv", []byte("\ufeffPrice;YEAR;Mark; Model ;Volume;Currency;Destination\n15000;2021;Kia;K5;2000;AED;Астана\n")},
+			want:    []batchItemRequest{kia},
+		},
+		{
+			name:    "blank rows are skipped",
+			request: request{"text/csv", []byte("mark,model,volume,year,price\n\nToyota,Camry,2500,2020,20000\n,,,,\n  , ,,,\n")},
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "blank volume cell is zero",
+			request: request{"text/csv", []byte("mark,model,volume,year,price\nKia,K5,,2021,15000\n")},
+			want:    []batchItemRequest{{Mark: "Kia", Model: "K5", Year: 2021, Price: 15000}},
+		},
+		{
+			name:    "error names the row",
+			request: request{"text/csv", []byte("mark,model,volume,year,price\nToyota,Camry,2500,2020,20000\nKia,K5,2000,2021г,15000\n")},
+			wantErr: "row 3: year",
+		},
+		{
+			name:    "required column is missing",
+			request: request{"text/csv", []byte("mark,model,year,price\nToyota,Camry,2020,20000\n")},
+			wantErr: "no volume column",
+		},
+		{
+			name:    "empty file",
+			request: request{"text/csv", nil},
+			wantErr: "file is empty",
+		},
+		{
+			name:    "xlsx body",
+			request: request{xlsxContentType, xlsxContent},
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "multipart csv",
+			request: multipartRequest("cars.CSV", csvFile),
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "multipart xlsx",
+			request: multipartRequest("cars.xlsx", xlsxContent),
+			want:    []batchItemRequest{camry},
+		},
+		{
+			name:    "multipart with an unknown extension",
+			request: multipartRequest("cars.txt", csvFile),
+			wantErr: "file must be .csv or .xlsx",
+		},
+		{
+			name:    "unsupported content type",
+			request: request{"text/plain", csvFile},
+			wantErr: "unsupported content type",
+		},
+		{
+			name:    "body over the limit",
+			request: request{"text/csv", append(csvFile, bytes.Repeat([]byte("Toyota,Camry,2500,2020,20000\n"), batchUploadLimit/20)...)},
+			wantErr: "request body too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/assessments:batch", bytes.NewReader(tt.request.body))
+			r.Header.Set("Content-Type", tt.request.contentType)
+			r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, batchUploadLimit)
+
+			items, err := readBatchItems(r)
+			if tt.wantErr != "" {
+				if !errors.Is(err, usecase.ErrInvalidArgument) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readBatchItems error = %v, want invalid argument with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readBatchItems: %v", err)
+			}
+			if !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("readBatchItems = %+v, want %+v", items, tt.want)
+			}
+		})
+	}
+}
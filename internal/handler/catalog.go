@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+type catalogHandler struct {
+	useCase usecase.UseCase
+	// body - готовый ответ для текущей версии справочника, пересобирается при ее смене
+	body *atomic.Pointer[catalogBody]
+}
+
+type catalogBody struct {
+	etag    string
+	json    []byte
+	gzipped []byte
+}
+
+// catalogResponse - дерево справочника в компактном виде: годы передаются
+// парами [год, стоимость], написания модели - только если они есть, классы
+// модели - только если среди них есть не легковой, типы двигателя объема -
+// только если среди них есть не ДВС.
+type catalogResponse struct {
+	Version  string        `json:"version"`
+	ImportID int64         `json:"import_id"`
+	Marks    []catalogMark `json:"marks"`
+}
+
+type catalogMark struct {
+	Name   string         `json:"name"`
+	Models []catalogModel `json:"models"`
+}
+
+type catalogModel struct {
+	Name           string          `json:"name"`
+	Variants       []string        `json:"variants,omitempty"`
+	VehicleClasses []string        `json:"vehicle_classes,omitempty"`
+	Volumes        []catalogVolume `json:"volumes"`
+}
+
+type catalogVolume struct {
+	Volume      int      `json:"volume"`
+	EngineTypes []string `json:"engine_types,omitempty"`
+	Years       [][2]int `json:"years"`
+}
+
+// handlerTree отдает весь справочник одним ответом: /api/v1/catalog. Клиент
+// хранит его у себя и переспрашивает с If-None-Match; пока не было нового
+// импорта или правки моделей, ответ - 304 без тела. engine_type=ev,hybrid
+// оставляет только комплектации с этими типами двигателя, vehicle_class=N1,L -
+// только с этими классами транспорта.
+func (h catalogHandler) handlerTree(w http.ResponseWriter, r *http.Request) error {
+	filter := usecase.CatalogFilter{}
+	for _, value := range r.URL.Query()["engine_type"] {
+		filter.EngineTypes = append(filter.EngineTypes, strings.Split(value, ",")...)
+	}
+	for _, value := range r.URL.Query()["vehicle_class"] {
+		filter.VehicleClasses = append(filter.VehicleClasses, strings.Split(value, ",")...)
+	}
+	tree, err := h.useCase.GetCatalogTree(r.Context(), filter)
+	if err != nil {
+		return err
+	}
+	// сжатое и несжатое тело - разные представления, и у каждого свой ETag
+	etag := `"` + tree.Version + `"`
+	gzipped := acceptsGzip(r)
+	responseETag := etag
+	if gzipped {
+		responseETag = `"` + tree.Version + `-gz"`
+	}
+	w.Header().Set("Vary", "Accept-Encoding")
+	if notModified(w, r, responseETag) {
+		return nil
+	}
+
+	// готовым хранится только полный справочник, срезы по фильтру собираются на лету
+	body := h.body.Load()
+	filtered := len(filter.EngineTypes) > 0 || len(filter.VehicleClasses) > 0
+	if filtered || body == nil || body.etag != etag {
+		body, err = newCatalogBody(tree, etag)
+		if err != nil {
+			return err
+		}
+		if !filtered {
+			h.body.Store(body)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if gzipped {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.gzipped)
+		return nil
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.json)
+	return nil
+}
+
+func newCatalogBody(tree usecase.CatalogTree, etag string) (*catalogBody, error) {
+	response := catalogResponse{
+		Version:  tree.Version,
+		ImportID: tree.ImportID,
+		Marks:    make([]catalogMark, 0, len(tree.Marks)),
+	}
+	for _, mark := range tree.Marks {
+		m := catalogMark{Name: mark.Name, Models: make([]catalogModel, 0, len(mark.Models))}
+		for _, model := range mark.Models {
+			cm := catalogModel{Name: model.Name, Variants: model.Variants, Volumes: make([]catalogVolume, 0, len(model.Volumes))}
+			if !slices.Equal(model.VehicleClasses, []string{usecase.ClassM1}) {
+				cm.VehicleClasses = model.VehicleClasses
+			}
+			for _, volume := range model.Volumes {
+				cv := catalogVolume{Volume: volume.Volume, Years: make([][2]int, 0, len(volume.Specifications))}
+				if !slices.Equal(volume.EngineTypes, []string{usecase.EngineICE}) {
+					cv.EngineTypes = volume.EngineTypes
+				}
+				for _, s := range volume.Specifications {
+					cv.Years = append(cv.Years, [2]int{s.Year, s.Amount})
+				}
+				cm.Volumes = append(cm.Volumes, cv)
+			}
+			m.Models = append(m.Models, cm)
+		}
+		response.Marks = append(response.Marks, m)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var gzipped bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&gzipped, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &catalogBody{etag: etag, json: body, gzipped: gzipped.Bytes()}, nil
+}
+
+// acceptsGzip проверяет Accept-Encoding без учета веса, кроме явного gzip;q=0.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -11,8 +15,9 @@ import (
 )
 
 type Dependencies struct {
-	AssetsFS http.FileSystem
-	UseCase  usecase.UseCase
+	AssetsFS   http.FileSystem
+	UseCase    usecase.UseCase
+	AdminToken string
 }
 
 type hadlerFunc func(w http.ResponseWriter, r *http.Request) error
@@ -21,13 +26,32 @@ func RegisterRoutes(r chi.Router, deps Dependencies) {
 	home := homeHandler{
 		deps.UseCase,
 	}
+	subscription := subscriptionHandler{
+		deps.UseCase,
+	}
+	lead := leadHandler{
+		deps.UseCase,
+	}
+	model := modelHandler{
+		deps.UseCase,
+	}
+	popularity := popularityHandler{
+		deps.UseCase,
+	}
+	tariff := tariffHandler{
+		deps.UseCase,
+	}
+	catalog := catalogHandler{
+		useCase: deps.UseCase,
+		body:    &atomic.Pointer[catalogBody]{},
+	}
 	r.Use(middleware.Logger)
 	r.Use(cors.Handler(cors.Options{
 		// Разрешаем все домены
 		AllowedOrigins:   []string{"*"}, // Можете использовать "*"
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300, // Определяет как долго результат запроса может кешироваться (в секундах)
 	}))
@@ -42,6 +66,50 @@ func RegisterRoutes(r chi.Router, deps Dependencies) {
 	r.Get("/transport", handler(home.handlerTransport))
 	r.Post("/assesstment", handler(home.handlerAssessment))
 
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/search", handler(home.handlerSearch))
+		r.Get("/catalog", handler(catalog.handlerTree))
+		r.Get("/vehicles/{mark}/{model}/{volume}/{year}/history", handler(home.handlerHistory))
+		r.Get("/vehicles/{mark}/{model}/{volume}/years", handler(home.handlerYears))
+		r.Get("/vin/{vin}", handler(home.handlerVIN))
+		r.With(rateLimit(leadRateLimit, leadRateWindow)).Post("/leads", handler(lead.handlerCreate))
+		r.Post("/assessments", handler(home.handlerAssessment))
+		r.Post("/assessments:batch", handler(home.handlerAssessmentBatch))
+		r.Get("/assessments/{id}", handler(home.handlerGetAssessment))
+		r.Get("/assessments/{id}.pdf", handler(home.handlerGetAssessmentPDF))
+		r.Get("/assessments/{id}.xlsx", handler(home.handlerGetAssessmentXLSX))
+		r.Get("/kgd/export.xlsx", handler(home.handlerExportKGD))
+		r.Get("/tariffs", handler(tariff.handlerCurrent))
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(adminOnly(deps.AdminToken))
+			// пользователи подписываются через /watch в боте, здесь - для поддержки
+			r.Get("/subscriptions", handler(subscription.handlerList))
+			r.Post("/subscriptions", handler(subscription.handlerCreate))
+			r.Delete("/subscriptions/{id}", handler(subscription.handlerDelete))
+			r.Get("/leads", handler(lead.handlerList))
+			r.Patch("/leads/{id}", handler(lead.handlerUpdateStatus))
+			r.Get("/models/aliases", handler(model.handlerListAliases))
+			r.Delete("/models/aliases", handler(model.handlerDeleteAlias))
+			r.Post("/models/merge", handler(model.handlerMerge))
+			r.Get("/popularity", handler(popularity.handlerList))
+			r.Put("/popularity", handler(popularity.handlerSet))
+			r.Delete("/popularity", handler(popularity.handlerDelete))
+			r.Post("/popularity/recalculate", handler(popularity.handlerRecalculate))
+			r.Get("/delivery-routes", handler(tariff.handlerListDeliveryRoutes))
+			r.Post("/delivery-routes", handler(tariff.handlerCreateDeliveryRoute))
+			r.Put("/delivery-routes/{id}", handler(tariff.handlerUpdateDeliveryRoute))
+			r.Delete("/delivery-routes/{id}", handler(tariff.handlerDeleteDeliveryRoute))
+			r.Get("/broker-tiers", handler(tariff.handlerListBrokerTiers))
+			r.Post("/broker-tiers", handler(tariff.handlerCreateBrokerTier))
+			r.Put("/broker-tiers/{id}", handler(tariff.handlerUpdateBrokerTier))
+			r.Delete("/broker-tiers/{id}", handler(tariff.handlerDeleteBrokerTier))
+			r.Get("/sos-prices", handler(tariff.handlerListSOSPrices))
+			r.Post("/sos-prices", handler(tariff.handlerCreateSOSPrice))
+			r.Put("/sos-prices/{id}", handler(tariff.handlerUpdateSOSPrice))
+			r.Delete("/sos-prices/{id}", handler(tariff.handlerDeleteSOSPrice))
+		})
+	})
 }
 
 func handler(h hadlerFunc) http.HandlerFunc {
@@ -53,6 +121,44 @@ func handler(h hadlerFunc) http.HandlerFunc {
 }
 
 func handlerError(w http.ResponseWriter, r *http.Request, err error) {
-	slog.Error("error during request", slog.String("err", err.Error()))
-	http.Error(w, "Something went wrong", http.StatusInternalServerError)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidArgument):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		slog.Error("error during request", slog.String("err", err.Error()))
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+	}
+}
+
+// notModified ставит ETag и отвечает 304, если у клиента та же версия. Ответ
+// можно хранить, но перед использованием нужно сверить версию.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == etag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+	return nil
 }
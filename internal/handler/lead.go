@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+const (
+	// leadBodyLimit - заявка с приложенным расчетом занимает несколько килобайт
+	leadBodyLimit = 64 << 10
+	// leadRateLimit заявок с одного IP за leadRateWindow
+	leadRateLimit  = 5
+	leadRateWindow = 10 * time.Minute
+)
+
+type leadHandler struct {
+	useCase usecase.UseCase
+}
+
+type leadRequest struct {
+	Name         string          `json:"name"`
+	Phone        string          `json:"phone"`
+	TelegramUser string          `json:"telegram_user"`
+	Mark         string          `json:"mark"`
+	Model        string          `json:"model"`
+	Volume       int             `json:"volume"`
+	Year         int             `json:"year"`
+	Assessment   json.RawMessage `json:"assessment"`
+}
+
+func (h leadHandler) handlerCreate(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, leadBodyLimit)
+	lr := leadRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&lr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	lead, err := h.useCase.CreateLead(r.Context(), usecase.Lead{
+		Name:         lr.Name,
+		Phone:        lr.Phone,
+		TelegramUser: lr.TelegramUser,
+		Mark:         lr.Mark,
+		Model:        lr.Model,
+		Volume:       lr.Volume,
+		Year:         lr.Year,
+		Assessment:   lr.Assessment,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, struct {
+		ID     int64
+		Status string
+	}{lead.ID, lead.Status})
+}
+
+func (h leadHandler) handlerList(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	leads, err := h.useCase.GetLeads(r.Context(), query.Get("status"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, leads)
+}
+
+type leadStatusRequest struct {
+	Status string `json:"status"`
+}
+
+func (h leadHandler) handlerUpdateStatus(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: id -> %v", usecase.ErrInvalidArgument, err)
+	}
+
+	sr := leadStatusRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&sr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	lead, err := h.useCase.ChangeLeadStatus(r.Context(), id, sr.Status)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, lead)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+type modelHandler struct {
+	useCase usecase.UseCase
+}
+
+func (h modelHandler) handlerListAliases(w http.ResponseWriter, r *http.Request) error {
+	aliases, err := h.useCase.GetModelAliases(r.Context(), r.URL.Query().Get("mark"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, aliases)
+}
+
+type modelMergeRequest struct {
+	Mark    string   `json:"mark"`
+	Model   string   `json:"model"`
+	Aliases []string `json:"aliases"`
+}
+
+// handlerMerge сводит написания к одной модели:
+// {"mark": "TOYOTA", "model": "LAND CRUISER PRADO", "aliases": ["LC PRADO 150"]}
+func (h modelHandler) handlerMerge(w http.ResponseWriter, r *http.Request) error {
+	mr := modelMergeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&mr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	merge, err := h.useCase.MergeModels(r.Context(), mr.Mark, mr.Model, mr.Aliases)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, merge)
+}
+
+// handlerDeleteAlias отменяет слияние; написание может содержать пробелы и
+// слеши, поэтому передается в query: ?mark=TOYOTA&alias=LC+PRADO+150
+func (h modelHandler) handlerDeleteAlias(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	if err := h.useCase.DeleteModelAlias(r.Context(), query.Get("mark"), query.Get("alias")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+type popularityHandler struct {
+	useCase usecase.UseCase
+}
+
+func (h popularityHandler) handlerList(w http.ResponseWriter, r *http.Request) error {
+	popularity, err := h.useCase.GetPopularity(r.Context(), r.URL.Query().Get("mark"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, popularity)
+}
+
+type popularityRequest struct {
+	Mark   string `json:"mark"`
+	Model  string `json:"model"`
+	Weight int    `json:"weight"`
+}
+
+// handlerSet задает вес марки ({"mark": "TOYOTA", "weight": 10}) или модели,
+// если передан model.
+func (h popularityHandler) handlerSet(w http.ResponseWriter, r *http.Request) error {
+	pr := popularityRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	popularity, err := h.useCase.SetPopularity(r.Context(), pr.Mark, pr.Model, pr.Weight)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, popularity)
+}
+
+func (h popularityHandler) handlerDelete(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	if err := h.useCase.DeletePopularity(r.Context(), query.Get("mark"), query.Get("model")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h popularityHandler) handlerRecalculate(w http.ResponseWriter, r *http.Request) error {
+	updated, err := h.useCase.RecalculatePopularity(r.Context())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, struct {
+		Updated int64
+	}{updated})
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimit пропускает не больше limit запросов с одного IP за окно window.
+// Счетчики живут в памяти и сбрасываются целиком при смене окна, поэтому
+// карта не растет бесконечно.
+func rateLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	var (
+		mu      sync.Mutex
+		started = time.Now()
+		counts  = make(map[string]int)
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+
+			mu.Lock()
+			now := time.Now()
+			if now.Sub(started) >= window {
+				started = now
+				clear(counts)
+			}
+			counts[ip]++
+			allowed := counts[ip] <= limit
+			retryAfter := window - now.Sub(started)
+			mu.Unlock()
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
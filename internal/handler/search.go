@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+// handlerSearch ищет авто по свободному запросу, например
+// /api/v1/search?q=ленд+крузер+2015 или /api/v1/search?q=LC200&limit=5
+func (h homeHandler) handlerSearch(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		return fmt.Errorf("%w: q is required", usecase.ErrInvalidArgument)
+	}
+	limit, err := optionalInt(query.Get("limit"))
+	if err != nil {
+		return fmt.Errorf("%w: limit -> %v", usecase.ErrInvalidArgument, err)
+	}
+
+	candidates, err := h.useCase.Search(r.Context(), q, limit)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, candidates)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+type subscriptionHandler struct {
+	useCase usecase.UseCase
+}
+
+type subscriptionRequest struct {
+	ChatID    int64  `json:"chat_id"`
+	Mark      string `json:"mark"`
+	Model     string `json:"model"`
+	Volume    int    `json:"volume"`
+	Year      int    `json:"year"`
+	PriceUSD  int    `json:"price_usd"`
+	Threshold int    `json:"threshold"`
+}
+
+func (h subscriptionHandler) handlerCreate(w http.ResponseWriter, r *http.Request) error {
+	sr := subscriptionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&sr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	subscription, err := h.useCase.Watch(r.Context(), usecase.Subscription{
+		ChatID:    sr.ChatID,
+		Mark:      sr.Mark,
+		Model:     sr.Model,
+		Volume:    sr.Volume,
+		Year:      sr.Year,
+		PriceUSD:  sr.PriceUSD,
+		Threshold: sr.Threshold,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, subscription)
+}
+
+func (h subscriptionHandler) handlerList(w http.ResponseWriter, r *http.Request) error {
+	chatID, err := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+	if err != nil || chatID == 0 {
+		return fmt.Errorf("%w: chat_id is required", usecase.ErrInvalidArgument)
+	}
+
+	subscriptions, err := h.useCase.GetSubscriptions(r.Context(), chatID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h subscriptionHandler) handlerDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: id -> %v", usecase.ErrInvalidArgument, err)
+	}
+	chatID, err := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+	if err != nil || chatID == 0 {
+		return fmt.Errorf("%w: chat_id is required", usecase.ErrInvalidArgument)
+	}
+
+	if err := h.useCase.Unwatch(r.Context(), chatID, id); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
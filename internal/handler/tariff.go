@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+type tariffHandler struct {
+	useCase usecase.UseCase
+}
+
+// handlerCurrent отдает действующие сегодня доставку, тарифы брокера и цены
+// SOS, чтобы фронтенд не держал их у себя: /api/v1/tariffs?country=kz&origin=Дубай
+func (h tariffHandler) handlerCurrent(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	tariffs, err := h.useCase.GetTariffs(r.Context(), query.Get("country"), query.Get("origin"), time.Now())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, tariffs)
+}
+
+type deliveryRouteRequest struct {
+	Country     string `json:"country"`
+	FromCity    string `json:"from_city"`
+	ToCity      string `json:"to_city"`
+	Carrier     string `json:"carrier"`
+	Amount      int    `json:"amount"`
+	Currency    string `json:"currency"`
+	TransitDays int    `json:"transit_days"`
+	ValidFrom   string `json:"valid_from"`
+	ValidTo     string `json:"valid_to"`
+}
+
+func (rr deliveryRouteRequest) route() usecase.DeliveryRoute {
+	return usecase.DeliveryRoute{
+		Country:     rr.Country,
+		FromCity:    rr.FromCity,
+		ToCity:      rr.ToCity,
+		Carrier:     rr.Carrier,
+		Amount:      rr.Amount,
+		Currency:    rr.Currency,
+		TransitDays: rr.TransitDays,
+		ValidFrom:   rr.ValidFrom,
+		ValidTo:     rr.ValidTo,
+	}
+}
+
+func (h tariffHandler) handlerListDeliveryRoutes(w http.ResponseWriter, r *http.Request) error {
+	routes, err := h.useCase.GetDeliveryRoutes(r.Context(), r.URL.Query().Get("country"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, routes)
+}
+
+func (h tariffHandler) handlerCreateDeliveryRoute(w http.ResponseWriter, r *http.Request) error {
+	rr := deliveryRouteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&rr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	route, err := h.useCase.CreateDeliveryRoute(r.Context(), rr.route())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, route)
+}
+
+func (h tariffHandler) handlerUpdateDeliveryRoute(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	rr := deliveryRouteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&rr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	route, err := h.useCase.UpdateDeliveryRoute(r.Context(), id, rr.route())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, route)
+}
+
+func (h tariffHandler) handlerDeleteDeliveryRoute(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	if err := h.useCase.DeleteDeliveryRoute(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// priceRequest - тело запроса для тарифов брокера и цен SOS: у них одинаковые поля.
+type priceRequest struct {
+	Country   string `json:"country"`
+	Name      string `json:"name"`
+	Amount    int    `json:"amount"`
+	ValidFrom string `json:"valid_from"`
+	ValidTo   string `json:"valid_to"`
+}
+
+func (pr priceRequest) brokerTier() usecase.BrokerTier {
+	return usecase.BrokerTier{
+		Country:   pr.Country,
+		Name:      pr.Name,
+		Amount:    pr.Amount,
+		ValidFrom: pr.ValidFrom,
+		ValidTo:   pr.ValidTo,
+	}
+}
+
+func (pr priceRequest) sosPrice() usecase.SOSPrice {
+	return usecase.SOSPrice{
+		Country:   pr.Country,
+		Name:      pr.Name,
+		Amount:    pr.Amount,
+		ValidFrom: pr.ValidFrom,
+		ValidTo:   pr.ValidTo,
+	}
+}
+
+func (h tariffHandler) handlerListBrokerTiers(w http.ResponseWriter, r *http.Request) error {
+	tiers, err := h.useCase.GetBrokerTiers(r.Context(), r.URL.Query().Get("country"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, tiers)
+}
+
+func (h tariffHandler) handlerCreateBrokerTier(w http.ResponseWriter, r *http.Request) error {
+	pr := priceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	tier, err := h.useCase.CreateBrokerTier(r.Context(), pr.brokerTier())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, tier)
+}
+
+func (h tariffHandler) handlerUpdateBrokerTier(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	pr := priceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	tier, err := h.useCase.UpdateBrokerTier(r.Context(), id, pr.brokerTier())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, tier)
+}
+
+func (h tariffHandler) handlerDeleteBrokerTier(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	if err := h.useCase.DeleteBrokerTier(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h tariffHandler) handlerListSOSPrices(w http.ResponseWriter, r *http.Request) error {
+	prices, err := h.useCase.GetSOSPrices(r.Context(), r.URL.Query().Get("country"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, prices)
+}
+
+func (h tariffHandler) handlerCreateSOSPrice(w http.ResponseWriter, r *http.Request) error {
+	pr := priceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	price, err := h.useCase.CreateSOSPrice(r.Context(), pr.sosPrice())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, price)
+}
+
+func (h tariffHandler) handlerUpdateSOSPrice(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	pr := priceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
+	}
+
+	price, err := h.useCase.UpdateSOSPrice(r.Context(), id, pr.sosPrice())
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, price)
+}
+
+func (h tariffHandler) handlerDeleteSOSPrice(w http.ResponseWriter, r *http.Request) error {
+	id, err := tariffID(r)
+	if err != nil {
+		return err
+	}
+	if err := h.useCase.DeleteSOSPrice(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func tariffID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: id -> %v", usecase.ErrInvalidArgument, err)
+	}
+	return id, nil
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/omekov/dubaicarkzv2/internal/report"
 	"github.com/omekov/dubaicarkzv2/internal/usecase"
 )
 
@@ -18,6 +22,10 @@ func (h homeHandler) handlerTransport(w http.ResponseWriter, r *http.Request) er
 	mark := strings.ToUpper(r.URL.Query().Get("mark"))
 	model := strings.ToUpper(r.URL.Query().Get("model"))
 	volumeQuery := r.URL.Query().Get("volume")
+	// справочник меняется только с импортом, поэтому ETag общий для всех списков
+	if notModified(w, r, h.useCase.CatalogETag()) {
+		return nil
+	}
 	if mark != "" && model == "" && volumeQuery == "" {
 		models, err := h.useCase.GetModels(r.Context(), mark)
 		if err != nil {
@@ -55,7 +63,6 @@ func (h homeHandler) handlerTransport(w http.ResponseWriter, r *http.Request) er
 		if err != nil {
 			return fmt.Errorf("volume -> %v", err)
 		}
-		fmt.Println(volume)
 		specifications, err := h.useCase.GetSpecifications(r.Context(), mark, model, volume)
 		if err != nil {
 			return err
@@ -86,19 +93,110 @@ func (h homeHandler) handlerTransport(w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
+// handlerHistory отдает стоимость комплектации по загрузкам КГД:
+// /api/v1/vehicles/{mark}/{model}/{volume}/{year}/history. История меняется
+// только с импортом или слиянием моделей, поэтому ETag общий со справочником.
+func (h homeHandler) handlerHistory(w http.ResponseWriter, r *http.Request) error {
+	mark, model, volume, err := vehicleParams(r)
+	if err != nil {
+		return err
+	}
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil {
+		return fmt.Errorf("%w: year -> %v", usecase.ErrInvalidArgument, err)
+	}
+	if notModified(w, r, h.useCase.CatalogETag()) {
+		return nil
+	}
+
+	history, err := h.useCase.GetValuationHistory(r.Context(), mark, model, volume, year)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, history)
+}
+
+// handlerVIN расшифровывает VIN и подбирает строки справочника для формы
+// расчета: /api/v1/vin/{vin}. Ответ меняется только вместе со справочником.
+func (h homeHandler) handlerVIN(w http.ResponseWriter, r *http.Request) error {
+	if notModified(w, r, h.useCase.CatalogETag()) {
+		return nil
+	}
+
+	decoding, err := h.useCase.DecodeVIN(r.Context(), chi.URLParam(r, "vin"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, decoding)
+}
+
+// handlerYears сравнивает цену под ключ по годам выпуска:
+// /api/v1/vehicles/{mark}/{model}/{volume}/years. Считается по текущему
+// курсу, поэтому не кешируется.
+func (h homeHandler) handlerYears(w http.ResponseWriter, r *http.Request) error {
+	mark, model, volume, err := vehicleParams(r)
+	if err != nil {
+		return err
+	}
+
+	comparison, err := h.useCase.CompareYears(r.Context(), mark, model, volume)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, comparison)
+}
+
+// vehicleParams разбирает марку, модель и объем из пути /vehicles/{mark}/{model}/{volume}.
+func vehicleParams(r *http.Request) (string, string, int, error) {
+	mark, err := url.PathUnescape(chi.URLParam(r, "mark"))
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%w: mark -> %v", usecase.ErrInvalidArgument, err)
+	}
+	model, err := url.PathUnescape(chi.URLParam(r, "model"))
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%w: model -> %v", usecase.ErrInvalidArgument, err)
+	}
+	volume, err := strconv.Atoi(chi.URLParam(r, "volume"))
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%w: volume -> %v", usecase.ErrInvalidArgument, err)
+	}
+	return mark, model, volume, nil
+}
+
 type assesstmentRequest struct {
-	Amount int `json:"amount"`
-	Volume int `json:"volume"`
-	Year   int `json:"year"`
+	Mark   string `json:"mark"`
+	Model  string `json:"model"`
+	Amount int    `json:"amount"`
+	Volume int    `json:"volume"`
+	Year   int    `json:"year"`
+	Origin string `json:"origin"`
+	// Currency - валюта amount, по умолчанию доллары
+	Currency    string `json:"currency"`
+	Destination string `json:"destination"`
+	// EngineType - ice, hybrid или ev; пустой - ice, а при нулевом объеме - тип
+	// из строки КГД
+	EngineType string `json:"engine_type"`
+	// VehicleClass - M1, N1 или L; пустой - легковой
+	VehicleClass string `json:"vehicle_class"`
 }
 
 func (h homeHandler) handlerAssessment(w http.ResponseWriter, r *http.Request) error {
 	ar := assesstmentRequest{}
-	err := json.NewDecoder(r.Body).Decode(&ar)
-	if err != nil {
-		return err
+	if err := json.NewDecoder(r.Body).Decode(&ar); err != nil {
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidArgument, err)
 	}
-	assesstment, err := h.useCase.AssessmentAuto(r.Context(), ar.Amount, ar.Volume, ar.Year)
+	assesstment, err := h.useCase.AssessmentAuto(r.Context(), usecase.AssessmentInput{
+		Mark:         ar.Mark,
+		Model:        ar.Model,
+		Amount:       ar.Amount,
+		Volume:       ar.Volume,
+		Year:         ar.Year,
+		Origin:       ar.Origin,
+		Currency:     ar.Currency,
+		Destination:  ar.Destination,
+		EngineType:   ar.EngineType,
+		VehicleClass: ar.VehicleClass,
+	})
 	if err != nil {
 		return err
 	}
@@ -110,3 +208,90 @@ func (h homeHandler) handlerAssessment(w http.ResponseWriter, r *http.Request) e
 	w.Write(assesstmentByte)
 	return nil
 }
+
+func (h homeHandler) handlerGetAssessment(w http.ResponseWriter, r *http.Request) error {
+	snapshot, err := h.useCase.GetAssessment(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	// снимок неизменяемый, поэтому его можно кешировать надолго
+	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	return writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (h homeHandler) handlerGetAssessmentPDF(w http.ResponseWriter, r *http.Request) error {
+	snapshot, err := h.useCase.GetAssessment(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := report.AssessmentPDF(&body, snapshot); err != nil {
+		return fmt.Errorf("AssessmentPDF -> %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="assessment-%s.pdf"`, snapshot.ID))
+	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+	return nil
+}
+
+func (h homeHandler) handlerGetAssessmentXLSX(w http.ResponseWriter, r *http.Request) error {
+	snapshot, err := h.useCase.GetAssessment(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := report.AssessmentXLSX(&body, snapshot); err != nil {
+		return fmt.Errorf("AssessmentXLSX -> %v", err)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	writeXLSX(w, fmt.Sprintf("assessment-%s.xlsx", snapshot.ID), body.Bytes())
+	return nil
+}
+
+// handlerExportKGD отдает срез справочника КГД, например
+// /api/v1/kgd/export.xlsx?mark=TOYOTA&year_from=2018&year_to=2023
+func (h homeHandler) handlerExportKGD(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	yearFrom, err := optionalInt(query.Get("year_from"))
+	if err != nil {
+		return fmt.Errorf("%w: year_from -> %v", usecase.ErrInvalidArgument, err)
+	}
+	yearTo, err := optionalInt(query.Get("year_to"))
+	if err != nil {
+		return fmt.Errorf("%w: year_to -> %v", usecase.ErrInvalidArgument, err)
+	}
+
+	rows, err := h.useCase.GetKGDRows(r.Context(), query.Get("mark"), query.Get("model"), yearFrom, yearTo)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := report.KGDRowsXLSX(&body, rows); err != nil {
+		return fmt.Errorf("KGDRowsXLSX -> %v", err)
+	}
+
+	writeXLSX(w, "kgd.xlsx", body.Bytes())
+	return nil
+}
+
+func writeXLSX(w http.ResponseWriter, filename string, body []byte) {
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+const (
+	brandName  = "DubaiCarKZ"
+	fontFamily = "DejaVu"
+
+	pageMargin   = 15.0
+	nameWidth    = 125.0
+	amountWidth  = 55.0
+	rowHeight    = 7.0
+	headerHeight = 9.0
+)
+
+// Шрифты DejaVu вшиты в бинарник: в них есть кириллица, и PDF собирается без
+// обращения к системным шрифтам или сети.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+var (
+	brandColor = [3]int{0, 102, 153}
+	zebraColor = [3]int{240, 244, 248}
+)
+
+// AssessmentPDF рендерит сохраненный расчет в PDF: авто, оценка КГД, курс,
+// все статьи расходов и итоги по каждому маршруту доставки.
+func AssessmentPDF(w io.Writer, s usecase.AssessmentSnapshot) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(fmt.Sprintf("Расчет %s", s.ID), true)
+	pdf.SetAuthor(brandName, true)
+	pdf.SetCreationDate(s.CreatedAt)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s · расчет %s · стр. %d", brandName, s.ID, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// шапка
+	pdf.SetFillColor(brandColor[0], brandColor[1], brandColor[2])
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont(fontFamily, "B", 18)
+	pdf.CellFormat(0, 14, " "+brandName, "", 1, "L", true, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Расчет стоимости авто под ключ № %s", s.ID), "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("от %s", s.CreatedAt.Format("02.01.2006 15:04")), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// авто и курс
+	rateDate := s.CreatedAt
+	if s.Rates.Timestamp != 0 {
+		rateDate = time.Unix(int64(s.Rates.Timestamp), 0)
+	}
+	car := fmt.Sprintf("%s %s", s.Input.Mark, s.Input.Model)
+	if s.Input.Mark == "" && s.Input.Model == "" {
+		car = "-"
+	}
+
+	sectionTitle(pdf, "Автомобиль")
+	row(pdf, "Марка и модель", car, false, 0)
+	row(pdf, "Класс", formatVehicleClass(s.Result.VehicleClass), false, 1)
+	row(pdf, "Год выпуска", fmt.Sprintf("%d", s.Input.Year), false, 2)
+	row(pdf, "Двигатель", formatEngine(s.Result.EngineType, s.Input.Volume), false, 3)
+	row(pdf, "Стоимость авто", formatPrice(s.Input.Amount, inputCurrency(s.Input)), false, 4)
+	next := 5
+	if s.Result.KGDAmount > 0 {
+		row(pdf, "Оценка по налоговой сетке КГД", formatPrice(s.Result.KGDAmount, "USD"), false, next)
+		next++
+	}
+	row(pdf, fmt.Sprintf("Курс доллара на %s", rateDate.Format("02.01.2006")), fmt.Sprintf("%.2f ₸", s.Rates.KZT), false, next)
+	pdf.Ln(4)
+
+	// статьи расходов
+	sectionTitle(pdf, "Статьи расходов")
+	items := s.LineItems()
+	for i, item := range items {
+		row(pdf, item.Name, formatAmount(item.Amount)+" ₸", false, i)
+	}
+	base, totals := s.Totals()
+	row(pdf, "Итого без доставки", formatAmount(base)+" ₸", true, len(items))
+	pdf.Ln(4)
+
+	// итоги по маршрутам
+	if len(totals) > 0 {
+		sectionTitle(pdf, "Итого под ключ с доставкой")
+		for i, t := range totals {
+			name := fmt.Sprintf("%s → %s (доставка %s = %s ₸)", t.FromCity, t.ToCity, formatPrice(t.DeliveryAmount, t.DeliveryCurrency), formatAmount(t.DeliveryKZT))
+			row(pdf, name, formatAmount(t.Amount)+" ₸", true, i)
+		}
+		pdf.Ln(4)
+	}
+
+	pdf.SetFont(fontFamily, "", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(0, 4, fmt.Sprintf(
+		"Расчет ориентировочный и зафиксирован на дату составления: МРП %s ₸, пошлина %d%%, НДС %d%%. "+
+			"Стоимость СБКТС, доставки и услуг брокера может отличаться.",
+		formatAmount(s.Rules.MRP), s.Rules.DutyPercent(s.Result.EngineType), s.Rules.VATPercent,
+	), "", "L", false)
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func sectionTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.SetTextColor(brandColor[0], brandColor[1], brandColor[2])
+	pdf.CellFormat(0, headerHeight, title, "B", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+func row(pdf *fpdf.Fpdf, name, value string, bold bool, i int) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont(fontFamily, style, 10)
+	pdf.SetFillColor(zebraColor[0], zebraColor[1], zebraColor[2])
+	fill := i%2 == 1
+	pdf.CellFormat(nameWidth, rowHeight, name, "", 0, "L", fill, 0, "")
+	pdf.CellFormat(amountWidth, rowHeight, value, "", 1, "R", fill, 0, "")
+}
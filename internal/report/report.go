@@ -0,0 +1,81 @@
+// Package report рендерит сохраненные расчеты в файлы для отправки клиентам.
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+)
+
+// formatAmount разбивает сумму на разряды пробелами: 12345678 -> "12 345 678".
+func formatAmount(amount int) string {
+	digits := strconv.Itoa(amount)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// formatPrice добавляет к сумме знак валюты: "$2 500", "9 000 AED", "150 000 ₸".
+func formatPrice(amount int, currency string) string {
+	switch currency {
+	case "USD":
+		return "$" + formatAmount(amount)
+	case "KZT":
+		return formatAmount(amount) + " ₸"
+	}
+	return formatAmount(amount) + " " + currency
+}
+
+// currencySign - знак валюты для подписей колонок: $, ₸ или код валюты.
+func currencySign(currency string) string {
+	switch currency {
+	case "USD":
+		return "$"
+	case "KZT":
+		return "₸"
+	}
+	return currency
+}
+
+// inputCurrency - валюта цены авто в расчете, пустая - доллары.
+func inputCurrency(input usecase.AssessmentInput) string {
+	if input.Currency == "" {
+		return "USD"
+	}
+	return input.Currency
+}
+
+// formatEngine описывает двигатель расчета: у электромобиля объема нет.
+func formatEngine(engineType string, volume int) string {
+	switch engineType {
+	case usecase.EngineEV:
+		return "Электродвигатель"
+	case usecase.EngineHybrid:
+		return fmt.Sprintf("%d см³, гибрид", volume)
+	}
+	return fmt.Sprintf("%d см³", volume)
+}
+
+// formatVehicleClass называет класс транспорта; в расчетах до появления
+// классов он пустой, там всегда легковой.
+func formatVehicleClass(class string) string {
+	switch class {
+	case usecase.ClassN1:
+		return "Легкий грузовой (N1)"
+	case usecase.ClassL:
+		return "Мототехника (L)"
+	}
+	return "Легковой (M1)"
+}
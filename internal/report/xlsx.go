@@ -0,0 +1,232 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	assessmentSheet = "Расчет"
+	kgdSheet        = "КГД"
+)
+
+// AssessmentXLSX выгружает сохраненный расчет в Excel. Суммы, которые
+// считаются из других ячеек, записаны формулами, а рядом выведен их текст,
+// чтобы клиент видел, откуда берется каждая цифра.
+func AssessmentXLSX(w io.Writer, s usecase.AssessmentSnapshot) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), assessmentSheet); err != nil {
+		return err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	money, err := f.NewStyle(&excelize.Style{NumFmt: 3})
+	if err != nil {
+		return err
+	}
+	boldMoney, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, NumFmt: 3})
+	if err != nil {
+		return err
+	}
+
+	sheet := newSheetWriter(f, assessmentSheet)
+	sheet.text(fmt.Sprintf("Расчет № %s от %s", s.ID, s.CreatedAt.Format("02.01.2006")), bold)
+	sheet.skip()
+
+	sheet.header(bold, "Параметр", "Значение", "Формула")
+	sheet.value("Марка и модель", strings.TrimSpace(s.Input.Mark+" "+s.Input.Model), 0)
+	sheet.value("Класс", formatVehicleClass(s.Result.VehicleClass), 0)
+	sheet.value("Год выпуска", s.Input.Year, 0)
+	if s.Result.EngineType == usecase.EngineEV {
+		sheet.value("Двигатель", formatEngine(s.Result.EngineType, s.Input.Volume), 0)
+	} else {
+		sheet.value("Объем двигателя, см³", s.Input.Volume, 0)
+	}
+	currency := inputCurrency(s.Input)
+	amount := sheet.value("Стоимость авто, "+currencySign(currency), s.Input.Amount, money)
+	if s.Result.KGDAmount > 0 {
+		sheet.value("Оценка по налоговой сетке КГД, $", s.Result.KGDAmount, money)
+	}
+	rate := sheet.value("Курс доллара, ₸", s.Result.USD, 0)
+	mrp := sheet.value("МРП, ₸", s.Rules.MRP, money)
+	dutyPercent := sheet.value("Ставка пошлины, %", s.Rules.DutyPercent(s.Result.EngineType), 0)
+	vatPercent := sheet.value("Ставка НДС, %", s.Rules.VATPercent, 0)
+	sheet.skip()
+
+	sheet.header(bold, "Статья расходов", "Сумма, ₸", "Формула")
+	first := sheet.row
+	var amountKZT, duty, collection string
+	for _, item := range s.LineItems() {
+		switch item.Kind {
+		case usecase.LineItemCar:
+			// доллары пересчитываются формулой по курсу, остальные валюты - по курсу расчета
+			if currency == "USD" {
+				amountKZT = sheet.formula(item.Name, fmt.Sprintf("%s*%s", amount, rate), money)
+			} else {
+				amountKZT = sheet.value(item.Name, item.Amount, money)
+			}
+		case usecase.LineItemCustomsCollection:
+			collection = sheet.formula(item.Name, fmt.Sprintf("%s*%d", mrp, s.Rules.CustomsCollectionMRP), money)
+		case usecase.LineItemCustomsDuty:
+			duty = sheet.formula(item.Name, fmt.Sprintf("ROUNDDOWN(%s*%s/100,0)", amountKZT, dutyPercent), money)
+		case usecase.LineItemVAT:
+			sheet.formula(item.Name, fmt.Sprintf("ROUNDDOWN((%s+%s+%s)*%s/100,0)", amountKZT, duty, collection, vatPercent), money)
+		default:
+			sheet.value(item.Name, item.Amount, money)
+		}
+	}
+	last := sheet.row - 1
+	base := sheet.formula("Итого без доставки", fmt.Sprintf("SUM(B%d:B%d)", first, last), boldMoney)
+	sheet.skip()
+
+	_, totals := s.Totals()
+	if len(totals) > 0 {
+		sheet.header(bold, "Доставка", "Сумма", "")
+		for _, t := range totals {
+			route := fmt.Sprintf("%s → %s, %s", t.FromCity, t.ToCity, t.DeliveryCurrency)
+			delivery := sheet.value(route, t.DeliveryAmount, money)
+			// доллары пересчитываются формулой по курсу выше, остальные валюты - по курсу расчета
+			deliveryKZT := fmt.Sprintf("%s*%s", delivery, rate)
+			if t.DeliveryCurrency != "USD" {
+				deliveryKZT = sheet.value(fmt.Sprintf("%s → %s, ₸", t.FromCity, t.ToCity), t.DeliveryKZT, money)
+			}
+			sheet.formula(fmt.Sprintf("Итого под ключ в %s, ₸", t.ToCity), fmt.Sprintf("%s+%s", base, deliveryKZT), boldMoney)
+		}
+	}
+	if sheet.err != nil {
+		return sheet.err
+	}
+
+	if err := f.SetColWidth(assessmentSheet, "A", "A", 50); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(assessmentSheet, "B", "B", 18); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(assessmentSheet, "C", "C", 40); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// KGDRowsXLSX выгружает строки справочника КГД для дилеров потоковой записью,
+// чтобы большие выборки не держать целиком в памяти excelize.
+func KGDRowsXLSX(w io.Writer, rows []usecase.KGDRow) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), kgdSheet); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(kgdSheet)
+	if err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(1, 2, 25); err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	header := []interface{}{"Марка", "Модель", "Класс", "Двигатель", "Объем, см³", "Год", "Стоимость КГД, $"}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for i, r := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, []interface{}{r.Mark, r.Model, r.VehicleClass, r.EngineType, r.Volume, r.Year, r.Amount}); err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 {
+		// таблица растягивается на все колонки заголовка
+		last, err := excelize.CoordinatesToCellName(len(header), len(rows)+1)
+		if err != nil {
+			return err
+		}
+		if err := sw.AddTable(&excelize.Table{Range: "A1:" + last, Name: "KGD"}); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// sheetWriter пишет строки расчета подряд и возвращает адреса ячеек со
+// значениями, чтобы на них можно было сослаться в формулах ниже.
+type sheetWriter struct {
+	f     *excelize.File
+	sheet string
+	row   int
+	err   error
+}
+
+func newSheetWriter(f *excelize.File, sheet string) *sheetWriter {
+	return &sheetWriter{f: f, sheet: sheet, row: 1}
+}
+
+func (s *sheetWriter) skip() {
+	s.row++
+}
+
+func (s *sheetWriter) text(value string, style int) {
+	s.set("A", value, style)
+	s.row++
+}
+
+func (s *sheetWriter) header(style int, names ...string) {
+	for i, name := range names {
+		s.set(string(rune('A'+i)), name, style)
+	}
+	s.row++
+}
+
+func (s *sheetWriter) value(name string, value interface{}, style int) string {
+	s.set("A", name, 0)
+	cell := s.set("B", value, style)
+	s.row++
+	return cell
+}
+
+func (s *sheetWriter) formula(name, formula string, style int) string {
+	s.set("A", name, 0)
+	cell := fmt.Sprintf("B%d", s.row)
+	if s.err == nil {
+		s.err = s.f.SetCellFormula(s.sheet, cell, formula)
+	}
+	if s.err == nil && style != 0 {
+		s.err = s.f.SetCellStyle(s.sheet, cell, cell, style)
+	}
+	s.set("C", "="+formula, 0)
+	s.row++
+	return cell
+}
+
+func (s *sheetWriter) set(col string, value interface{}, style int) string {
+	cell := fmt.Sprintf("%s%d", col, s.row)
+	if s.err != nil {
+		return cell
+	}
+	s.err = s.f.SetCellValue(s.sheet, cell, value)
+	if s.err == nil && style != 0 {
+		s.err = s.f.SetCellStyle(s.sheet, cell, cell, style)
+	}
+	return cell
+}
@@ -0,0 +1,388 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const (
+	assessmentIDLength   = 8
+	assessmentIDAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	assessmentIDAttempts = 5
+)
+
+// Rules - ставки, по которым считается растаможка машины класса VehicleClass.
+// Они попадают в снимок расчета, чтобы старые расчеты не менялись вместе с
+// МРП и ставками. В снимках до появления классов VehicleClass пустой, а до
+// появления ставок сборов пусты UtilRates и RegistrationRates: суммы сборов
+// таких расчетов уже лежат в Result и заново не считаются.
+type Rules struct {
+	VehicleClass         string
+	MRP                  int
+	CustomsDutyPercent   int
+	CustomsCollectionMRP int
+	VATPercent           int
+	// EVCustomsDutyPercent - пошлина на электромобили: легковые в ЕАЭС ввозят
+	// без пошлины. В снимках до ее появления поле нулевое, что с этим совпадает.
+	EVCustomsDutyPercent int
+	// UtilBaseMRP - базовая ставка утильсбора в МРП, UtilRates - коэффициенты
+	// к ней по объему двигателя; пустой список - класс утильсбор не платит.
+	UtilBaseMRP int
+	UtilRates   []UtilRate
+	// RegistrationRates - сбор за первичную регистрацию по возрасту авто.
+	RegistrationRates []RegistrationRate
+	// EVRegistrationExempt - электромобили от сбора за регистрацию освобождены.
+	EVRegistrationExempt bool
+}
+
+// UtilRate - коэффициент к базовой ставке утильсбора для двигателей объемом до
+// MaxVolume куб. см включительно, MaxVolume = 0 - без ограничения.
+type UtilRate struct {
+	MaxVolume   int
+	Coefficient float64
+}
+
+// RegistrationRate - сбор за первичную регистрацию в МРП для авто не старше
+// MaxAge лет, MaxAge = 0 - без ограничения.
+type RegistrationRate struct {
+	MaxAge int
+	MRP    float64
+}
+
+// classRules - ставки по классам транспорта, МРП подставляет rules.
+//
+// Источники:
+//   - пошлина - Единый таможенный тариф ЕАЭС (решение Совета ЕЭК № 54 от
+//     16.07.2012): легковые - позиция 8703, грузовые - 8704, мототехника -
+//     8711. Нулевая пошлина на электромобили - льгота Совета ЕЭК только для
+//     подсубпозиции 8703 80;
+//   - таможенный сбор за таможенные операции - 6 МРП за декларацию;
+//   - НДС при импорте - ст. 422 Налогового кодекса РК;
+//   - сбор за первичную регистрацию - Налоговый кодекс РК, сбор за
+//     государственную регистрацию механических транспортных средств;
+//   - утильсбор - расширенные обязательства производителей (импортеров) по
+//     Экологическому кодексу РК, коэффициенты к базовой ставке утверждает
+//     Правительство РК.
+var classRules = map[string]Rules{
+	// легковые: пошлина 15%, электромобили без пошлины; утильсбор по объему
+	// двигателя, электромобили - по нижней ставке; регистрация по возрасту
+	ClassM1: {
+		CustomsDutyPercent:   15,
+		EVCustomsDutyPercent: 0,
+		UtilRates: []UtilRate{
+			{MaxVolume: 1000, Coefficient: 1.5},
+			{MaxVolume: 2000, Coefficient: 3.5},
+			{MaxVolume: 3000, Coefficient: 5},
+			{MaxVolume: 0, Coefficient: 11.5},
+		},
+		RegistrationRates: []RegistrationRate{
+			{MaxAge: 1, MRP: 0.25},
+			{MaxAge: 3, MRP: 50},
+			{MaxAge: 0, MRP: 500},
+		},
+	},
+	// легкие грузовые: пошлина 10% и для электрических; регистрация 4 МРП
+	// независимо от возраста
+	ClassN1: {
+		CustomsDutyPercent:   10,
+		EVCustomsDutyPercent: 10,
+		UtilRates: []UtilRate{
+			{MaxVolume: 2500, Coefficient: 2},
+			{MaxVolume: 0, Coefficient: 4},
+		},
+		RegistrationRates: []RegistrationRate{{MaxAge: 0, MRP: 4}},
+	},
+	// мототехника: пошлина 5%, утильсбор не платит, регистрация 2 МРП
+	ClassL: {
+		CustomsDutyPercent:   5,
+		EVCustomsDutyPercent: 5,
+		RegistrationRates:    []RegistrationRate{{MaxAge: 0, MRP: 2}},
+	},
+}
+
+// DutyPercent - ставка пошлины для типа двигателя.
+func (r Rules) DutyPercent(engineType string) int {
+	if engineType == EngineEV {
+		return r.EVCustomsDutyPercent
+	}
+	return r.CustomsDutyPercent
+}
+
+// utilAmount - утилизационный сбор: базовая ставка, умноженная на
+// коэффициент по объему двигателя. Электромобили платят по нижней ставке.
+func (r Rules) utilAmount(engineType string, volume int) int {
+	if len(r.UtilRates) == 0 {
+		return 0
+	}
+	base := float64(r.MRP * r.UtilBaseMRP)
+	if engineType == EngineEV {
+		return int(base * r.UtilRates[0].Coefficient)
+	}
+	for _, rate := range r.UtilRates {
+		if rate.MaxVolume == 0 || volume <= rate.MaxVolume {
+			return int(base * rate.Coefficient)
+		}
+	}
+	return int(base * r.UtilRates[len(r.UtilRates)-1].Coefficient)
+}
+
+// firstRegistrationAmount - сбор за первичную регистрацию авто возрастом age
+// лет, считая по годам выпуска.
+func (r Rules) firstRegistrationAmount(engineType string, age int) int {
+	if engineType == EngineEV && r.EVRegistrationExempt {
+		return 0
+	}
+	for _, rate := range r.RegistrationRates {
+		if rate.MaxAge == 0 || age <= rate.MaxAge {
+			return int(float64(r.MRP) * rate.MRP)
+		}
+	}
+	return 0
+}
+
+// AssessmentRates - курсы на момент расчета.
+type AssessmentRates struct {
+	Base      string
+	Timestamp int
+	KZT       float64
+	AED       float64
+	CNY       float64
+	RUB       float64
+}
+
+// AssessmentSnapshot - сохраненный расчет со всем, что нужно для его повторного показа.
+type AssessmentSnapshot struct {
+	ID        string
+	CreatedAt time.Time
+	Input     AssessmentInput
+	Rates     AssessmentRates
+	Rules     Rules
+	Result    Assessment
+}
+
+// rules - ставки для класса транспорта из classRules и общие для всех классов
+// сборы по текущему МРП. Неизвестный класс считается легковым.
+func (u UseCase) rules(class string) Rules {
+	rules, ok := classRules[class]
+	if !ok {
+		rules = classRules[ClassM1]
+	}
+	rules.VehicleClass = class
+	rules.MRP = u.mrp
+	rules.CustomsCollectionMRP = 6
+	rules.VATPercent = 12
+	rules.UtilBaseMRP = 50
+	rules.EVRegistrationExempt = true
+	return rules
+}
+
+// fees возвращает сборы, которые не зависят от стоимости авто: первичную
+// регистрацию и утильсбор.
+func (r Rules) fees(v vehicle) (firstRegistration, util int) {
+	return r.firstRegistrationAmount(v.EngineType, time.Now().Year()-v.Year), r.utilAmount(v.EngineType, v.Volume)
+}
+
+// GetAssessment возвращает сохраненный расчет ровно в том виде, в каком он был посчитан.
+func (u UseCase) GetAssessment(ctx context.Context, id string) (AssessmentSnapshot, error) {
+	a, ok, err := u.repo.GetAssessment(ctx, id)
+	if err != nil {
+		return AssessmentSnapshot{}, err
+	}
+	if !ok {
+		return AssessmentSnapshot{}, fmt.Errorf("%w: assessment %s", ErrNotFound, id)
+	}
+
+	snapshot := AssessmentSnapshot{
+		ID:        a.ID,
+		CreatedAt: time.Unix(a.CreatedAt, 0),
+	}
+	if err := json.Unmarshal([]byte(a.Input), &snapshot.Input); err != nil {
+		return AssessmentSnapshot{}, fmt.Errorf("assessment %s input -> %v", id, err)
+	}
+	if err := json.Unmarshal([]byte(a.Rates), &snapshot.Rates); err != nil {
+		return AssessmentSnapshot{}, fmt.Errorf("assessment %s rates -> %v", id, err)
+	}
+	if err := json.Unmarshal([]byte(a.Rules), &snapshot.Rules); err != nil {
+		return AssessmentSnapshot{}, fmt.Errorf("assessment %s rules -> %v", id, err)
+	}
+	if err := json.Unmarshal([]byte(a.Result), &snapshot.Result); err != nil {
+		return AssessmentSnapshot{}, fmt.Errorf("assessment %s result -> %v", id, err)
+	}
+	return snapshot, nil
+}
+
+func (u UseCase) saveAssessment(ctx context.Context, input AssessmentInput, rates AssessmentRates, rules Rules, result Assessment) (AssessmentSnapshot, error) {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return AssessmentSnapshot{}, err
+	}
+	ratesJSON, err := json.Marshal(rates)
+	if err != nil {
+		return AssessmentSnapshot{}, err
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return AssessmentSnapshot{}, err
+	}
+
+	for attempt := 0; attempt < assessmentIDAttempts; attempt++ {
+		id, err := newAssessmentID()
+		if err != nil {
+			return AssessmentSnapshot{}, err
+		}
+
+		result.ID = id
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return AssessmentSnapshot{}, err
+		}
+
+		created, err := u.repo.CreateAssessment(ctx, repository.Assessment{
+			ID:     id,
+			Input:  string(inputJSON),
+			Rates:  string(ratesJSON),
+			Rules:  string(rulesJSON),
+			Result: string(resultJSON),
+		})
+		if err != nil {
+			return AssessmentSnapshot{}, err
+		}
+		if created {
+			return AssessmentSnapshot{
+				ID:        id,
+				CreatedAt: time.Now(),
+				Input:     input,
+				Rates:     rates,
+				Rules:     rules,
+				Result:    result,
+			}, nil
+		}
+	}
+	return AssessmentSnapshot{}, errors.New("could not generate unique assessment id")
+}
+
+func newAssessmentID() (string, error) {
+	max := big.NewInt(int64(len(assessmentIDAlphabet)))
+	id := make([]byte, assessmentIDLength)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		id[i] = assessmentIDAlphabet[n.Int64()]
+	}
+	return string(id), nil
+}
+
+func ratesFromCurrency(currency external.OpenExchangeRatesResponse) AssessmentRates {
+	return AssessmentRates{
+		Base:      currency.Base,
+		Timestamp: currency.Timestamp,
+		KZT:       currency.Rates.KZT,
+		AED:       currency.Rates.AED,
+		CNY:       currency.Rates.CNY,
+		RUB:       currency.Rates.RUB,
+	}
+}
+
+const (
+	LineItemCar               = "car"
+	LineItemSBKTS             = "sbkts"
+	LineItemCustomsCollection = "customs_collection"
+	LineItemCustomsDuty       = "customs_duty"
+	LineItemVAT               = "vat"
+	LineItemRegistration      = "registration"
+	LineItemUtil              = "util"
+	LineItemSOS               = "sos"
+	LineItemBroker            = "broker"
+)
+
+// LineItem - строка расчета в тенге, Kind - одна из констант LineItem*.
+type LineItem struct {
+	Kind   string
+	Name   string
+	Amount int
+}
+
+// AssessmentTotal - итог под ключ с доставкой до конкретного города.
+// DeliveryAmount указан в валюте DeliveryCurrency.
+type AssessmentTotal struct {
+	FromCity         string
+	ToCity           string
+	Carrier          string
+	DeliveryAmount   int
+	DeliveryCurrency string
+	DeliveryKZT      int
+	Amount           int
+}
+
+// LineItems раскладывает расчет на статьи расходов без доставки. Брокер и
+// кнопка SOS берутся по минимальному тарифу, как ориентир для клиента.
+func (s AssessmentSnapshot) LineItems() []LineItem {
+	r := s.Result
+	items := []LineItem{
+		{Kind: LineItemCar, Name: "Стоимость авто в тенге", Amount: r.AmountKZT},
+		{Kind: LineItemSBKTS, Name: "СБКТС", Amount: r.SBKTS},
+		{Kind: LineItemCustomsCollection, Name: "Таможенный сбор", Amount: r.CustomsCollectionAmount},
+		{Kind: LineItemCustomsDuty, Name: fmt.Sprintf("Таможенная пошлина %d%%", s.Rules.DutyPercent(r.EngineType)), Amount: r.CustomsDutyAmount},
+		{Kind: LineItemVAT, Name: fmt.Sprintf("НДС %d%%", s.Rules.VATPercent), Amount: r.VATAmount},
+		{Kind: LineItemRegistration, Name: "Первичная регистрация", Amount: r.FirstRegistrationAmount},
+		{Kind: LineItemUtil, Name: "Утилизационный сбор", Amount: r.UtilAmount},
+	}
+	if sos, ok := minAmount(r.ButtonSOSAmount); ok {
+		items = append(items, LineItem{Kind: LineItemSOS, Name: "Кнопка SOS/ЭВАК", Amount: sos})
+	}
+	if broker, ok := minAmount(r.BrokerAmouts); ok {
+		items = append(items, LineItem{Kind: LineItemBroker, Name: "Услуги брокера, портовые сборы - ориентировочно", Amount: broker})
+	}
+	return items
+}
+
+// Totals возвращает сумму статей без доставки и итог под ключ для каждого
+// маршрута доставки. Доставка берется в тенге по курсу расчета.
+func (s AssessmentSnapshot) Totals() (int, []AssessmentTotal) {
+	base := 0
+	for _, item := range s.LineItems() {
+		base += item.Amount
+	}
+
+	totals := make([]AssessmentTotal, 0, len(s.Result.Delivereds))
+	for _, d := range s.Result.Delivereds {
+		currency, deliveryKZT := d.Currency, d.AmountKZT
+		if currency == "" {
+			// расчеты до появления валюты в маршрутах
+			currency, deliveryKZT = "USD", d.Amount*s.Result.USD
+		}
+		totals = append(totals, AssessmentTotal{
+			FromCity:         d.FromCity,
+			ToCity:           d.ToCity,
+			Carrier:          d.Carrier,
+			DeliveryAmount:   d.Amount,
+			DeliveryCurrency: currency,
+			DeliveryKZT:      deliveryKZT,
+			Amount:           base + deliveryKZT,
+		})
+	}
+	return base, totals
+}
+
+func minAmount(amounts []int) (int, bool) {
+	if len(amounts) == 0 {
+		return 0, false
+	}
+	min := amounts[0]
+	for _, a := range amounts[1:] {
+		if a < min {
+			min = a
+		}
+	}
+	return min, true
+}
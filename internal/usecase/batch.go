@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const (
+	// BatchAssessmentLimit - сколько машин можно посчитать одним запросом
+	BatchAssessmentLimit = 100
+	// batchAssessmentWorkers - сколько позиций пакета считается одновременно
+	batchAssessmentWorkers = 8
+)
+
+// BatchAssessmentResult - итог одной позиции пакета. Index - номер позиции во
+// входном списке с нуля; при ошибке Assessment пустой, а Error - ее текст.
+type BatchAssessmentResult struct {
+	Index      int
+	Input      AssessmentInput
+	Assessment *Assessment
+	Error      string
+}
+
+// AssessmentBatch считает растаможку для списка машин дилера. Курсы и тарифы
+// запрашиваются один раз на весь пакет, позиции считаются параллельно, а
+// ошибка одной позиции не мешает остальным. Результаты идут в порядке входа.
+func (u UseCase) AssessmentBatch(ctx context.Context, inputs []AssessmentInput) ([]BatchAssessmentResult, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: empty batch", ErrInvalidArgument)
+	}
+	if len(inputs) > BatchAssessmentLimit {
+		return nil, fmt.Errorf("%w: batch of %d items, limit is %d", ErrInvalidArgument, len(inputs), BatchAssessmentLimit)
+	}
+
+	// тарифы берутся по всем городам отправки, позиции фильтруют их сами
+	env, err := u.newAssessmentEnv(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchAssessmentResult, len(inputs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchAssessmentWorkers, len(inputs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = u.assessBatchItem(ctx, env, i, inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+func (u UseCase) assessBatchItem(ctx context.Context, env assessmentEnv, index int, input AssessmentInput) BatchAssessmentResult {
+	input = normalizeAssessmentInput(input)
+	result := BatchAssessmentResult{Index: index, Input: input}
+
+	err := ctx.Err()
+	if err == nil {
+		err = validateBatchInput(input)
+	}
+	if err == nil {
+		var assessment Assessment
+		if assessment, err = u.assess(ctx, env, input); err == nil {
+			result.Assessment = &assessment
+		}
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidArgument), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		result.Error = err.Error()
+	default:
+		// внутренние ошибки клиенту не показываются, как и в одиночном расчете
+		slog.Error("AssessmentBatch", slog.Int("item", index), slog.String("err", err.Error()))
+		result.Error = "Something went wrong"
+	}
+	return result
+}
+
+// validateBatchInput отсекает пустые строки списка: в одиночном расчете поля
+// заполняет форма, а в файле дилера их легко пропустить.
+func validateBatchInput(input AssessmentInput) error {
+	switch {
+	case input.Mark == "" || input.Model == "":
+		return fmt.Errorf("%w: mark and model are required", ErrInvalidArgument)
+	case input.Amount <= 0:
+		return fmt.Errorf("%w: price must be positive", ErrInvalidArgument)
+	case input.Volume < 0:
+		return fmt.Errorf("%w: volume must not be negative", ErrInvalidArgument)
+	case input.Year <= 0:
+		return fmt.Errorf("%w: year is required", ErrInvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestAssessmentBatch(t *testing.T) {
+	store := newFakeStore()
+	store.delivereds = []repository.Delivered{
+		{ID: 1, Country: "kz", FromCity: "Дубай", ToCity: "Алматы", Amount: 2500, Currency: "USD"},
+		{ID: 2, Country: "kz", FromCity: "Дубай", ToCity: "Астана", Amount: 2700, Currency: "USD"},
+		{ID: 3, Country: "kz", FromCity: "Шарджа", ToCity: "Алматы", Amount: 9000, Currency: "AED"},
+	}
+	rates := newFakeRates()
+	u := newTestUseCase(store, rates)
+	ctx := context.Background()
+	year := time.Now().Year()
+
+	inputs := []AssessmentInput{
+		{Mark: "toyota", Model: "camry", Amount: 10000, Volume: 2500, Year: year},
+		{Mark: "toyota", Model: "camry", Amount: 36700, Volume: 2500, Year: year, Currency: "aed", Destination: "астана"},
+		{Mark: "", Model: "camry", Amount: 10000, Volume: 2500, Year: year},
+		{Mark: "kia", Model: "k5", Amount: 0, Volume: 2000, Year: year},
+		{Mark: "kia", Model: "k5", Amount: 10000, Volume: 2000, Year: year, Currency: "GBP"},
+		{Mark: "kia", Model: "k5", Amount: 10000, Volume: 2000, Year: year, Origin: "шарджа"},
+		// пустая ячейка объема в файле приходит нулем
+		{Mark: "kia", Model: "k5", Amount: 10000, Year: year},
+	}
+	// позиций больше, чем воркеров, чтобы проверить порядок результатов
+	for range batchAssessmentWorkers * 2 {
+		inputs = append(inputs, inputs[0])
+	}
+
+	results, err := u.AssessmentBatch(ctx, inputs)
+	if err != nil {
+		t.Fatalf("AssessmentBatch: %v", err)
+	}
+	if rates.calls != 1 {
+		t.Errorf("GetCurrency called %d times, want 1", rates.calls)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results, want %d", len(results), len(inputs))
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("results[%d].Index = %d", i, result.Index)
+		}
+	}
+
+	if a := results[0].Assessment; a == nil || a.AmountKZT != 5000000 || len(a.Delivereds) != 3 {
+		t.Errorf("results[0] = %+v, want 5000000 KZT and all routes", results[0])
+	}
+	if a := results[1].Assessment; a == nil || a.AmountKZT != 5000000 || len(a.Delivereds) != 1 || a.Delivereds[0].ToCity != "Астана" {
+		t.Errorf("results[1] = %+v, want AED price and only Астана", results[1])
+	}
+	if a := results[5].Assessment; a == nil || len(a.Delivereds) != 1 || a.Delivereds[0].FromCity != "Шарджа" {
+		t.Errorf("results[5] = %+v, want only routes from Шарджа", results[5])
+	}
+	for i, want := range map[int]string{2: "mark and model", 3: "price", 4: "GBP", 6: "volume"} {
+		if results[i].Assessment != nil || !strings.Contains(results[i].Error, want) {
+			t.Errorf("results[%d] = %+v, want error about %s", i, results[i], want)
+		}
+	}
+	if results[1].Input.Mark != "TOYOTA" || results[1].Input.Currency != "AED" {
+		t.Errorf("results[1].Input = %+v, want normalized input", results[1].Input)
+	}
+
+	saved := 0
+	for _, result := range results {
+		if result.Assessment == nil {
+			continue
+		}
+		saved++
+		if _, err := u.GetAssessment(ctx, result.Assessment.ID); err != nil {
+			t.Errorf("GetAssessment(%s): %v", result.Assessment.ID, err)
+		}
+	}
+	if saved != len(store.assessments) {
+		t.Errorf("saved %d assessments, store has %d", saved, len(store.assessments))
+	}
+}
+
+func TestAssessmentBatchLimits(t *testing.T) {
+	ctx := context.Background()
+	u := newTestUseCase(newFakeStore(), newFakeRates())
+
+	if _, err := u.AssessmentBatch(ctx, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("empty batch: %v, want ErrInvalidArgument", err)
+	}
+	if _, err := u.AssessmentBatch(ctx, make([]AssessmentInput, BatchAssessmentLimit+1)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("oversized batch: %v, want ErrInvalidArgument", err)
+	}
+
+	rates := newFakeRates()
+	rates.err = errRatesUnavailable
+	u = newTestUseCase(newFakeStore(), rates)
+	if _, err := u.AssessmentBatch(ctx, []AssessmentInput{{Mark: "KIA", Model: "K5", Amount: 1, Year: 2020}}); !errors.Is(err, errRatesUnavailable) {
+		t.Errorf("batch without rates: %v, want errRatesUnavailable", err)
+	}
+}
@@ -0,0 +1,371 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// Catalog - неизменяемый снимок справочника КГД: марки, модели, объемы и годы
+// в том же порядке, что отдают запросы к базе. Снимок строится целиком и
+// подменяется атомарно, поэтому запрос никогда не видит справочник наполовину
+// обновленным. Срезы снимка общие для всех запросов, менять их нельзя.
+type Catalog struct {
+	etag           string
+	tree           CatalogTree
+	marks          []Mark
+	models         map[string][]Model
+	volumes        map[catalogKey][]Volume
+	specifications map[catalogKey][]Specification
+}
+
+// CatalogTree - весь справочник одним деревом марка -> модель -> объем -> годы
+// для клиентов, которые скачивают его целиком. Version складывается из номера
+// загрузки КГД и хеша содержимого: после импорта или слияния моделей она другая.
+type CatalogTree struct {
+	Version  string
+	ImportID int64
+	Marks    []CatalogMark
+}
+
+type CatalogMark struct {
+	Name   string
+	Models []CatalogModel
+}
+
+// CatalogModel - модель справочника. VehicleClasses - классы транспорта ее
+// комплектаций в порядке ClassM1, ClassN1, ClassL.
+type CatalogModel struct {
+	Name           string
+	Variants       []string
+	VehicleClasses []string
+	Volumes        []CatalogVolume
+}
+
+type CatalogVolume struct {
+	Volume         int
+	EngineTypes    []string
+	Specifications []Specification
+}
+
+// catalogKey - модель марки или, с объемом, конкретная комплектация.
+type catalogKey struct {
+	mark   string
+	model  string
+	volume int
+}
+
+// catalogCache хранит текущий снимок. UseCase копируется по значению, поэтому
+// снимок общий через указатель на кеш.
+type catalogCache struct {
+	current atomic.Pointer[Catalog]
+}
+
+// RefreshCatalog перечитывает справочник из базы и подменяет снимок в памяти.
+// Вызывается при старте и после всего, что меняет справочник или его порядок:
+// загрузки КГД, слияния моделей, правки популярности.
+func (u UseCase) RefreshCatalog(ctx context.Context) error {
+	importID, err := u.repo.GetLastImportID(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := u.repo.GetCatalogRows(ctx)
+	if err != nil {
+		return err
+	}
+	popularity, err := u.repo.GetPopularity(ctx, "")
+	if err != nil {
+		return err
+	}
+	u.catalog.current.Store(buildCatalog(importID, rows, popularity))
+	return nil
+}
+
+// SyncCatalog сверяет снимок с последней загрузкой КГД в базе и, если
+// справочник заменили в обход сервера, например командой kgd rollback,
+// пересобирает поисковый индекс и снимок. Сообщает, был ли снимок пересобран.
+func (u UseCase) SyncCatalog(ctx context.Context) (bool, error) {
+	importID, err := u.repo.GetLastImportID(ctx)
+	if err != nil {
+		return false, err
+	}
+	if c := u.catalog.current.Load(); c != nil && c.tree.ImportID == importID {
+		return false, nil
+	}
+	if err := u.RebuildSearchIndex(ctx); err != nil {
+		return false, err
+	}
+	return true, u.RefreshCatalog(ctx)
+}
+
+// refreshCatalog обновляет снимок после изменения данных. При ошибке остается
+// прежний снимок: он устарел, но согласован.
+func (u UseCase) refreshCatalog(ctx context.Context) {
+	if err := u.RefreshCatalog(ctx); err != nil {
+		slog.Error("RefreshCatalog", slog.String("err", err.Error()))
+	}
+}
+
+// CatalogETag возвращает ETag текущего снимка справочника в кавычках или
+// пустую строку, пока снимок не построен. ETag меняется вместе с содержимым.
+func (u UseCase) CatalogETag() string {
+	if c := u.catalog.current.Load(); c != nil {
+		return c.etag
+	}
+	return ""
+}
+
+// CatalogFilter ограничивает дерево справочника типами двигателя и классами
+// транспорта; пустой список не ограничивает, пустой фильтр - весь справочник.
+type CatalogFilter struct {
+	EngineTypes    []string
+	VehicleClasses []string
+}
+
+// GetCatalogTree отдает справочник деревом. Если снимок еще не построен, строит
+// его. В дереве по фильтру остаются только подходящие годы, а объемы, модели и
+// марки без них убираются; к версии добавляются значения из фильтра.
+func (u UseCase) GetCatalogTree(ctx context.Context, filter CatalogFilter) (CatalogTree, error) {
+	engineTypes, err := normalizeFilter(filter.EngineTypes, "engine type", normalizeEngineType)
+	if err != nil {
+		return CatalogTree{}, err
+	}
+	classes, err := normalizeFilter(filter.VehicleClasses, "vehicle class", normalizeVehicleClass)
+	if err != nil {
+		return CatalogTree{}, err
+	}
+
+	c, err := u.loadCatalog(ctx)
+	if err != nil {
+		return CatalogTree{}, err
+	}
+	if len(engineTypes) == 0 && len(classes) == 0 {
+		return c.tree, nil
+	}
+	return filterCatalogTree(c.tree, engineTypes, classes), nil
+}
+
+// normalizeFilter проверяет значения фильтра и убирает повторы. Пустое
+// значение - ошибка, а не «все»: normalize превратил бы его в значение по умолчанию.
+func normalizeFilter(values []string, name string, normalize func(string) (string, error)) ([]string, error) {
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("%w: empty %s", ErrInvalidArgument, name)
+		}
+		value, err := normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(normalized, value) {
+			normalized = append(normalized, value)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+func filterCatalogTree(tree CatalogTree, engineTypes, classes []string) CatalogTree {
+	matches := func(s Specification) bool {
+		return (len(engineTypes) == 0 || slices.Contains(engineTypes, s.EngineType)) &&
+			(len(classes) == 0 || slices.Contains(classes, s.VehicleClass))
+	}
+	filtered := CatalogTree{
+		Version:  tree.Version + "-" + strings.Join(append(slices.Clone(engineTypes), classes...), "+"),
+		ImportID: tree.ImportID,
+		Marks:    make([]CatalogMark, 0),
+	}
+	for _, mark := range tree.Marks {
+		m := CatalogMark{Name: mark.Name, Models: make([]CatalogModel, 0)}
+		for _, model := range mark.Models {
+			cm := CatalogModel{Name: model.Name, Variants: model.Variants, Volumes: make([]CatalogVolume, 0)}
+			for _, volume := range model.Volumes {
+				specifications := make([]Specification, 0, len(volume.Specifications))
+				for _, s := range volume.Specifications {
+					if matches(s) {
+						specifications = append(specifications, s)
+					}
+				}
+				if len(specifications) > 0 {
+					cm.Volumes = append(cm.Volumes, CatalogVolume{
+						Volume:         volume.Volume,
+						EngineTypes:    engineTypesOf(specifications),
+						Specifications: specifications,
+					})
+				}
+			}
+			if len(cm.Volumes) > 0 {
+				cm.VehicleClasses = vehicleClassesOf(cm.Volumes)
+				m.Models = append(m.Models, cm)
+			}
+		}
+		if len(m.Models) > 0 {
+			filtered.Marks = append(filtered.Marks, m)
+		}
+	}
+	return filtered
+}
+
+// loadCatalog возвращает текущий снимок, при необходимости построив его.
+func (u UseCase) loadCatalog(ctx context.Context) (*Catalog, error) {
+	if c := u.catalog.current.Load(); c != nil {
+		return c, nil
+	}
+	if err := u.RefreshCatalog(ctx); err != nil {
+		return nil, err
+	}
+	return u.catalog.current.Load(), nil
+}
+
+func buildCatalog(importID int64, rows []repository.CatalogRow, popularity []repository.Popularity) *Catalog {
+	c := &Catalog{
+		marks:          make([]Mark, 0),
+		models:         make(map[string][]Model),
+		volumes:        make(map[catalogKey][]Volume),
+		specifications: make(map[catalogKey][]Specification),
+	}
+
+	// после объединения написаний у модели может оказаться несколько строк на
+	// один год и тип двигателя: остается сумма канонического написания, а без
+	// него - первого по алфавиту
+	type yearKey struct {
+		catalogKey
+		year       int
+		engineType string
+	}
+	years := make(map[yearKey]int)
+
+	// строки отсортированы по марке, модели и написанию, поэтому модели и их
+	// написания собираются подряд, а объемы и годы разных написаний - вперемешку
+	for _, row := range rows {
+		models, ok := c.models[row.Mark]
+		if !ok {
+			c.marks = append(c.marks, Mark{Name: row.Mark})
+		}
+		if len(models) == 0 || models[len(models)-1].Name != row.Model {
+			models = append(models, Model{Name: row.Model, Variants: make([]string, 0)})
+		}
+		last := &models[len(models)-1]
+		if row.Variant != "" && row.Variant != row.Model && !slices.Contains(last.Variants, row.Variant) {
+			last.Variants = append(last.Variants, row.Variant)
+		}
+		c.models[row.Mark] = models
+
+		modelKey := catalogKey{mark: row.Mark, model: row.Model}
+		volumeKey := catalogKey{mark: row.Mark, model: row.Model, volume: row.Volume}
+		if _, ok := c.specifications[volumeKey]; !ok {
+			c.volumes[modelKey] = append(c.volumes[modelKey], Volume{Value: row.Volume})
+		}
+		specification := Specification{
+			Year:            row.Year,
+			Amount:          row.Amount,
+			EngineType:      engineTypeOrICE(row.EngineType),
+			BatteryCapacity: row.BatteryCapacity,
+			Power:           row.Power,
+			VehicleClass:    vehicleClassOrM1(row.VehicleClass),
+		}
+		key := yearKey{catalogKey: volumeKey, year: specification.Year, engineType: specification.EngineType}
+		if i, ok := years[key]; ok {
+			if row.Variant == "" || row.Variant == row.Model {
+				c.specifications[volumeKey][i] = specification
+			}
+			continue
+		}
+		years[key] = len(c.specifications[volumeKey])
+		c.specifications[volumeKey] = append(c.specifications[volumeKey], specification)
+	}
+
+	for modelKey, volumes := range c.volumes {
+		slices.SortFunc(volumes, func(a, b Volume) int { return cmp.Compare(a.Value, b.Value) })
+		for i := range volumes {
+			volumeKey := catalogKey{mark: modelKey.mark, model: modelKey.model, volume: volumes[i].Value}
+			volumes[i].EngineTypes = engineTypesOf(c.specifications[volumeKey])
+		}
+	}
+	for _, specifications := range c.specifications {
+		slices.SortStableFunc(specifications, func(a, b Specification) int { return cmp.Compare(b.Year, a.Year) })
+	}
+
+	// популярные марки и модели выше, при равенстве остается порядок по имени
+	weights := make(map[catalogKey]repository.Popularity, len(popularity))
+	for _, p := range popularity {
+		weights[catalogKey{mark: p.Mark, model: p.Model}] = p
+	}
+	byPopularity := func(a, b repository.Popularity) int {
+		return cmp.Or(cmp.Compare(b.Weight, a.Weight), cmp.Compare(b.Requests, a.Requests))
+	}
+	slices.SortStableFunc(c.marks, func(a, b Mark) int {
+		return byPopularity(weights[catalogKey{mark: a.Name}], weights[catalogKey{mark: b.Name}])
+	})
+	for mark, models := range c.models {
+		slices.SortStableFunc(models, func(a, b Model) int {
+			return byPopularity(weights[catalogKey{mark: mark, model: a.Name}], weights[catalogKey{mark: mark, model: b.Name}])
+		})
+	}
+
+	c.tree = catalogTree(c, importID)
+	c.etag = `"` + c.tree.Version + `"`
+	return c
+}
+
+// catalogTree собирает дерево из снимка и хеширует его в порядке выдачи,
+// поэтому версия меняется и при смене цен, и при смене порядка марок и моделей.
+func catalogTree(c *Catalog, importID int64) CatalogTree {
+	tree := CatalogTree{
+		ImportID: importID,
+		Marks:    make([]CatalogMark, 0, len(c.marks)),
+	}
+	h := sha256.New()
+	for _, mark := range c.marks {
+		fmt.Fprintf(h, "%s\n", mark.Name)
+		treeMark := CatalogMark{Name: mark.Name, Models: make([]CatalogModel, 0, len(c.models[mark.Name]))}
+		for _, model := range c.models[mark.Name] {
+			fmt.Fprintf(h, "\t%s %q\n", model.Name, model.Variants)
+			volumes := c.volumes[catalogKey{mark: mark.Name, model: model.Name}]
+			treeModel := CatalogModel{Name: model.Name, Variants: model.Variants, Volumes: make([]CatalogVolume, 0, len(volumes))}
+			for _, volume := range volumes {
+				specifications := c.specifications[catalogKey{mark: mark.Name, model: model.Name, volume: volume.Value}]
+				fmt.Fprintf(h, "\t\t%d %v\n", volume.Value, specifications)
+				treeModel.Volumes = append(treeModel.Volumes, CatalogVolume{Volume: volume.Value, EngineTypes: volume.EngineTypes, Specifications: specifications})
+			}
+			treeModel.VehicleClasses = vehicleClassesOf(treeModel.Volumes)
+			treeMark.Models = append(treeMark.Models, treeModel)
+		}
+		tree.Marks = append(tree.Marks, treeMark)
+	}
+	tree.Version = fmt.Sprintf("%d-%x", importID, h.Sum(nil)[:8])
+	return tree
+}
+
+// engineTypesOf перечисляет типы двигателей комплектации в порядке EngineICE,
+// EngineHybrid, EngineEV: у одного объема бывают и ДВС, и гибрид.
+func engineTypesOf(specifications []Specification) []string {
+	types := make([]string, 0, 1)
+	for _, engineType := range []string{EngineICE, EngineHybrid, EngineEV} {
+		if slices.ContainsFunc(specifications, func(s Specification) bool { return s.EngineType == engineType }) {
+			types = append(types, engineType)
+		}
+	}
+	return types
+}
+
+// vehicleClassesOf перечисляет классы транспорта комплектаций модели в порядке
+// ClassM1, ClassN1, ClassL.
+func vehicleClassesOf(volumes []CatalogVolume) []string {
+	classes := make([]string, 0, 1)
+	for _, class := range []string{ClassM1, ClassN1, ClassL} {
+		if slices.ContainsFunc(volumes, func(v CatalogVolume) bool {
+			return slices.ContainsFunc(v.Specifications, func(s Specification) bool { return s.VehicleClass == class })
+		}) {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func catalogRow(mark, model, variant string, volume, year, amount int) repository.CatalogRow {
+	return repository.CatalogRow{Mark: mark, Model: model, Variant: variant, Volume: volume, Year: year, Amount: amount}
+}
+
+func TestCatalog(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("ACURA", "MDX", "MDX", 3500, 2019, 35000),
+		catalogRow("HYUNDAI", "SONATA", "NF SONATA", 2000, 2021, 17000),
+		catalogRow("HYUNDAI", "SONATA", "SONATA", 2000, 2021, 18000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2020, 20000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 3500, 2022, 30000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY 70", 2000, 2021, 21000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY 70", 2500, 2022, 26000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY 70", 2500, 2021, 22000),
+		catalogRow("TOYOTA", "LAND CRUISER 200", "LC200", 4600, 2015, 40000),
+	}
+	store.popularity = []repository.Popularity{
+		{Mark: "TOYOTA", Weight: 10},
+		{Mark: "HYUNDAI", Requests: 5},
+		{Mark: "TOYOTA", Model: "LAND CRUISER 200", Weight: 1},
+	}
+	uc := newTestUseCase(store, newFakeRates())
+	ice := []string{EngineICE}
+
+	if etag := uc.CatalogETag(); etag != "" {
+		t.Errorf("CatalogETag before refresh = %q, want empty", etag)
+	}
+	if err := uc.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+
+	marks, _ := uc.GetMarks(ctx)
+	if want := []Mark{{"TOYOTA"}, {"HYUNDAI"}, {"ACURA"}}; !reflect.DeepEqual(marks, want) {
+		t.Errorf("GetMarks = %v, want %v", marks, want)
+	}
+	models, _ := uc.GetModels(ctx, "TOYOTA")
+	wantModels := []Model{{"LAND CRUISER 200", []string{"LC200"}}, {"CAMRY", []string{"CAMRY 70"}}}
+	if !reflect.DeepEqual(models, wantModels) {
+		t.Errorf("GetModels = %v, want %v", models, wantModels)
+	}
+	volumes, _ := uc.GetVolumes(ctx, "TOYOTA", "CAMRY")
+	if want := []Volume{{2000, ice}, {2500, ice}, {3500, ice}}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("GetVolumes = %v, want %v", volumes, want)
+	}
+	specifications, _ := uc.GetSpecifications(ctx, "TOYOTA", "CAMRY", 2500)
+	if want := []Specification{{2022, 25000, EngineICE, 0, 0, ClassM1}, {2021, 22000, EngineICE, 0, 0, ClassM1}, {2020, 20000, EngineICE, 0, 0, ClassM1}}; !reflect.DeepEqual(specifications, want) {
+		t.Errorf("GetSpecifications = %v, want %v", specifications, want)
+	}
+	// у объединенных написаний один год остается одной строкой с суммой
+	// канонического написания, в каком бы порядке они ни шли
+	specifications, _ = uc.GetSpecifications(ctx, "HYUNDAI", "SONATA", 2000)
+	if want := []Specification{{2021, 18000, EngineICE, 0, 0, ClassM1}}; !reflect.DeepEqual(specifications, want) {
+		t.Errorf("GetSpecifications of merged spellings = %v, want %v", specifications, want)
+	}
+	if models, _ := uc.GetModels(ctx, "KIA"); models == nil || len(models) != 0 {
+		t.Errorf("GetModels of an unknown mark = %#v, want an empty list", models)
+	}
+
+	etag := uc.CatalogETag()
+	if etag == "" {
+		t.Fatal("CatalogETag after refresh is empty")
+	}
+	if err := uc.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+	if got := uc.CatalogETag(); got != etag {
+		t.Errorf("CatalogETag of the same data = %q, want %q", got, etag)
+	}
+
+	// новый снимок подменяет старый целиком, и ETag меняется вместе с данными
+	store.catalogRows[4].Amount = 20500
+	if err := uc.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+	if got := uc.CatalogETag(); got == etag {
+		t.Errorf("CatalogETag did not change after the amount changed")
+	}
+	specifications, _ = uc.GetSpecifications(ctx, "TOYOTA", "CAMRY", 2500)
+	if specifications[2].Amount != 20500 {
+		t.Errorf("GetSpecifications after refresh = %v, want the new amount", specifications)
+	}
+}
+
+func TestGetCatalogTree(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.importID = 7
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2020, 20000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY 70", 3500, 2021, 30000),
+	}
+	uc := newTestUseCase(store, newFakeRates())
+
+	ice := []string{EngineICE}
+
+	// без снимка дерево строится при первом запросе
+	tree, err := uc.GetCatalogTree(ctx, CatalogFilter{})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	want := []CatalogMark{{Name: "TOYOTA", Models: []CatalogModel{{
+		Name:           "CAMRY",
+		Variants:       []string{"CAMRY 70"},
+		VehicleClasses: []string{ClassM1},
+		Volumes: []CatalogVolume{
+			{Volume: 2500, EngineTypes: ice, Specifications: []Specification{{2022, 25000, EngineICE, 0, 0, ClassM1}, {2020, 20000, EngineICE, 0, 0, ClassM1}}},
+			{Volume: 3500, EngineTypes: ice, Specifications: []Specification{{2021, 30000, EngineICE, 0, 0, ClassM1}}},
+		},
+	}}}}
+	if !reflect.DeepEqual(tree.Marks, want) {
+		t.Errorf("GetCatalogTree marks = %+v, want %+v", tree.Marks, want)
+	}
+	if tree.ImportID != 7 || !strings.HasPrefix(tree.Version, "7-") {
+		t.Errorf("GetCatalogTree import = %d, version %q, want import 7", tree.ImportID, tree.Version)
+	}
+	if etag := uc.CatalogETag(); etag != `"`+tree.Version+`"` {
+		t.Errorf("CatalogETag = %s, want the quoted version %q", etag, tree.Version)
+	}
+
+	// та же выгрузка под новым номером импорта - другая версия
+	store.importID = 8
+	if err := uc.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+	next, _ := uc.GetCatalogTree(ctx, CatalogFilter{})
+	if !strings.HasPrefix(next.Version, "8-") || next.Version[2:] != tree.Version[2:] {
+		t.Errorf("version after a new import = %q, want the same hash as %q with import 8", next.Version, tree.Version)
+	}
+}
+
+func TestGetCatalogTreeEngineFilter(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	ev := catalogRow("HYUNDAI", "IONIQ 5", "IONIQ 5", 0, 2023, 45000)
+	ev.EngineType, ev.BatteryCapacity, ev.Power = EngineEV, 77.4, 239
+	hybrid := catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2023, 28000)
+	hybrid.EngineType = EngineHybrid
+	store.catalogRows = []repository.CatalogRow{
+		ev,
+		hybrid,
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000),
+		catalogRow("TOYOTA", "LAND CRUISER 200", "LC200", 4600, 2015, 40000),
+	}
+	uc := newTestUseCase(store, newFakeRates())
+
+	full, err := uc.GetCatalogTree(ctx, CatalogFilter{})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	camry := full.Marks[1].Models[0].Volumes[0]
+	if want := []string{EngineICE, EngineHybrid}; !reflect.DeepEqual(camry.EngineTypes, want) {
+		t.Errorf("CAMRY 2500 engine types = %v, want %v", camry.EngineTypes, want)
+	}
+	volumes, _ := uc.GetVolumes(ctx, "HYUNDAI", "IONIQ 5")
+	if want := []Volume{{0, []string{EngineEV}}}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("GetVolumes(IONIQ 5) = %v, want %v", volumes, want)
+	}
+
+	tree, err := uc.GetCatalogTree(ctx, CatalogFilter{EngineTypes: []string{"EV", "hybrid", "ev"}})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	want := []CatalogMark{
+		{Name: "HYUNDAI", Models: []CatalogModel{{Name: "IONIQ 5", Variants: []string{}, VehicleClasses: []string{ClassM1}, Volumes: []CatalogVolume{
+			{Volume: 0, EngineTypes: []string{EngineEV}, Specifications: []Specification{{2023, 45000, EngineEV, 77.4, 239, ClassM1}}},
+		}}}},
+		{Name: "TOYOTA", Models: []CatalogModel{{Name: "CAMRY", Variants: []string{}, VehicleClasses: []string{ClassM1}, Volumes: []CatalogVolume{
+			{Volume: 2500, EngineTypes: []string{EngineHybrid}, Specifications: []Specification{{2023, 28000, EngineHybrid, 0, 0, ClassM1}}},
+		}}}},
+	}
+	if !reflect.DeepEqual(tree.Marks, want) {
+		t.Errorf("filtered tree = %+v, want %+v", tree.Marks, want)
+	}
+	if tree.Version != full.Version+"-ev+hybrid" {
+		t.Errorf("filtered version = %q, want %q", tree.Version, full.Version+"-ev+hybrid")
+	}
+
+	if _, err := uc.GetCatalogTree(ctx, CatalogFilter{EngineTypes: []string{"diesel"}}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("unknown engine type = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestGetCatalogTreeVehicleClassFilter(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	pickup := catalogRow("TOYOTA", "HILUX", "HILUX", 2800, 2021, 30000)
+	pickup.VehicleClass = ClassN1
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000),
+		pickup,
+	}
+	uc := newTestUseCase(store, newFakeRates())
+
+	full, err := uc.GetCatalogTree(ctx, CatalogFilter{})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	tree, err := uc.GetCatalogTree(ctx, CatalogFilter{VehicleClasses: []string{"n1"}})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	want := []CatalogMark{{Name: "TOYOTA", Models: []CatalogModel{{Name: "HILUX", Variants: []string{}, VehicleClasses: []string{ClassN1}, Volumes: []CatalogVolume{
+		{Volume: 2800, EngineTypes: []string{EngineICE}, Specifications: []Specification{{2021, 30000, EngineICE, 0, 0, ClassN1}}},
+	}}}}}
+	if !reflect.DeepEqual(tree.Marks, want) {
+		t.Errorf("filtered tree = %+v, want %+v", tree.Marks, want)
+	}
+	if tree.Version != full.Version+"-N1" {
+		t.Errorf("filtered version = %q, want %q", tree.Version, full.Version+"-N1")
+	}
+
+	// фильтры складываются: легковых электромобилей здесь нет
+	tree, err = uc.GetCatalogTree(ctx, CatalogFilter{EngineTypes: []string{EngineEV}, VehicleClasses: []string{ClassM1}})
+	if err != nil {
+		t.Fatalf("GetCatalogTree: %v", err)
+	}
+	if len(tree.Marks) != 0 || tree.Version != full.Version+"-ev+M1" {
+		t.Errorf("filtered tree = %+v, want no marks and version suffix -ev+M1", tree)
+	}
+
+	for _, classes := range [][]string{{"M3"}, {" "}} {
+		if _, err := uc.GetCatalogTree(ctx, CatalogFilter{VehicleClasses: classes}); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("vehicle classes %q = %v, want ErrInvalidArgument", classes, err)
+		}
+	}
+}
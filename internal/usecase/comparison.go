@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+)
+
+// YearComparison - цена под ключ одной комплектации по всем годам из списка
+// КГД, от нового к старому, по курсу Rate. CheapestYear - год с наименьшей
+// ценой; при равной цене - более новый.
+type YearComparison struct {
+	Mark         string
+	Model        string
+	Volume       int
+	Rate         float64
+	CheapestYear int
+	Years        []YearCost
+}
+
+// YearCost - из чего складывается цена под ключ авто одного года выпуска.
+// Amount - оценка КГД в долларах, остальные суммы в тенге. Difference -
+// насколько год дороже самого дешевого.
+type YearCost struct {
+	Year                    int
+	VehicleClass            string
+	EngineType              string
+	Amount                  int
+	AmountKZT               int
+	CustomsDutyAmount       int
+	CustomsCollectionAmount int
+	VATAmount               int
+	FirstRegistrationAmount int
+	UtilAmount              int
+	TurnkeyAmount           int
+	Difference              int
+	Cheapest                bool
+}
+
+// CompareYears отвечает на вопрос "а если взять на год новее или старше":
+// считает цену под ключ для каждого года комплектации по текущему курсу.
+// Сбор за первичную регистрацию зависит от возраста, поэтому более старый
+// год не всегда дешевле.
+func (u UseCase) CompareYears(ctx context.Context, mark, model string, volume int) (YearComparison, error) {
+	mark, model = NormalizeName(mark), NormalizeName(model)
+	if mark == "" || model == "" || volume < 0 {
+		return YearComparison{}, fmt.Errorf("%w: mark, model and volume are required", ErrInvalidArgument)
+	}
+
+	specifications, err := u.GetSpecifications(ctx, mark, model, volume)
+	if err != nil {
+		return YearComparison{}, err
+	}
+	if len(specifications) == 0 {
+		return YearComparison{}, fmt.Errorf("%w: %s %s %d", ErrNotFound, mark, model, volume)
+	}
+
+	currency, err := u.rates.GetCurrency(ctx)
+	if err != nil {
+		return YearComparison{}, err
+	}
+	rate := currency.Rates.KZT
+
+	comparison := YearComparison{
+		Mark:   mark,
+		Model:  model,
+		Volume: volume,
+		Rate:   rate,
+		Years:  make([]YearCost, 0, len(specifications)),
+	}
+	cheapest := 0
+	for i, spec := range specifications {
+		cost := u.turnkeyCost(int(rate*float64(spec.Amount)), spec.vehicle(volume))
+		cost.Year = spec.Year
+		cost.Amount = spec.Amount
+		comparison.Years = append(comparison.Years, cost)
+		if cost.TurnkeyAmount < comparison.Years[cheapest].TurnkeyAmount {
+			cheapest = i
+		}
+	}
+
+	minimum := comparison.Years[cheapest].TurnkeyAmount
+	for i := range comparison.Years {
+		comparison.Years[i].Difference = comparison.Years[i].TurnkeyAmount - minimum
+	}
+	comparison.Years[cheapest].Cheapest = true
+	comparison.CheapestYear = comparison.Years[cheapest].Year
+	return comparison, nil
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestCompareYears(t *testing.T) {
+	year := time.Now().Year()
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, year, 10000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, year-2, 9000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, year-5, 8000),
+	}
+	rates := newFakeRates()
+	u := newTestUseCase(store, rates)
+	ctx := context.Background()
+	if err := u.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+
+	comparison, err := u.CompareYears(ctx, "toyota", " camry ", 2500)
+	if err != nil {
+		t.Fatalf("CompareYears: %v", err)
+	}
+	if len(comparison.Years) != 3 || comparison.Rate != 500 || rates.calls != 1 {
+		t.Fatalf("CompareYears = %+v, rates called %d times", comparison, rates.calls)
+	}
+
+	// более старый авто дешевле по оценке, но сбор за регистрацию растет с возрастом
+	wantRegistration := []int{3692 / 4, 3692 * 50, 3692 * 500}
+	for i, cost := range comparison.Years {
+		if cost.FirstRegistrationAmount != wantRegistration[i] {
+			t.Errorf("year %d registration = %d, want %d", cost.Year, cost.FirstRegistrationAmount, wantRegistration[i])
+		}
+		if cost.UtilAmount != 3692*50*5 {
+			t.Errorf("year %d util = %d, want %d", cost.Year, cost.UtilAmount, 3692*50*5)
+		}
+		if cost.TurnkeyAmount != u.calcTurnkey(cost.AmountKZT, vehicle{EngineType: EngineICE, Volume: 2500, Year: cost.Year}) {
+			t.Errorf("year %d turnkey = %d, want calcTurnkey", cost.Year, cost.TurnkeyAmount)
+		}
+	}
+	if comparison.CheapestYear != year-2 || !comparison.Years[1].Cheapest || comparison.Years[1].Difference != 0 {
+		t.Errorf("cheapest = %d, years %+v", comparison.CheapestYear, comparison.Years)
+	}
+	if comparison.Years[0].Difference <= 0 || comparison.Years[2].Difference <= 0 || comparison.Years[0].Cheapest {
+		t.Errorf("differences = %+v", comparison.Years)
+	}
+
+	if _, err := u.CompareYears(ctx, "TOYOTA", "CAMRY", 3500); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown volume = %v, want ErrNotFound", err)
+	}
+	if _, err := u.CompareYears(ctx, "", "CAMRY", 2500); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("empty mark = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestFeeBrackets(t *testing.T) {
+	u := NewUseCase(newFakeStore(), nil, nil, nil)
+	rules := u.rules(ClassM1)
+	for _, tt := range []struct {
+		age, want int
+	}{
+		{-1, 923}, {0, 923}, {1, 923}, {2, 3692 * 50}, {3, 3692 * 50}, {4, 3692 * 500}, {20, 3692 * 500},
+	} {
+		if got := rules.firstRegistrationAmount(EngineICE, tt.age); got != tt.want {
+			t.Errorf("firstRegistrationAmount(age %d) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+	base := 3692 * 50
+	for _, tt := range []struct {
+		volume int
+		want   int
+	}{
+		{0, base * 3 / 2}, {1000, base * 3 / 2}, {1001, base * 7 / 2}, {2000, base * 7 / 2},
+		{2001, base * 5}, {3000, base * 5}, {3001, base * 23 / 2}, {5700, base * 23 / 2},
+	} {
+		if got := rules.utilAmount(EngineICE, tt.volume); got != tt.want {
+			t.Errorf("utilAmount(%d) = %d, want %d", tt.volume, got, tt.want)
+		}
+	}
+}
+
+func TestClassFees(t *testing.T) {
+	u := NewUseCase(newFakeStore(), nil, nil, nil)
+	base := 3692 * 50
+	tests := []struct {
+		class, engineType string
+		volume, age       int
+		registration      int
+		util              int
+	}{
+		{ClassM1, EngineEV, 0, 5, 0, base * 3 / 2},
+		{ClassM1, EngineHybrid, 2500, 0, 923, base * 5},
+		{ClassN1, EngineICE, 2500, 10, 3692 * 4, base * 2},
+		{ClassN1, EngineICE, 2800, 0, 3692 * 4, base * 4},
+		{ClassN1, EngineEV, 0, 0, 0, base * 2},
+		{ClassL, EngineICE, 1000, 10, 3692 * 2, 0},
+		{ClassL, EngineEV, 0, 0, 0, 0},
+		// неизвестный класс считается легковым
+		{"", EngineICE, 1600, 10, 3692 * 500, base * 7 / 2},
+	}
+	for _, tt := range tests {
+		rules := u.rules(tt.class)
+		if got := rules.firstRegistrationAmount(tt.engineType, tt.age); got != tt.registration {
+			t.Errorf("%s %s firstRegistrationAmount(age %d) = %d, want %d", tt.class, tt.engineType, tt.age, got, tt.registration)
+		}
+		if got := rules.utilAmount(tt.engineType, tt.volume); got != tt.util {
+			t.Errorf("%s %s utilAmount(%d) = %d, want %d", tt.class, tt.engineType, tt.volume, got, tt.util)
+		}
+	}
+
+	// сборы считаются от МРП из снимка ставок, а не от текущего
+	rules := u.rules(ClassM1)
+	rules.MRP = 4000
+	if got := rules.utilAmount(EngineICE, 2000); got != 4000*50*7/2 {
+		t.Errorf("utilAmount with MRP 4000 = %d, want %d", got, 4000*50*7/2)
+	}
+	if got := rules.firstRegistrationAmount(EngineICE, 3); got != 4000*50 {
+		t.Errorf("firstRegistrationAmount with MRP 4000 = %d, want %d", got, 4000*50)
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// DigestCar - популярное авто, для которого в дайджесте считается пример цены под ключ.
+type DigestCar struct {
+	Mark     string
+	Model    string
+	Volume   int
+	PriceUSD int
+}
+
+type DigestRate struct {
+	Name          string
+	Value         float64
+	Change        float64
+	ChangePercent float64
+	HasPrevious   bool
+}
+
+type Digest struct {
+	Date  time.Time
+	Rates []DigestRate
+	Car   *Quote
+}
+
+// RatesDigest берет текущие курсы, сохраняет их снимок за день и сравнивает
+// с предыдущим сохраненным днем. Пример авто необязателен: если его нет в
+// списке КГД, дайджест собирается без него.
+func (u UseCase) RatesDigest(ctx context.Context, now time.Time, car DigestCar) (Digest, error) {
+	currency, err := u.rates.GetCurrency(ctx)
+	if err != nil {
+		return Digest{}, err
+	}
+	if currency.Rates.KZT == 0 || currency.Rates.AED == 0 || currency.Rates.RUB == 0 {
+		return Digest{}, errors.New("exchange rates response has no KZT, AED or RUB rate")
+	}
+
+	// openexchangerates отдает курсы к доллару, поэтому кросс-курсы считаем через него
+	today := repository.ExchangeRate{
+		Date:   now.Format(time.DateOnly),
+		USDKZT: currency.Rates.KZT,
+		AEDKZT: currency.Rates.KZT / currency.Rates.AED,
+		RUBKZT: currency.Rates.KZT / currency.Rates.RUB,
+	}
+
+	previous, hasPrevious, err := u.repo.GetPreviousExchangeRate(ctx, today.Date)
+	if err != nil {
+		return Digest{}, err
+	}
+	if err := u.repo.SaveExchangeRate(ctx, today); err != nil {
+		return Digest{}, err
+	}
+
+	digest := Digest{
+		Date: now,
+		Rates: []DigestRate{
+			digestRate("USD/KZT", today.USDKZT, previous.USDKZT, hasPrevious),
+			digestRate("AED/KZT", today.AEDKZT, previous.AEDKZT, hasPrevious),
+			digestRate("RUB/KZT", today.RUBKZT, previous.RUBKZT, hasPrevious),
+		},
+	}
+
+	if car.Mark != "" {
+		quote, err := u.carQuote(ctx, car.Mark, car.Model, car.Volume, 0, car.PriceUSD, today.USDKZT)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return Digest{}, err
+		}
+		if err == nil {
+			digest.Car = &quote
+		}
+	}
+	return digest, nil
+}
+
+func digestRate(name string, value, previous float64, hasPrevious bool) DigestRate {
+	rate := DigestRate{
+		Name:        name,
+		Value:       value,
+		HasPrevious: hasPrevious && previous != 0,
+	}
+	if rate.HasPrevious {
+		rate.Change = value - previous
+		rate.ChangePercent = rate.Change / previous * 100
+	}
+	return rate
+}
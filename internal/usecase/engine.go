@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Типы двигателя в справочнике КГД и в расчете. Гибриды растаможиваются как
+// машины с ДВС по объему двигателя, у электромобилей свои ставки.
+const (
+	EngineICE    = "ice"
+	EngineHybrid = "hybrid"
+	EngineEV     = "ev"
+)
+
+// normalizeEngineType проверяет тип двигателя из запроса; пустой тип остается
+// пустым, его определяет resolveEngineType.
+func normalizeEngineType(engineType string) (string, error) {
+	switch engineType = strings.ToLower(strings.TrimSpace(engineType)); engineType {
+	case "", EngineICE, EngineHybrid, EngineEV:
+		return engineType, nil
+	}
+	return "", fmt.Errorf("%w: engine type %q, want %s, %s or %s", ErrInvalidArgument, engineType, EngineICE, EngineHybrid, EngineEV)
+}
+
+// resolveEngineType определяет тип двигателя для расчета. Пустой тип - ДВС,
+// а при нулевом объеме - электромобиль, но только если таким его знает
+// строка КГД kgd: пустая ячейка объема в файле дилера - не электромобиль.
+// Нулевой объем допустим только у электромобиля.
+func resolveEngineType(engineType string, volume int, kgd Specification) (string, error) {
+	if engineType == "" {
+		engineType = EngineICE
+		if volume == 0 && kgd.EngineType == EngineEV {
+			engineType = EngineEV
+		}
+	}
+	if volume == 0 && engineType != EngineEV {
+		return "", fmt.Errorf("%w: volume is required unless engine type is %s", ErrInvalidArgument, EngineEV)
+	}
+	return engineType, nil
+}
+
+// engineTypeOrICE возвращает тип двигателя строки справочника; у строк,
+// прочитанных до разметки, он пустой.
+func engineTypeOrICE(engineType string) string {
+	if engineType == "" {
+		return EngineICE
+	}
+	return engineType
+}
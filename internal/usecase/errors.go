@@ -0,0 +1,10 @@
+package usecase
+
+import "errors"
+
+var (
+	// ErrInvalidArgument - ошибка во входных данных, отдается клиенту как 400.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrNotFound - запрошенная запись не найдена, отдается клиенту как 404.
+	ErrNotFound = errors.New("not found")
+)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 //	{
@@ -24,8 +25,8 @@ type OpenExchangeRatesResponse struct {
 	Disclaimer string   `json:"disclaimer"`
 	License    string   `json:"license"`
 	Timestamp  int      `json:"timestamp"`
-	Base       int      `json:"base"`
-	Rates      Currency `json:"rate"`
+	Base       string   `json:"base"`
+	Rates      Currency `json:"rates"`
 }
 
 type Currency struct {
@@ -48,9 +49,34 @@ type OpenExchangeRatesErrorResponse struct {
 	Description string `json:"description"`
 }
 
+// GetCurrency возвращает курсы к доллару, переиспользуя ответ в течение currencyCacheTTL.
 func (c Client) GetCurrency(ctx context.Context) (OpenExchangeRatesResponse, error) {
+	if c.currency == nil {
+		return c.fetchCurrency(ctx)
+	}
+
+	c.currency.mu.Lock()
+	defer c.currency.mu.Unlock()
+	if !c.currency.fetchedAt.IsZero() && time.Since(c.currency.fetchedAt) < currencyCacheTTL {
+		return c.currency.value, nil
+	}
+
+	currency, err := c.fetchCurrency(ctx)
+	if err != nil {
+		return currency, err
+	}
+	c.currency.value = currency
+	c.currency.fetchedAt = time.Now()
+	return currency, nil
+}
+
+func (c Client) fetchCurrency(ctx context.Context) (OpenExchangeRatesResponse, error) {
 	var bodyResp OpenExchangeRatesResponse
-	resp, err := c.httpClient.Get(c.exchangeURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.exchangeURL, nil)
+	if err != nil {
+		return bodyResp, err
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return bodyResp, err
 	}
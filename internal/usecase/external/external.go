@@ -1,11 +1,26 @@
 package external
 
-import "net/http"
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// currencyCacheTTL - сколько переиспользовать полученный курс. Бесплатный тариф
+// openexchangerates ограничен по запросам, а инлайн-режим бота дергает курс часто.
+const currencyCacheTTL = 10 * time.Minute
 
 type Client struct {
 	kgdURL      string
 	exchangeURL string
 	httpClient  http.Client
+	currency    *currencyCache
+}
+
+type currencyCache struct {
+	mu        sync.Mutex
+	value     OpenExchangeRatesResponse
+	fetchedAt time.Time
 }
 
 func NewExternatClient(kgdURL, exchangeURL string) Client {
@@ -13,5 +28,6 @@ func NewExternatClient(kgdURL, exchangeURL string) Client {
 		kgdURL:      kgdURL,
 		exchangeURL: exchangeURL,
 		httpClient:  *http.DefaultClient,
+		currency:    &currencyCache{},
 	}
 }
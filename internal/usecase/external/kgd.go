@@ -1,10 +1,16 @@
 package external
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
 func (c Client) DownloadFile() ([]byte, error) {
@@ -25,3 +31,144 @@ func (c Client) DownloadFile() ([]byte, error) {
 
 	return fileBytes, nil
 }
+
+var (
+	// kgdLinkRe находит ссылки на Excel-файлы на странице КГД вместе с текстом ссылки.
+	kgdLinkRe = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']+\.xlsx?)["'][^>]*>(.*?)</a>`)
+	kgdTagRe  = regexp.MustCompile(`<[^>]*>`)
+	// kgdDateRe - дата в имени файла или тексте ссылки: 01.02.2024, 2024-02-01 или просто год
+	kgdDateRe = regexp.MustCompile(`(\d{2})[./-](\d{2})[./-](\d{4})|(\d{4})[./_-](\d{2})[./_-](\d{2})|\b((?:19|20)\d{2})\b`)
+)
+
+// FindKGDFileURL ищет на странице pageURL ссылку на Excel-файл. Если задан
+// pattern, подходят только ссылки, у которых он находится в адресе или тексте.
+// Из подходящих берется ссылка с самой поздней датой в адресе или тексте, без
+// дат - первая на странице. Относительная ссылка дополняется адресом страницы.
+func (c Client) FindKGDFileURL(ctx context.Context, pageURL string, pattern *regexp.Regexp) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ошибка загрузки страницы КГД: статус %d", resp.StatusCode)
+	}
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Ошибка при чтении страницы КГД: %v", err)
+	}
+
+	href, ok := newestKGDLink(string(page), pattern)
+	if !ok {
+		return "", fmt.Errorf("на странице %s нет ссылки на файл КГД", pageURL)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	link, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(link).String(), nil
+}
+
+// newestKGDLink выбирает из ссылок на Excel-файлы подходящую под pattern с
+// самой поздней датой; при равных датах выигрывает ссылка выше на странице.
+func newestKGDLink(page string, pattern *regexp.Regexp) (string, bool) {
+	var (
+		best     string
+		bestDate time.Time
+		found    bool
+	)
+	for _, match := range kgdLinkRe.FindAllStringSubmatch(page, -1) {
+		href := html.UnescapeString(match[1])
+		text := strings.TrimSpace(html.UnescapeString(kgdTagRe.ReplaceAllString(match[2], "")))
+		if pattern != nil && !pattern.MatchString(href) && !pattern.MatchString(text) {
+			continue
+		}
+
+		date := kgdLinkDate(href)
+		if d := kgdLinkDate(text); d.After(date) {
+			date = d
+		}
+		if !found || date.After(bestDate) {
+			best, bestDate, found = href, date, true
+		}
+	}
+	return best, found
+}
+
+// kgdLinkDate возвращает самую позднюю дату из строки, нулевое время - если дат нет.
+func kgdLinkDate(s string) time.Time {
+	var latest time.Time
+	for _, m := range kgdDateRe.FindAllStringSubmatch(s, -1) {
+		var layout, value string
+		switch {
+		case m[1] != "":
+			layout, value = "02.01.2006", m[1]+"."+m[2]+"."+m[3]
+		case m[4] != "":
+			layout, value = "2006.01.02", m[4]+"."+m[5]+"."+m[6]
+		default:
+			layout, value = "2006", m[7]
+		}
+		if d, err := time.Parse(layout, value); err == nil && d.After(latest) {
+			latest = d
+		}
+	}
+	return latest
+}
+
+// KGDFile - файл КГД и заголовки, по которым сервер сравнит его при следующей
+// проверке. NotModified - сервер ответил 304, Body пустой.
+type KGDFile struct {
+	URL          string
+	Body         []byte
+	ETag         string
+	LastModified string
+	NotModified  bool
+}
+
+// FetchKGDFile скачивает файл fileURL. Непустые etag и lastModified от прошлой
+// загрузки уходят в If-None-Match и If-Modified-Since, чтобы не скачивать
+// неизменившийся файл.
+func (c Client) FetchKGDFile(ctx context.Context, fileURL, etag, lastModified string) (KGDFile, error) {
+	file := KGDFile{URL: fileURL}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return file, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return file, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		file.NotModified = true
+		return file, nil
+	case http.StatusOK:
+	default:
+		return file, fmt.Errorf("ошибка загрузки файла: статус %d", resp.StatusCode)
+	}
+
+	file.Body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return file, fmt.Errorf("Ошибка при чтении данных файла: %v", err)
+	}
+	file.ETag = resp.Header.Get("ETag")
+	file.LastModified = resp.Header.Get("Last-Modified")
+	return file, nil
+}
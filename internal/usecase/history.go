@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Направление последнего изменения стоимости в истории.
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+)
+
+// ValuationHistory - стоимость комплектации в каждой загрузке КГД, от ранней
+// к поздней. Trend сравнивает последнюю загрузку с предыдущей; при одной
+// загрузке он TrendFlat.
+type ValuationHistory struct {
+	Mark    string
+	Model   string
+	Volume  int
+	Year    int
+	Trend   string
+	History []Valuation
+}
+
+// Valuation - стоимость в одной загрузке и ее изменение к предыдущей; у первой
+// загрузки изменение нулевое.
+type Valuation struct {
+	ImportID      int64
+	ImportedAt    string
+	Amount        int
+	Change        int
+	ChangePercent float64
+}
+
+// GetValuationHistory возвращает, как менялась налоговая база комплектации от
+// загрузки к загрузке. Отмененные загрузки в историю не входят.
+func (u UseCase) GetValuationHistory(ctx context.Context, mark, model string, volume, year int) (ValuationHistory, error) {
+	mark, model = NormalizeName(mark), NormalizeName(model)
+	if mark == "" || model == "" || year <= 0 || volume < 0 {
+		return ValuationHistory{}, fmt.Errorf("%w: mark, model, volume and year are required", ErrInvalidArgument)
+	}
+
+	valuationsData, err := u.repo.GetValuationHistory(ctx, mark, model, volume, year)
+	if err != nil {
+		return ValuationHistory{}, err
+	}
+	if len(valuationsData) == 0 {
+		return ValuationHistory{}, fmt.Errorf("%w: %s %s %d %d", ErrNotFound, mark, model, volume, year)
+	}
+
+	history := ValuationHistory{
+		Mark:    mark,
+		Model:   model,
+		Volume:  volume,
+		Year:    year,
+		Trend:   TrendFlat,
+		History: make([]Valuation, 0, len(valuationsData)),
+	}
+	for i, v := range valuationsData {
+		valuation := Valuation{
+			ImportID:   v.ImportID,
+			ImportedAt: v.ImportedAt,
+			Amount:     v.Amount,
+		}
+		if i > 0 {
+			previous := valuationsData[i-1].Amount
+			valuation.Change = v.Amount - previous
+			if previous != 0 {
+				valuation.ChangePercent = math.Round(float64(valuation.Change)/float64(previous)*10000) / 100
+			}
+		}
+		history.History = append(history.History, valuation)
+	}
+
+	switch last := history.History[len(history.History)-1]; {
+	case last.Change > 0:
+		history.Trend = TrendUp
+	case last.Change < 0:
+		history.Trend = TrendDown
+	}
+	return history, nil
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestGetValuationHistory(t *testing.T) {
+	store := newFakeStore()
+	store.valuations = map[string][]repository.Valuation{
+		"TOYOTA CAMRY 2500 2020": {
+			{ImportID: 1, ImportedAt: "2024-01-10 09:00:00", Amount: 20000},
+			{ImportID: 3, ImportedAt: "2024-07-10 09:00:00", Amount: 25000},
+			{ImportID: 4, ImportedAt: "2025-01-10 09:00:00", Amount: 24000},
+		},
+		"TOYOTA CAMRY 3500 2020": {
+			{ImportID: 4, ImportedAt: "2025-01-10 09:00:00", Amount: 30000},
+		},
+	}
+	uc := NewUseCase(store, nil, nil, nil)
+	ctx := context.Background()
+
+	history, err := uc.GetValuationHistory(ctx, " toyota ", "Camry", 2500, 2020)
+	if err != nil {
+		t.Fatalf("GetValuationHistory: %v", err)
+	}
+	want := []Valuation{
+		{ImportID: 1, ImportedAt: "2024-01-10 09:00:00", Amount: 20000},
+		{ImportID: 3, ImportedAt: "2024-07-10 09:00:00", Amount: 25000, Change: 5000, ChangePercent: 25},
+		{ImportID: 4, ImportedAt: "2025-01-10 09:00:00", Amount: 24000, Change: -1000, ChangePercent: -4},
+	}
+	if history.Mark != "TOYOTA" || history.Model != "CAMRY" || history.Trend != TrendDown || !reflect.DeepEqual(history.History, want) {
+		t.Errorf("GetValuationHistory = %+v", history)
+	}
+
+	// одна загрузка - сравнивать не с чем
+	history, err = uc.GetValuationHistory(ctx, "TOYOTA", "CAMRY", 3500, 2020)
+	if err != nil || history.Trend != TrendFlat || len(history.History) != 1 {
+		t.Errorf("single import history = %+v, %v", history, err)
+	}
+
+	if _, err := uc.GetValuationHistory(ctx, "TOYOTA", "CAMRY", 2500, 1999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown vehicle = %v, want ErrNotFound", err)
+	}
+	if _, err := uc.GetValuationHistory(ctx, "TOYOTA", "", 2500, 2020); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("empty model = %v, want ErrInvalidArgument", err)
+	}
+}
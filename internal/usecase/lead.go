@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const (
+	LeadStatusNew       = "new"
+	LeadStatusContacted = "contacted"
+	LeadStatusClosed    = "closed"
+
+	defaultLeadsLimit = 20
+	maxLeadsLimit     = 100
+
+	// notifyLeadTimeout ограничивает пересылку заявки, которая идет уже после
+	// ответа клиенту
+	notifyLeadTimeout = 30 * time.Second
+)
+
+// leadTransitions - допустимые переходы статуса заявки, closed - конечный.
+var leadTransitions = map[string][]string{
+	LeadStatusNew:       {LeadStatusContacted, LeadStatusClosed},
+	LeadStatusContacted: {LeadStatusClosed},
+}
+
+// Notifier доставляет менеджерам уведомления о новых заявках.
+type Notifier interface {
+	NotifyLead(ctx context.Context, lead Lead) error
+}
+
+type Lead struct {
+	ID           int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	Phone        string
+	TelegramUser string
+	Mark         string
+	Model        string
+	Volume       int
+	Year         int
+	Assessment   json.RawMessage
+	Status       string
+}
+
+type LeadPage struct {
+	Items []Lead
+	Total int
+	Page  int
+	Limit int
+}
+
+// CreateLead сохраняет заявку и пересылает ее менеджерам в фоне, чтобы
+// медленный Telegram не задерживал ответ. Ошибка пересылки не теряет заявку:
+// она уже в базе и видна в админке.
+func (u UseCase) CreateLead(ctx context.Context, l Lead) (Lead, error) {
+	l.Name = strings.TrimSpace(l.Name)
+	l.Phone = strings.TrimSpace(l.Phone)
+	l.TelegramUser = strings.TrimPrefix(strings.TrimSpace(l.TelegramUser), "@")
+	l.Mark = strings.ToUpper(strings.TrimSpace(l.Mark))
+	l.Model = strings.ToUpper(strings.TrimSpace(l.Model))
+
+	if l.Name == "" {
+		return l, fmt.Errorf("%w: name is required", ErrInvalidArgument)
+	}
+	if countDigits(l.Phone) < 10 {
+		return l, fmt.Errorf("%w: phone must contain at least 10 digits", ErrInvalidArgument)
+	}
+	if len(l.Assessment) > 0 && !json.Valid(l.Assessment) {
+		return l, fmt.Errorf("%w: assessment must be valid JSON", ErrInvalidArgument)
+	}
+
+	id, err := u.repo.CreateLead(ctx, repository.Lead{
+		Name:         l.Name,
+		Phone:        l.Phone,
+		TelegramUser: l.TelegramUser,
+		Mark:         l.Mark,
+		Model:        l.Model,
+		Volume:       l.Volume,
+		Year:         l.Year,
+		Assessment:   string(l.Assessment),
+	})
+	if err != nil {
+		return l, err
+	}
+
+	lead, err := u.getLead(ctx, id)
+	if err != nil {
+		return l, err
+	}
+
+	if u.notifier != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyLeadTimeout)
+			defer cancel()
+			if err := u.notifier.NotifyLead(ctx, lead); err != nil {
+				slog.Error("NotifyLead", slog.Int64("lead", lead.ID), slog.String("err", err.Error()))
+			}
+		}()
+	}
+	return lead, nil
+}
+
+// GetLeads возвращает страницу заявок; page начинается с 1.
+func (u UseCase) GetLeads(ctx context.Context, status string, page, limit int) (LeadPage, error) {
+	if status != "" && !isLeadStatus(status) {
+		return LeadPage{}, fmt.Errorf("%w: unknown status %q", ErrInvalidArgument, status)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultLeadsLimit
+	}
+	if limit > maxLeadsLimit {
+		limit = maxLeadsLimit
+	}
+
+	total, err := u.repo.CountLeads(ctx, status)
+	if err != nil {
+		return LeadPage{}, err
+	}
+
+	leadsData, err := u.repo.GetLeads(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return LeadPage{}, err
+	}
+
+	leads := make([]Lead, 0, len(leadsData))
+	for _, l := range leadsData {
+		leads = append(leads, leadFromData(l))
+	}
+	return LeadPage{
+		Items: leads,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// ChangeLeadStatus переводит заявку по цепочке new -> contacted -> closed.
+func (u UseCase) ChangeLeadStatus(ctx context.Context, id int64, status string) (Lead, error) {
+	if !isLeadStatus(status) {
+		return Lead{}, fmt.Errorf("%w: unknown status %q", ErrInvalidArgument, status)
+	}
+
+	lead, err := u.getLead(ctx, id)
+	if err != nil {
+		return lead, err
+	}
+	if lead.Status == status {
+		return lead, nil
+	}
+
+	allowed := false
+	for _, next := range leadTransitions[lead.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return lead, fmt.Errorf("%w: cannot change status from %s to %s", ErrInvalidArgument, lead.Status, status)
+	}
+
+	if err := u.repo.UpdateLeadStatus(ctx, id, status); err != nil {
+		return lead, err
+	}
+	return u.getLead(ctx, id)
+}
+
+func (u UseCase) getLead(ctx context.Context, id int64) (Lead, error) {
+	l, ok, err := u.repo.GetLead(ctx, id)
+	if err != nil {
+		return Lead{}, err
+	}
+	if !ok {
+		return Lead{}, fmt.Errorf("%w: lead %d", ErrNotFound, id)
+	}
+	return leadFromData(l), nil
+}
+
+func leadFromData(l repository.Lead) Lead {
+	lead := Lead{
+		ID:           l.ID,
+		CreatedAt:    time.Unix(l.CreatedAt, 0),
+		UpdatedAt:    time.Unix(l.UpdatedAt, 0),
+		Name:         l.Name,
+		Phone:        l.Phone,
+		TelegramUser: l.TelegramUser,
+		Mark:         l.Mark,
+		Model:        l.Model,
+		Volume:       l.Volume,
+		Year:         l.Year,
+		Status:       l.Status,
+	}
+	if l.Assessment != "" {
+		lead.Assessment = json.RawMessage(l.Assessment)
+	}
+	return lead
+}
+
+func isLeadStatus(status string) bool {
+	return status == LeadStatusNew || status == LeadStatusContacted || status == LeadStatusClosed
+}
+
+func countDigits(s string) int {
+	count := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+type ModelAlias struct {
+	Mark      string
+	Alias     string
+	Model     string
+	CreatedAt time.Time
+}
+
+// ModelMerge - итог слияния: сколько строк КГД получили каноническую модель.
+type ModelMerge struct {
+	Mark    string
+	Model   string
+	Aliases []string
+	Rows    int64
+}
+
+// NormalizeName приводит марку или модель к виду, в котором они хранятся:
+// верхний регистр, без лишних пробелов по краям и внутри.
+func NormalizeName(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
+
+// GetModelAliases возвращает синонимы моделей марки; пустая марка - все синонимы.
+func (u UseCase) GetModelAliases(ctx context.Context, mark string) ([]ModelAlias, error) {
+	aliasesData, err := u.repo.GetModelAliases(ctx, NormalizeName(mark))
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]ModelAlias, 0, len(aliasesData))
+	for _, a := range aliasesData {
+		aliases = append(aliases, modelAliasFromData(a))
+	}
+	return aliases, nil
+}
+
+// MergeModels сводит написания aliases к канонической модели model. Повторное
+// слияние того же написания перенаправляет его на новую модель.
+func (u UseCase) MergeModels(ctx context.Context, mark, model string, aliases []string) (ModelMerge, error) {
+	merge := ModelMerge{
+		Mark:    NormalizeName(mark),
+		Model:   NormalizeName(model),
+		Aliases: make([]string, 0, len(aliases)),
+	}
+	if merge.Mark == "" || merge.Model == "" {
+		return merge, fmt.Errorf("%w: mark and model are required", ErrInvalidArgument)
+	}
+
+	seen := make(map[string]bool)
+	for _, alias := range aliases {
+		alias = NormalizeName(alias)
+		if alias == "" || alias == merge.Model || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		merge.Aliases = append(merge.Aliases, alias)
+	}
+	if len(merge.Aliases) == 0 {
+		return merge, fmt.Errorf("%w: at least one alias different from the model is required", ErrInvalidArgument)
+	}
+
+	rows, err := u.repo.MergeModels(ctx, merge.Mark, merge.Model, merge.Aliases)
+	if err != nil {
+		return merge, err
+	}
+	merge.Rows = rows
+
+	u.refreshSearchIndex(ctx)
+	u.refreshCatalog(ctx)
+	return merge, nil
+}
+
+// DeleteModelAlias отменяет слияние: строки КГД с этим написанием снова
+// показываются отдельной моделью.
+func (u UseCase) DeleteModelAlias(ctx context.Context, mark, alias string) error {
+	mark, alias = NormalizeName(mark), NormalizeName(alias)
+	deleted, err := u.repo.DeleteModelAlias(ctx, mark, alias)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: model alias %s %s", ErrNotFound, mark, alias)
+	}
+
+	u.refreshSearchIndex(ctx)
+	u.refreshCatalog(ctx)
+	return nil
+}
+
+// refreshSearchIndex пересобирает индекс поиска после переименования моделей.
+// Ошибка не откатывает слияние: индекс соберется заново при следующем старте.
+func (u UseCase) refreshSearchIndex(ctx context.Context) {
+	if err := u.RebuildSearchIndex(ctx); err != nil {
+		slog.Error("RebuildSearchIndex", slog.String("err", err.Error()))
+	}
+}
+
+func modelAliasFromData(a repository.ModelAlias) ModelAlias {
+	return ModelAlias{
+		Mark:      a.Mark,
+		Alias:     a.Alias,
+		Model:     a.Model,
+		CreatedAt: time.Unix(a.CreatedAt, 0),
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// Popularity - вес марки или модели в списках. Пустая модель - вес марки.
+// Списки сортируются по Weight, при равном весе - по Requests.
+type Popularity struct {
+	Mark      string
+	Model     string
+	Weight    int
+	Requests  int
+	UpdatedAt time.Time
+}
+
+func (u UseCase) GetPopularity(ctx context.Context, mark string) ([]Popularity, error) {
+	popularityData, err := u.repo.GetPopularity(ctx, NormalizeName(mark))
+	if err != nil {
+		return nil, err
+	}
+
+	popularity := make([]Popularity, 0, len(popularityData))
+	for _, p := range popularityData {
+		popularity = append(popularity, popularityFromData(p))
+	}
+	return popularity, nil
+}
+
+// SetPopularity задает ручной вес марки или модели; порядок списков меняется
+// сразу, без повторного импорта.
+func (u UseCase) SetPopularity(ctx context.Context, mark, model string, weight int) (Popularity, error) {
+	p := Popularity{
+		Mark:   NormalizeName(mark),
+		Model:  NormalizeName(model),
+		Weight: weight,
+	}
+	if p.Mark == "" {
+		return p, fmt.Errorf("%w: mark is required", ErrInvalidArgument)
+	}
+	if p.Weight < 0 {
+		return p, fmt.Errorf("%w: weight must not be negative", ErrInvalidArgument)
+	}
+
+	if err := u.repo.SetPopularity(ctx, p.Mark, p.Model, p.Weight); err != nil {
+		return p, err
+	}
+	u.refreshCatalog(ctx)
+
+	all, err := u.GetPopularity(ctx, p.Mark)
+	if err != nil {
+		return p, err
+	}
+	for _, saved := range all {
+		if saved.Model == p.Model {
+			return saved, nil
+		}
+	}
+	return p, nil
+}
+
+func (u UseCase) DeletePopularity(ctx context.Context, mark, model string) error {
+	mark, model = NormalizeName(mark), NormalizeName(model)
+	deleted, err := u.repo.DeletePopularity(ctx, mark, model)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: popularity %s %s", ErrNotFound, mark, model)
+	}
+	u.refreshCatalog(ctx)
+	return nil
+}
+
+// RecalculatePopularity обновляет счетчики расчетов по маркам и моделям.
+// Ручные веса не меняются.
+func (u UseCase) RecalculatePopularity(ctx context.Context) (int64, error) {
+	updated, err := u.repo.RecalculatePopularity(ctx)
+	if err != nil {
+		return updated, err
+	}
+	u.refreshCatalog(ctx)
+	return updated, nil
+}
+
+func popularityFromData(p repository.Popularity) Popularity {
+	return Popularity{
+		Mark:      p.Mark,
+		Model:     p.Model,
+		Weight:    p.Weight,
+		Requests:  p.Requests,
+		UpdatedAt: time.Unix(p.UpdatedAt, 0),
+	}
+}
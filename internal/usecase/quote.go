@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const quoteLimit = 10
+
+var (
+	yearRe          = regexp.MustCompile(`^\d{4}$`)
+	volumeLitersRe  = regexp.MustCompile(`^\d[.,]\d$`)
+	volumeCentiRe   = regexp.MustCompile(`^\d{3,4}$`)
+	quoteSeparators = regexp.MustCompile(`[\s/]+`)
+)
+
+// Quote - быстрая оценка авто по строке КГД без доставки и брокера.
+// Amount - оценка КГД в долларах, TurnkeyAmount - цена под ключ в тенге по курсу Rate.
+type Quote struct {
+	Mark          string
+	Model         string
+	Volume        int
+	Year          int
+	VehicleClass  string
+	EngineType    string
+	Amount        int
+	Rate          float64
+	TurnkeyAmount int
+}
+
+// SearchQuotes ищет авто по свободному запросу вида "ленд крузер 2015 4.6"
+// так же, как Search, и возвращает до 10 ближайших строк КГД с оценкой
+// стоимости под ключ в тенге по текущему курсу.
+func (u UseCase) SearchQuotes(ctx context.Context, query string) ([]Quote, error) {
+	quotes := make([]Quote, 0)
+	candidates, err := u.Search(ctx, query, quoteLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return quotes, nil
+	}
+
+	currency, err := u.rates.GetCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rate := currency.Rates.KZT
+
+	for _, c := range candidates {
+		v := vehicle{Class: c.VehicleClass, EngineType: c.EngineType, Volume: c.Volume, Year: c.Year}
+		quotes = append(quotes, Quote{
+			Mark:          c.Mark,
+			Model:         c.Model,
+			Volume:        c.Volume,
+			Year:          c.Year,
+			Amount:        c.Amount,
+			Rate:          rate,
+			VehicleClass:  v.Class,
+			EngineType:    v.EngineType,
+			TurnkeyAmount: u.calcTurnkey(int(rate*float64(c.Amount)), v),
+		})
+	}
+	return quotes, nil
+}
+
+// carQuote считает цену под ключ для конкретного авто из списка КГД. Если
+// указана цена авто в долларах, база считается от нее, иначе от оценки КГД.
+// При year = 0 берется самый свежий год.
+func (u UseCase) carQuote(ctx context.Context, mark, model string, volume, year, priceUSD int, rate float64) (Quote, error) {
+	specifications, err := u.GetSpecifications(ctx, mark, model, volume)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	for _, spec := range specifications {
+		if year != 0 && spec.Year != year {
+			continue
+		}
+
+		amountUSD := spec.Amount
+		if priceUSD > 0 {
+			amountUSD = priceUSD
+		}
+		return Quote{
+			Mark:          mark,
+			Model:         model,
+			Volume:        volume,
+			Year:          spec.Year,
+			Amount:        spec.Amount,
+			Rate:          rate,
+			VehicleClass:  spec.VehicleClass,
+			EngineType:    spec.EngineType,
+			TurnkeyAmount: u.calcTurnkey(int(rate*float64(amountUSD)), spec.vehicle(volume)),
+		}, nil
+	}
+	return Quote{}, fmt.Errorf("%w: %s %s %d %d", ErrNotFound, mark, model, volume, year)
+}
+
+// calcTurnkey считает растаможку и регистрацию поверх стоимости авто в тенге.
+func (u UseCase) calcTurnkey(amountKZT int, v vehicle) int {
+	return u.turnkeyCost(amountKZT, v).TurnkeyAmount
+}
+
+// turnkeyCost раскладывает цену под ключ на платежи. Год и оценку КГД
+// заполняет вызывающий.
+func (u UseCase) turnkeyCost(amountKZT int, v vehicle) YearCost {
+	rules := u.rules(v.Class)
+	firstRegistration, util := rules.fees(v)
+	cost := YearCost{
+		VehicleClass:            v.Class,
+		EngineType:              v.EngineType,
+		AmountKZT:               amountKZT,
+		CustomsDutyAmount:       (amountKZT * rules.DutyPercent(v.EngineType)) / 100,
+		CustomsCollectionAmount: rules.MRP * rules.CustomsCollectionMRP,
+		FirstRegistrationAmount: firstRegistration,
+		UtilAmount:              util,
+	}
+	cost.VATAmount = ((amountKZT + cost.CustomsDutyAmount + cost.CustomsCollectionAmount) * rules.VATPercent) / 100
+	cost.TurnkeyAmount = amountKZT +
+		cost.CustomsDutyAmount +
+		cost.CustomsCollectionAmount +
+		cost.VATAmount +
+		cost.FirstRegistrationAmount +
+		cost.UtilAmount
+	return cost
+}
+
+func parseQuoteQuery(query string) repository.DataFilter {
+	filter := repository.DataFilter{}
+	currentYear := time.Now().Year()
+
+	for _, token := range quoteSeparators.Split(strings.TrimSpace(query), -1) {
+		if token == "" {
+			continue
+		}
+
+		switch {
+		case yearRe.MatchString(token):
+			value, _ := strconv.Atoi(token)
+			if value >= 1950 && value <= currentYear+1 {
+				filter.Year = value
+				continue
+			}
+			filter.Volume = value
+		case volumeLitersRe.MatchString(token):
+			liters, _ := strconv.ParseFloat(strings.Replace(token, ",", ".", 1), 64)
+			filter.Volume = int(liters * 1000)
+		case volumeCentiRe.MatchString(token):
+			filter.Volume, _ = strconv.Atoi(token)
+		default:
+			filter.Terms = append(filter.Terms, strings.ToUpper(token))
+		}
+	}
+	return filter
+}
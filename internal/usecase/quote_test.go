@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestSearchQuotes(t *testing.T) {
+	year := time.Now().Year() - 5
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "LAND CRUISER 200", "LAND CRUISER 200", 4600, year, 40000),
+		catalogRow("TOYOTA", "LAND CRUISER PRADO", "LAND CRUISER PRADO", 2700, year, 30000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, year, 20000),
+	}
+	store.searchAliases = map[string]string{"LC200": "TOYOTA LAND CRUISER 200"}
+	rates := newFakeRates()
+	u := newTestUseCase(store, rates)
+	ctx := context.Background()
+
+	// кириллица, опечатка и синоним находят одну и ту же модель
+	for _, query := range []string{"ленд крузер 4.6", "land cruser 4.6", "LC200"} {
+		quotes, err := u.SearchQuotes(ctx, query)
+		if err != nil {
+			t.Fatalf("SearchQuotes(%q): %v", query, err)
+		}
+		if len(quotes) == 0 || quotes[0].Model != "LAND CRUISER 200" {
+			t.Errorf("SearchQuotes(%q) = %+v, want LAND CRUISER 200 first", query, quotes)
+			continue
+		}
+		q := quotes[0]
+		want := u.calcTurnkey(40000*500, vehicle{Class: ClassM1, EngineType: EngineICE, Volume: 4600, Year: year})
+		if q.Amount != 40000 || q.Rate != 500 || q.TurnkeyAmount != want {
+			t.Errorf("SearchQuotes(%q)[0] = %+v, want turnkey %d at rate 500", query, q, want)
+		}
+	}
+
+	calls := rates.calls
+	quotes, err := u.SearchQuotes(ctx, "lamborghini")
+	if err != nil || len(quotes) != 0 {
+		t.Errorf("SearchQuotes(lamborghini) = %+v, %v, want nothing", quotes, err)
+	}
+	if rates.calls != calls {
+		t.Errorf("rates requested without results")
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Assessment - неизменяемый снимок расчета: входные данные, курсы, ставки и
+// результат хранятся JSON-ом, чтобы ссылка показывала те же цифры и позже.
+type Assessment struct {
+	ID        string `db:"id"`
+	CreatedAt int64  `db:"created_at"`
+	Input     string `db:"input"`
+	Rates     string `db:"rates"`
+	Rules     string `db:"rules"`
+	Result    string `db:"result"`
+}
+
+// CreateAssessment сохраняет снимок и возвращает false, если такой id уже занят.
+func (r Repo) CreateAssessment(ctx context.Context, a Assessment) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.createAssessmentStmt.ExecContext(ctx, a.ID, a.Input, a.Rates, a.Rules, a.Result)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r Repo) GetAssessment(ctx context.Context, id string) (Assessment, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	a := Assessment{}
+	err := r.getAssessmentStmt.QueryRowContext(ctx, id).Scan(&a.ID, &a.CreatedAt, &a.Input, &a.Rates, &a.Rules, &a.Result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, false, nil
+	}
+	if err != nil {
+		return a, false, err
+	}
+	return a, true, nil
+}
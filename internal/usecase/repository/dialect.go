@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect - диалект SQL хранилища. Значение совпадает с именем драйвера
+// database/sql и golang-migrate, а также с каталогом миграций.
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite3"
+	DialectPostgres Dialect = "postgres"
+)
+
+// ParseDSN определяет диалект по DSN: postgres:// и postgresql:// - PostgreSQL,
+// все остальное - путь к файлу SQLite, в том числе с префиксом sqlite3://.
+// Возвращает диалект и строку подключения для sql.Open.
+func ParseDSN(dsn string) (Dialect, string, error) {
+	switch {
+	case dsn == "":
+		return "", "", fmt.Errorf("empty database dsn")
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return DialectPostgres, dsn, nil
+	case strings.HasPrefix(dsn, "sqlite3://"):
+		return DialectSQLite, strings.TrimPrefix(dsn, "sqlite3://"), nil
+	case strings.Contains(dsn, "://"):
+		return "", "", fmt.Errorf("unsupported database dsn scheme: %s", dsn[:strings.Index(dsn, "://")])
+	}
+	return DialectSQLite, dsn, nil
+}
+
+// Rebind переводит плейсхолдеры ? в $1, $2... для PostgreSQL. Знаки вопроса
+// внутри строковых литералов в одинарных кавычках не трогаются.
+func (d Dialect) Rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n, quoted := 0, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == '?' && !quoted:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// unixNow - текущее время в секундах Unix, как в DEFAULT колонок created_at.
+func (d Dialect) unixNow() string {
+	if d == DialectPostgres {
+		return "EXTRACT(EPOCH FROM now())::BIGINT"
+	}
+	return "strftime('%s', 'now')"
+}
+
+// jsonText достает строковое поле верхнего уровня из JSON в текстовой колонке.
+func (d Dialect) jsonText(column, field string) string {
+	if d == DialectPostgres {
+		return "(" + column + "::jsonb ->> '" + field + "')"
+	}
+	return "json_extract(" + column + ", '$." + field + "')"
+}
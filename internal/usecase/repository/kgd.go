@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Статусы загрузки файла КГД.
+const (
+	KGDImportDone   = "imported"
+	KGDImportFailed = "failed"
+	// KGDImportRolledBack - загрузка отменена командой kgd rollback
+	KGDImportRolledBack = "rolled_back"
+)
+
+// dataColumns - колонки справочника, общие для data, data_staging и data_previous.
+const dataColumns = "id, mark, model, variant, volume, year, amount, engine_type, battery_capacity, power, vehicle_class"
+
+// applyModelAliases сводит написания из файла к каноническим моделям по model_alias.
+const applyModelAliases = `UPDATE data SET model = (SELECT a.model FROM model_alias a WHERE a.mark = data.mark AND a.alias = data.variant)
+	WHERE EXISTS (SELECT 1 FROM model_alias a WHERE a.mark = data.mark AND a.alias = data.variant);`
+
+// ErrNoPreviousData - откатываться некуда: прежний справочник не сохранен.
+var ErrNoPreviousData = errors.New("no previous KGD data to roll back to")
+
+// KGDImport - запись о загрузке файла КГД. ETag, LastModified и SHA256 файла
+// нужны, чтобы при следующей проверке понять, изменился ли он.
+type KGDImport struct {
+	ID           int64  `db:"id"`
+	URL          string `db:"kgd_url"`
+	CreatedAt    string `db:"created_at"`
+	ETag         string `db:"etag"`
+	LastModified string `db:"last_modified"`
+	SHA256       string `db:"sha256"`
+	Status       string `db:"status"`
+	Error        string `db:"error"`
+	Rows         int    `db:"row_count"`
+}
+
+// GetLastImportID возвращает номер последней удачной загрузки файла КГД, 0 - загрузок не было.
+func (r Repo) GetLastImportID(ctx context.Context) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.getLastImportIDStmt.QueryRowContext(ctx).Scan(&id)
+	return id, err
+}
+
+// GetLastKGDImport возвращает последнюю загрузку со статусом status.
+func (r Repo) GetLastKGDImport(ctx context.Context, status string) (KGDImport, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	i := KGDImport{}
+	err := r.getLastKGDImportStmt.QueryRowContext(ctx, status).Scan(
+		&i.ID, &i.URL, &i.CreatedAt, &i.ETag, &i.LastModified, &i.SHA256, &i.Status, &i.Error, &i.Rows,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return i, false, nil
+	}
+	if err != nil {
+		return i, false, err
+	}
+	return i, true, nil
+}
+
+// CreateKGDImport записывает итог загрузки, удачной или нет.
+func (r Repo) CreateKGDImport(ctx context.Context, i KGDImport) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createKGDImportStmt.QueryRowContext(ctx, i.URL, i.ETag, i.LastModified, i.SHA256, i.Status, i.Error, i.Rows).Scan(&id)
+	return id, err
+}
+
+// KGDRow - строка файла КГД: Variant - написание из файла, Model - каноническая
+// модель. До замены справочника Model совпадает с Variant, синонимы
+// применяются в PromoteStaging. BatteryCapacity и Power нулевые, если КГД их
+// не указал.
+type KGDRow struct {
+	ID              int     `db:"id"`
+	Mark            string  `db:"mark"`
+	Model           string  `db:"model"`
+	Variant         string  `db:"variant"`
+	Volume          int     `db:"volume"`
+	Year            int     `db:"year"`
+	Amount          int     `db:"amount"`
+	EngineType      string  `db:"engine_type"`
+	BatteryCapacity float64 `db:"battery_capacity"`
+	Power           int     `db:"power"`
+	VehicleClass    string  `db:"vehicle_class"`
+}
+
+// StagingStats - сводка по data_staging для проверки перед заменой справочника.
+// Matched, OldAmount и NewAmount считаются по строкам, которые есть и в data
+// с той же маркой, написанием, объемом и годом.
+type StagingStats struct {
+	Rows      int
+	Marks     map[string]bool
+	Matched   int
+	OldAmount int64
+	NewAmount int64
+}
+
+// LoadStaging заменяет содержимое data_staging строками rows. Справочник data
+// не меняется.
+func (r Repo) LoadStaging(ctx context.Context, rows []KGDRow) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM data_staging;"); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, r.dialect.Rebind("INSERT INTO data_staging ("+dataColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row.ID, row.Mark, row.Model, row.Variant, row.Volume, row.Year, row.Amount, row.EngineType, row.BatteryCapacity, row.Power, row.VehicleClass); err != nil {
+			return fmt.Errorf("row %d: %v", row.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetStagingStats сравнивает data_staging с текущим справочником.
+func (r Repo) GetStagingStats(ctx context.Context) (StagingStats, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	stats := StagingStats{Marks: make(map[string]bool)}
+	rows, err := r.getStagingMarksStmt.QueryContext(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mark string
+		var count int
+		if err := rows.Scan(&mark, &count); err != nil {
+			return stats, err
+		}
+		stats.Marks[mark] = true
+		stats.Rows += count
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	err = r.getStagingChangeStmt.QueryRowContext(ctx).Scan(&stats.Matched, &stats.OldAmount, &stats.NewAmount)
+	return stats, err
+}
+
+// CountPreviousData возвращает число строк прежнего справочника, 0 - откатываться некуда.
+func (r Repo) CountPreviousData(ctx context.Context) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := r.countPreviousDataStmt.QueryRowContext(ctx).Scan(&count)
+	return count, err
+}
+
+// PromoteStaging в одной транзакции переносит data в data_previous,
+// data_staging - в data, сводит написания к моделям по синонимам, записывает
+// загрузку i и сохраняет ее строки в data_history. Пока транзакция идет,
+// читатели видят прежний справочник. Возвращает номер загрузки.
+func (r Repo) PromoteStaging(ctx context.Context, i KGDImport) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, r.dialect.Rebind("INSERT INTO kgd_data_migration (kgd_url, etag, last_modified, sha256, status, error, row_count) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id;"),
+		i.URL, i.ETag, i.LastModified, i.SHA256, KGDImportDone, "", i.Rows).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM data_previous;",
+		"INSERT INTO data_previous (" + dataColumns + ") SELECT " + dataColumns + " FROM data;",
+		"DELETE FROM data;",
+		"INSERT INTO data (" + dataColumns + ") SELECT " + dataColumns + " FROM data_staging;",
+		"DELETE FROM data_staging;",
+		applyModelAliases,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind("INSERT INTO data_history (import_id, "+dataColumns+") SELECT ?, "+dataColumns+" FROM data;"), id)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// RollbackData возвращает справочник из data_previous и помечает последнюю
+// загрузку отмененной; ее файл больше не загружается автоматически, а ее
+// строки остаются в data_history, но не попадают в историю стоимости. Синонимы
+// моделей применяются заново, чтобы не потерять слияния после той загрузки.
+// Повторный откат вернет ErrNoPreviousData: прежняя версия хранится одна.
+func (r Repo) RollbackData(ctx context.Context) (KGDImport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return KGDImport{}, err
+	}
+	defer tx.Rollback()
+
+	var previous int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM data_previous;").Scan(&previous); err != nil {
+		return KGDImport{}, err
+	}
+	if previous == 0 {
+		return KGDImport{}, ErrNoPreviousData
+	}
+
+	last := KGDImport{}
+	err = tx.QueryRowContext(ctx, r.dialect.Rebind(`SELECT id, kgd_url, created_at, etag, last_modified, sha256, status, error, row_count FROM kgd_data_migration
+		WHERE status = ? ORDER BY id DESC LIMIT 1;`), KGDImportDone).Scan(
+		&last.ID, &last.URL, &last.CreatedAt, &last.ETag, &last.LastModified, &last.SHA256, &last.Status, &last.Error, &last.Rows,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return KGDImport{}, ErrNoPreviousData
+	}
+	if err != nil {
+		return KGDImport{}, err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM data;",
+		"INSERT INTO data (" + dataColumns + ") SELECT " + dataColumns + " FROM data_previous;",
+		"DELETE FROM data_previous;",
+		applyModelAliases,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return KGDImport{}, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind("UPDATE kgd_data_migration SET status = ? WHERE id = ?;"), KGDImportRolledBack, last.ID); err != nil {
+		return KGDImport{}, err
+	}
+	last.Status = KGDImportRolledBack
+	return last, tx.Commit()
+}
+
+// Valuation - стоимость комплектации в одной загрузке КГД.
+type Valuation struct {
+	ImportID   int64  `db:"import_id"`
+	ImportedAt string `db:"created_at"`
+	Amount     int    `db:"amount"`
+}
+
+// GetValuationHistory возвращает стоимость комплектации по всем действующим
+// загрузкам, от ранней к поздней. Если у модели несколько написаний с разной
+// стоимостью, берется наибольшая.
+func (r Repo) GetValuationHistory(ctx context.Context, mark, model string, volume, year int) ([]Valuation, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	valuations := make([]Valuation, 0)
+	rows, err := r.getValuationHistoryStmt.QueryContext(ctx, mark, model, volume, year)
+	if err != nil {
+		return valuations, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := Valuation{}
+		if err := rows.Scan(&v.ImportID, &v.ImportedAt, &v.Amount); err != nil {
+			return valuations, err
+		}
+		valuations = append(valuations, v)
+	}
+	return valuations, rows.Err()
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKGDImport(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		if _, ok, err := repo.GetLastKGDImport(ctx, KGDImportDone); err != nil || ok {
+			t.Fatalf("GetLastKGDImport on an empty table = %v, %v", ok, err)
+		}
+
+		done, err := repo.CreateKGDImport(ctx, KGDImport{URL: "https://kgd.gov.kz/a.xlsx", ETag: `"a"`, SHA256: "aa", Status: KGDImportDone, Rows: 10})
+		if err != nil {
+			t.Fatalf("CreateKGDImport: %v", err)
+		}
+		if _, err := repo.CreateKGDImport(ctx, KGDImport{URL: "https://kgd.gov.kz/b.xlsx", SHA256: "bb", Status: KGDImportFailed, Error: "broken"}); err != nil {
+			t.Fatalf("CreateKGDImport: %v", err)
+		}
+
+		last, ok, err := repo.GetLastKGDImport(ctx, KGDImportDone)
+		if err != nil || !ok {
+			t.Fatalf("GetLastKGDImport = %v, %v", ok, err)
+		}
+		if last.ID != done || last.ETag != `"a"` || last.Rows != 10 || last.CreatedAt == "" {
+			t.Errorf("GetLastKGDImport = %+v", last)
+		}
+		failed, ok, err := repo.GetLastKGDImport(ctx, KGDImportFailed)
+		if err != nil || !ok || failed.Error != "broken" {
+			t.Errorf("GetLastKGDImport failed = %+v, %v, %v", failed, ok, err)
+		}
+
+		// номер версии справочника дают только удачные загрузки
+		id, err := repo.GetLastImportID(ctx)
+		if err != nil || id != done {
+			t.Errorf("GetLastImportID = %d, %v, want %d", id, err, done)
+		}
+	})
+}
+
+func TestStaging(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		load := func(toyota int) StagingStats {
+			t.Helper()
+			err := repo.LoadStaging(ctx, []KGDRow{
+				{ID: 1, Mark: "TOYOTA", Model: "LC PRADO", Variant: "LC PRADO", Volume: 2700, Year: 2020, Amount: toyota},
+				{ID: 2, Mark: "HYUNDAI", Model: "SONATA", Variant: "SONATA", Volume: 2000, Year: 2020, Amount: 200},
+			})
+			if err != nil {
+				t.Fatalf("LoadStaging: %v", err)
+			}
+			stats, err := repo.GetStagingStats(ctx)
+			if err != nil {
+				t.Fatalf("GetStagingStats: %v", err)
+			}
+			return stats
+		}
+		amounts := func() map[string]int {
+			t.Helper()
+			rows, err := repo.GetDataRows(ctx, "", "", 0, 0)
+			if err != nil {
+				t.Fatalf("GetDataRows: %v", err)
+			}
+			amounts := make(map[string]int)
+			for _, row := range rows {
+				amounts[row.Model] = row.Amount
+			}
+			return amounts
+		}
+
+		stats := load(100)
+		if stats.Rows != 2 || !stats.Marks["TOYOTA"] || !stats.Marks["HYUNDAI"] || stats.Matched != 0 {
+			t.Errorf("first staging stats = %+v", stats)
+		}
+		first, err := repo.PromoteStaging(ctx, KGDImport{URL: "a.xlsx", SHA256: "aa", Rows: 2})
+		if err != nil {
+			t.Fatalf("PromoteStaging: %v", err)
+		}
+		// написание из файла сведено к модели по синонимам из миграции
+		if got := amounts(); got["LAND CRUISER PRADO"] != 100 || got["SONATA"] != 200 {
+			t.Errorf("data after first promote = %v", got)
+		}
+		if _, err := repo.RollbackData(ctx); !errors.Is(err, ErrNoPreviousData) {
+			t.Errorf("RollbackData without previous data = %v, want ErrNoPreviousData", err)
+		}
+
+		stats = load(150)
+		if stats.Matched != 2 || stats.OldAmount != 300 || stats.NewAmount != 350 {
+			t.Errorf("second staging stats = %+v", stats)
+		}
+		// пока staging не перенесен, справочник прежний
+		if got := amounts(); got["LAND CRUISER PRADO"] != 100 {
+			t.Errorf("data changed before promote: %v", got)
+		}
+		second, err := repo.PromoteStaging(ctx, KGDImport{URL: "b.xlsx", SHA256: "bb", Rows: 2})
+		if err != nil {
+			t.Fatalf("PromoteStaging: %v", err)
+		}
+		if got := amounts(); got["LAND CRUISER PRADO"] != 150 {
+			t.Errorf("data after second promote = %v", got)
+		}
+		if previous, err := repo.CountPreviousData(ctx); err != nil || previous != 2 {
+			t.Errorf("CountPreviousData = %d, %v, want 2", previous, err)
+		}
+		if stats, err := repo.GetStagingStats(ctx); err != nil || stats.Rows != 0 {
+			t.Errorf("staging after promote = %+v, %v", stats, err)
+		}
+
+		rolledBack, err := repo.RollbackData(ctx)
+		if err != nil {
+			t.Fatalf("RollbackData: %v", err)
+		}
+		if rolledBack.ID != second || rolledBack.SHA256 != "bb" || rolledBack.Status != KGDImportRolledBack {
+			t.Errorf("RollbackData = %+v, want import %d", rolledBack, second)
+		}
+		if got := amounts(); got["LAND CRUISER PRADO"] != 100 || got["SONATA"] != 200 {
+			t.Errorf("data after rollback = %v", got)
+		}
+		if id, err := repo.GetLastImportID(ctx); err != nil || id != first {
+			t.Errorf("GetLastImportID after rollback = %d, %v, want %d", id, err, first)
+		}
+		if i, ok, err := repo.GetLastKGDImport(ctx, KGDImportRolledBack); err != nil || !ok || i.ID != second {
+			t.Errorf("GetLastKGDImport rolled back = %+v, %v, %v", i, ok, err)
+		}
+		// прежняя версия хранится одна
+		if _, err := repo.RollbackData(ctx); !errors.Is(err, ErrNoPreviousData) {
+			t.Errorf("second RollbackData = %v, want ErrNoPreviousData", err)
+		}
+	})
+}
+
+func TestValuationHistory(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		promote := func(sha string, sonata int) int64 {
+			t.Helper()
+			err := repo.LoadStaging(ctx, []KGDRow{
+				{ID: 1, Mark: "HYUNDAI", Model: "SONATA", Variant: "SONATA", Volume: 2000, Year: 2020, Amount: sonata},
+				{ID: 2, Mark: "HYUNDAI", Model: "SONATA NEW", Variant: "SONATA NEW", Volume: 2000, Year: 2021, Amount: 300},
+			})
+			if err != nil {
+				t.Fatalf("LoadStaging: %v", err)
+			}
+			id, err := repo.PromoteStaging(ctx, KGDImport{URL: sha + ".xlsx", SHA256: sha, Rows: 2})
+			if err != nil {
+				t.Fatalf("PromoteStaging: %v", err)
+			}
+			return id
+		}
+		history := func(model string, year int) []int {
+			t.Helper()
+			valuations, err := repo.GetValuationHistory(ctx, "HYUNDAI", model, 2000, year)
+			if err != nil {
+				t.Fatalf("GetValuationHistory: %v", err)
+			}
+			amounts := make([]int, 0, len(valuations))
+			for _, v := range valuations {
+				if v.ImportedAt == "" {
+					t.Errorf("valuation without import date: %+v", v)
+				}
+				amounts = append(amounts, v.Amount)
+			}
+			return amounts
+		}
+
+		first := promote("aa", 200)
+		promote("bb", 220)
+		promote("cc", 180)
+		if got := history("SONATA", 2020); !reflect.DeepEqual(got, []int{200, 220, 180}) {
+			t.Errorf("history = %v, want [200 220 180]", got)
+		}
+		if valuations, _ := repo.GetValuationHistory(ctx, "HYUNDAI", "SONATA", 2000, 2020); valuations[0].ImportID != first {
+			t.Errorf("first valuation import = %d, want %d", valuations[0].ImportID, first)
+		}
+
+		// отмененная загрузка пропадает из истории
+		if _, err := repo.RollbackData(ctx); err != nil {
+			t.Fatalf("RollbackData: %v", err)
+		}
+		if got := history("SONATA", 2020); !reflect.DeepEqual(got, []int{200, 220}) {
+			t.Errorf("history after rollback = %v, want [200 220]", got)
+		}
+
+		// после слияния история прежних загрузок находится под новой моделью
+		if _, err := repo.MergeModels(ctx, "HYUNDAI", "SONATA", []string{"SONATA NEW"}); err != nil {
+			t.Fatalf("MergeModels: %v", err)
+		}
+		if got := history("SONATA", 2021); !reflect.DeepEqual(got, []int{300, 300}) {
+			t.Errorf("merged history = %v, want [300 300]", got)
+		}
+		if _, err := repo.DeleteModelAlias(ctx, "HYUNDAI", "SONATA NEW"); err != nil {
+			t.Fatalf("DeleteModelAlias: %v", err)
+		}
+		if got := history("SONATA NEW", 2021); len(got) != 2 {
+			t.Errorf("history after alias removal = %v, want 2 imports", got)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type Lead struct {
+	ID           int64  `db:"id"`
+	CreatedAt    int64  `db:"created_at"`
+	UpdatedAt    int64  `db:"updated_at"`
+	Name         string `db:"name"`
+	Phone        string `db:"phone"`
+	TelegramUser string `db:"telegram_user"`
+	Mark         string `db:"mark"`
+	Model        string `db:"model"`
+	Volume       int    `db:"volume"`
+	Year         int    `db:"year"`
+	Assessment   string `db:"assessment"`
+	Status       string `db:"status"`
+}
+
+func (r Repo) CreateLead(ctx context.Context, l Lead) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createLeadStmt.QueryRowContext(ctx, l.Name, l.Phone, l.TelegramUser, l.Mark, l.Model, l.Volume, l.Year, l.Assessment).Scan(&id)
+	return id, err
+}
+
+func (r Repo) GetLead(ctx context.Context, id int64) (Lead, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	l := Lead{}
+	err := r.getLeadStmt.QueryRowContext(ctx, id).Scan(
+		&l.ID, &l.CreatedAt, &l.UpdatedAt, &l.Name, &l.Phone, &l.TelegramUser,
+		&l.Mark, &l.Model, &l.Volume, &l.Year, &l.Assessment, &l.Status,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return l, false, nil
+	}
+	if err != nil {
+		return l, false, err
+	}
+	return l, true, nil
+}
+
+// GetLeads возвращает страницу заявок, новые сверху. Пустой status - все статусы.
+func (r Repo) GetLeads(ctx context.Context, status string, limit, offset int) ([]Lead, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	leads := make([]Lead, 0)
+	rows, err := r.getLeadsStmt.QueryContext(ctx, status, status, limit, offset)
+	if err != nil {
+		return leads, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := Lead{}
+		err := rows.Scan(
+			&l.ID, &l.CreatedAt, &l.UpdatedAt, &l.Name, &l.Phone, &l.TelegramUser,
+			&l.Mark, &l.Model, &l.Volume, &l.Year, &l.Assessment, &l.Status,
+		)
+		if err != nil {
+			return leads, err
+		}
+
+		leads = append(leads, l)
+	}
+	return leads, rows.Err()
+}
+
+func (r Repo) CountLeads(ctx context.Context, status string) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := r.countLeadsStmt.QueryRowContext(ctx, status, status).Scan(&count)
+	return count, err
+}
+
+func (r Repo) UpdateLeadStatus(ctx context.Context, id int64, status string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.updateLeadStatusStmt.ExecContext(ctx, status, id)
+	return err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+)
+
+// ModelAlias - написание модели Alias, которое сводится к модели Model марки Mark.
+type ModelAlias struct {
+	Mark      string `db:"mark"`
+	Alias     string `db:"alias"`
+	Model     string `db:"model"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+// GetModelAliases возвращает синонимы моделей марки, а при пустой марке - все.
+func (r Repo) GetModelAliases(ctx context.Context, mark string) ([]ModelAlias, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	aliases := make([]ModelAlias, 0)
+	rows, err := r.getModelAliasesStmt.QueryContext(ctx, mark, mark)
+	if err != nil {
+		return aliases, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := ModelAlias{}
+		err := rows.Scan(&a.Mark, &a.Alias, &a.Model, &a.CreatedAt)
+		if err != nil {
+			return aliases, err
+		}
+
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// MergeModels сводит написания aliases к модели model в одной транзакции:
+// запоминает синонимы, перенаправляет синонимы, указывавшие на них, и
+// переименовывает строки КГД, их историю и подписки. Возвращает число обновленных строк КГД.
+func (r Repo) MergeModels(ctx context.Context, mark, model string, aliases []string) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var updated int64
+	for _, alias := range aliases {
+		_, err := tx.ExecContext(ctx, r.dialect.Rebind(`INSERT INTO model_alias (mark, alias, model) VALUES (?, ?, ?)
+			ON CONFLICT (mark, alias) DO UPDATE SET model = excluded.model;`), mark, alias, model)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.Rebind("UPDATE model_alias SET model = ? WHERE mark = ? AND model = ?;"), model, mark, alias)
+		if err != nil {
+			return 0, err
+		}
+		res, err := tx.ExecContext(ctx, r.dialect.Rebind("UPDATE data SET model = ? WHERE mark = ? AND (model = ? OR variant = ?);"), model, mark, alias, alias)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += affected
+		_, err = tx.ExecContext(ctx, r.dialect.Rebind("UPDATE data_history SET model = ? WHERE mark = ? AND (model = ? OR variant = ?);"), model, mark, alias, alias)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.Rebind("UPDATE subscription SET model = ? WHERE mark = ? AND model = ?;"), model, mark, alias)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return updated, tx.Commit()
+}
+
+// DeleteModelAlias убирает синоним и возвращает строкам КГД и их истории с
+// этим написанием исходную модель.
+func (r Repo) DeleteModelAlias(ctx context.Context, mark, alias string) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind("DELETE FROM model_alias WHERE mark = ? AND alias = ?;"), mark, alias)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	for _, table := range []string{"data", "data_history"} {
+		_, err = tx.ExecContext(ctx, r.dialect.Rebind("UPDATE "+table+" SET model = variant WHERE mark = ? AND variant = ?;"), mark, alias)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+)
+
+// Popularity - вес марки (Model = "") или модели в списках. Weight задается
+// вручную, Requests - количество расчетов по ней.
+type Popularity struct {
+	Mark      string `db:"mark"`
+	Model     string `db:"model"`
+	Weight    int    `db:"weight"`
+	Requests  int    `db:"requests"`
+	UpdatedAt int64  `db:"updated_at"`
+}
+
+// GetPopularity возвращает веса марки и ее моделей, а при пустой марке - все.
+func (r Repo) GetPopularity(ctx context.Context, mark string) ([]Popularity, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	popularity := make([]Popularity, 0)
+	rows, err := r.getPopularityStmt.QueryContext(ctx, mark, mark)
+	if err != nil {
+		return popularity, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p := Popularity{}
+		err := rows.Scan(&p.Mark, &p.Model, &p.Weight, &p.Requests, &p.UpdatedAt)
+		if err != nil {
+			return popularity, err
+		}
+
+		popularity = append(popularity, p)
+	}
+	return popularity, rows.Err()
+}
+
+// SetPopularity задает ручной вес, не трогая счетчик расчетов.
+func (r Repo) SetPopularity(ctx context.Context, mark, model string, weight int) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.setPopularityStmt.ExecContext(ctx, mark, model, weight)
+	return err
+}
+
+func (r Repo) DeletePopularity(ctx context.Context, mark, model string) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.deletePopularityStmt.ExecContext(ctx, mark, model)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// RecalculatePopularity пересчитывает requests по сохраненным расчетам: для
+// моделей и отдельно для марок целиком. Записи без веса и без расчетов удаляются.
+func (r Repo) RecalculatePopularity(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE popularity SET requests = 0;"); err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	mark, model := r.dialect.jsonText("input", "Mark"), r.dialect.jsonText("input", "Model")
+	upsert := " ON CONFLICT (mark, model) DO UPDATE SET requests = excluded.requests, updated_at = " + r.dialect.unixNow() + ";"
+	for _, query := range []string{
+		`INSERT INTO popularity (mark, model, requests)
+			SELECT ` + mark + `, ` + model + `, COUNT(*) FROM assessment
+			WHERE ` + mark + ` != '' AND ` + model + ` != ''
+			GROUP BY 1, 2` + upsert,
+		`INSERT INTO popularity (mark, model, requests)
+			SELECT ` + mark + `, '', COUNT(*) FROM assessment
+			WHERE ` + mark + ` != ''
+			GROUP BY 1` + upsert,
+	} {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += affected
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM popularity WHERE weight = 0 AND requests = 0;"); err != nil {
+		return 0, err
+	}
+	return updated, tx.Commit()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRecalculatePopularity(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		exec(t, repo, "DELETE FROM popularity;")
+		for i, input := range []string{
+			`{"Mark":"KIA","Model":"K5","Amount":20000}`,
+			`{"Mark":"KIA","Model":"K5","Amount":21000}`,
+			`{"Mark":"KIA","Model":"SPORTAGE","Amount":25000}`,
+			`{"Mark":"","Model":"","Amount":1000}`,
+		} {
+			created, err := repo.CreateAssessment(ctx, Assessment{ID: string(rune('A' + i)), Input: input, Rates: "{}", Rules: "{}", Result: "{}"})
+			if err != nil || !created {
+				t.Fatalf("CreateAssessment = %v, %v", created, err)
+			}
+		}
+		if err := repo.SetPopularity(ctx, "BMW", "", 3); err != nil {
+			t.Fatalf("SetPopularity: %v", err)
+		}
+		if err := repo.SetPopularity(ctx, "AUDI", "", 0); err != nil {
+			t.Fatalf("SetPopularity: %v", err)
+		}
+
+		updated, err := repo.RecalculatePopularity(ctx)
+		if err != nil {
+			t.Fatalf("RecalculatePopularity: %v", err)
+		}
+		if updated != 3 {
+			t.Errorf("RecalculatePopularity updated %d rows, want 3", updated)
+		}
+
+		popularity, err := repo.GetPopularity(ctx, "")
+		if err != nil {
+			t.Fatalf("GetPopularity: %v", err)
+		}
+		got := make(map[string][2]int)
+		for _, p := range popularity {
+			got[p.Mark+"/"+p.Model] = [2]int{p.Weight, p.Requests}
+		}
+		want := map[string][2]int{
+			"BMW/":         {3, 0},
+			"KIA/":         {0, 3},
+			"KIA/K5":       {0, 2},
+			"KIA/SPORTAGE": {0, 1},
+		}
+		if len(got) != len(want) {
+			t.Errorf("GetPopularity = %v, want %v", got, want)
+		}
+		for key, w := range want {
+			if got[key] != w {
+				t.Errorf("popularity %s = %v, want %v", key, got[key], w)
+			}
+		}
+	})
+}
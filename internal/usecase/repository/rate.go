@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ExchangeRate - снимок курсов к тенге за день, Date в формате 2006-01-02.
+type ExchangeRate struct {
+	Date   string  `db:"date"`
+	USDKZT float64 `db:"usd_kzt"`
+	AEDKZT float64 `db:"aed_kzt"`
+	RUBKZT float64 `db:"rub_kzt"`
+}
+
+// SaveExchangeRate сохраняет курс за день, перезаписывая более ранний снимок того же дня.
+func (r Repo) SaveExchangeRate(ctx context.Context, rate ExchangeRate) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.saveExchangeRateStmt.ExecContext(ctx, rate.Date, rate.USDKZT, rate.AEDKZT, rate.RUBKZT)
+	return err
+}
+
+// GetPreviousExchangeRate возвращает последний снимок до указанной даты.
+func (r Repo) GetPreviousExchangeRate(ctx context.Context, date string) (ExchangeRate, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rate := ExchangeRate{}
+	err := r.getPreviousExchangeRateStmt.QueryRowContext(ctx, date).Scan(&rate.Date, &rate.USDKZT, &rate.AEDKZT, &rate.RUBKZT)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rate, false, nil
+	}
+	if err != nil {
+		return rate, false, err
+	}
+	return rate, true, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExchangeRate(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+
+		for _, rate := range []ExchangeRate{
+			{Date: "2024-05-01", USDKZT: 440, AEDKZT: 119.8, RUBKZT: 4.8},
+			{Date: "2024-05-02", USDKZT: 441, AEDKZT: 120, RUBKZT: 4.8},
+			{Date: "2024-05-02", USDKZT: 442.5, AEDKZT: 120.5, RUBKZT: 4.9},
+		} {
+			if err := repo.SaveExchangeRate(ctx, rate); err != nil {
+				t.Fatalf("SaveExchangeRate: %v", err)
+			}
+		}
+
+		rate, ok, err := repo.GetPreviousExchangeRate(ctx, "2024-05-03")
+		want := ExchangeRate{Date: "2024-05-02", USDKZT: 442.5, AEDKZT: 120.5, RUBKZT: 4.9}
+		if err != nil || !ok || rate != want {
+			t.Errorf("GetPreviousExchangeRate = %+v, %v, %v, want the last snapshot of the day %+v", rate, ok, err, want)
+		}
+
+		if _, ok, err := repo.GetPreviousExchangeRate(ctx, "2024-05-01"); err != nil || ok {
+			t.Errorf("GetPreviousExchangeRate before the first day = %v, %v, want none", ok, err)
+		}
+	})
+}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type Repo struct {
@@ -10,34 +13,443 @@ type Repo struct {
 	getModelsStmt         *sql.Stmt
 	getVolumesStmt        *sql.Stmt
 	getSpecificationsStmt *sql.Stmt
-	db                    *sql.DB
+	getDataRowsStmt       *sql.Stmt
+	getCatalogRowsStmt    *sql.Stmt
+	getLastImportIDStmt   *sql.Stmt
+	getLastKGDImportStmt  *sql.Stmt
+	createKGDImportStmt   *sql.Stmt
+	getStagingMarksStmt   *sql.Stmt
+	getStagingChangeStmt  *sql.Stmt
+	countPreviousDataStmt *sql.Stmt
+
+	getValuationHistoryStmt *sql.Stmt
+
+	createSubscriptionStmt       *sql.Stmt
+	getSubscriptionsStmt         *sql.Stmt
+	updateSubscriptionAmountStmt *sql.Stmt
+	deleteSubscriptionStmt       *sql.Stmt
+
+	saveExchangeRateStmt        *sql.Stmt
+	getPreviousExchangeRateStmt *sql.Stmt
+
+	createLeadStmt       *sql.Stmt
+	getLeadStmt          *sql.Stmt
+	getLeadsStmt         *sql.Stmt
+	countLeadsStmt       *sql.Stmt
+	updateLeadStatusStmt *sql.Stmt
+
+	createAssessmentStmt *sql.Stmt
+	getAssessmentStmt    *sql.Stmt
+
+	getVehicleNamesStmt  *sql.Stmt
+	getSearchAliasesStmt *sql.Stmt
+	getModelAliasesStmt  *sql.Stmt
+
+	getPopularityStmt    *sql.Stmt
+	setPopularityStmt    *sql.Stmt
+	deletePopularityStmt *sql.Stmt
+
+	getDeliveredsStmt      *sql.Stmt
+	getBrokerAmountsStmt   *sql.Stmt
+	getSOSPricesStmt       *sql.Stmt
+	listDeliveredsStmt     *sql.Stmt
+	getDeliveredStmt       *sql.Stmt
+	createDeliveredStmt    *sql.Stmt
+	updateDeliveredStmt    *sql.Stmt
+	deleteDeliveredStmt    *sql.Stmt
+	listBrokerAmountsStmt  *sql.Stmt
+	getBrokerAmountStmt    *sql.Stmt
+	createBrokerAmountStmt *sql.Stmt
+	updateBrokerAmountStmt *sql.Stmt
+	deleteBrokerAmountStmt *sql.Stmt
+	listSOSPricesStmt      *sql.Stmt
+	getSOSPriceStmt        *sql.Stmt
+	createSOSPriceStmt     *sql.Stmt
+	updateSOSPriceStmt     *sql.Stmt
+	deleteSOSPriceStmt     *sql.Stmt
+
+	// searchIndexStmt равен nil, если SQLite собран без fts5
+	searchIndexStmt *sql.Stmt
+
+	db      *sql.DB
+	read    *sql.DB
+	dialect Dialect
+	// queryTimeout ограничивает каждый запрос, нулевое значение - только ctx вызывающего
+	queryTimeout time.Duration
 }
 
-func NewRepository(db *sql.DB) (Repo, error) {
-	getMarksStmt, err := db.Prepare("SELECT DISTINCT mark FROM data ORDER BY popular_rate DESC;")
+// NewRepository готовит запросы репозитория в диалекте dialect. SELECT
+// готовятся на пуле read, остальное - на db; read может совпадать с db.
+func NewRepository(db, read *sql.DB, dialect Dialect) (Repo, error) {
+	prepare := func(query string) (*sql.Stmt, error) {
+		if strings.HasPrefix(query, "SELECT ") {
+			return read.Prepare(dialect.Rebind(query))
+		}
+		return db.Prepare(dialect.Rebind(query))
+	}
+
+	getMarksStmt, err := prepare(`SELECT d.mark FROM data d
+		LEFT JOIN popularity p ON p.mark = d.mark AND p.model = ''
+		GROUP BY d.mark
+		ORDER BY COALESCE(MAX(p.weight), 0) DESC, COALESCE(MAX(p.requests), 0) DESC, d.mark ASC;`)
 	if err != nil {
 		return Repo{}, fmt.Errorf("getMarkStmt -> %v", err)
 	}
 
-	getModelsStmt, err := db.Prepare("SELECT DISTINCT model FROM data WHERE mark = ? ORDER BY model ASC;")
+	getModelsStmt, err := prepare(`SELECT d.model, d.variant FROM data d
+		LEFT JOIN popularity p ON p.mark = d.mark AND p.model = d.model
+		WHERE d.mark = ?
+		GROUP BY d.model, d.variant
+		ORDER BY COALESCE(MAX(p.weight), 0) DESC, COALESCE(MAX(p.requests), 0) DESC, d.model ASC, d.variant ASC;`)
 	if err != nil {
 		return Repo{}, fmt.Errorf("getModelStmt -> %v", err)
 	}
 
-	getVolumesStmt, err := db.Prepare("SELECT DISTINCT volume FROM data WHERE mark = ? and model = ? ORDER BY model ASC;")
+	getVolumesStmt, err := prepare("SELECT DISTINCT volume FROM data WHERE mark = ? and model = ? ORDER BY volume ASC;")
 	if err != nil {
 		return Repo{}, fmt.Errorf("getVolumeStmt -> %v", err)
 	}
 
-	getSpecificationsStmt, err := db.Prepare("SELECT year, amount FROM data WHERE mark = ? and model = ? and volume = ? ORDER BY year DESC;")
+	getSpecificationsStmt, err := prepare(`SELECT year, amount, engine_type, battery_capacity, power, vehicle_class FROM data WHERE mark = ? and model = ? and volume = ?
+		ORDER BY year DESC, CASE WHEN variant = '' OR variant = model THEN 0 ELSE 1 END, variant ASC;`)
 	if err != nil {
 		return Repo{}, fmt.Errorf("getSpecificationStmt -> %v", err)
 	}
+
+	getDataRowsStmt, err := prepare(`SELECT id, mark, model, volume, year, amount, engine_type, vehicle_class FROM data
+		WHERE (? = '' OR mark = ?) AND (? = '' OR model = ?) AND (? = 0 OR year >= ?) AND (? = 0 OR year <= ?)
+		ORDER BY mark ASC, model ASC, volume ASC, year ASC;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("getDataRowsStmt -> %v", err)
+	}
+
+	getCatalogRowsStmt, err := prepare("SELECT mark, model, variant, volume, year, amount, engine_type, battery_capacity, power, vehicle_class FROM data ORDER BY mark ASC, model ASC, variant ASC, volume ASC, year DESC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getCatalogRowsStmt -> %v", err)
+	}
+
+	getLastImportIDStmt, err := prepare("SELECT COALESCE(MAX(id), 0) FROM kgd_data_migration WHERE status = 'imported';")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getLastImportIDStmt -> %v", err)
+	}
+
+	getLastKGDImportStmt, err := prepare(`SELECT id, kgd_url, created_at, etag, last_modified, sha256, status, error, row_count FROM kgd_data_migration
+		WHERE status = ? ORDER BY id DESC LIMIT 1;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("getLastKGDImportStmt -> %v", err)
+	}
+
+	createKGDImportStmt, err := prepare("INSERT INTO kgd_data_migration (kgd_url, etag, last_modified, sha256, status, error, row_count) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createKGDImportStmt -> %v", err)
+	}
+
+	getStagingMarksStmt, err := prepare("SELECT mark, COUNT(*) FROM data_staging GROUP BY mark;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getStagingMarksStmt -> %v", err)
+	}
+
+	getStagingChangeStmt, err := prepare(`SELECT COUNT(*), COALESCE(SUM(d.amount), 0), COALESCE(SUM(s.amount), 0) FROM data_staging s
+		JOIN data d ON d.mark = s.mark AND d.variant = s.variant AND d.volume = s.volume AND d.year = s.year;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("getStagingChangeStmt -> %v", err)
+	}
+
+	countPreviousDataStmt, err := prepare("SELECT COUNT(*) FROM data_previous;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("countPreviousDataStmt -> %v", err)
+	}
+
+	getValuationHistoryStmt, err := prepare(`SELECT m.id, m.created_at, MAX(h.amount) FROM data_history h
+		JOIN kgd_data_migration m ON m.id = h.import_id
+		WHERE m.status = 'imported' AND h.mark = ? AND h.model = ? AND h.volume = ? AND h.year = ?
+		GROUP BY m.id, m.created_at ORDER BY m.id ASC;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("getValuationHistoryStmt -> %v", err)
+	}
+
+	createSubscriptionStmt, err := prepare("INSERT INTO subscription (chat_id, mark, model, volume, year, price_usd, threshold, last_amount) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createSubscriptionStmt -> %v", err)
+	}
+
+	getSubscriptionsStmt, err := prepare("SELECT id, chat_id, mark, model, volume, year, price_usd, threshold, last_amount FROM subscription WHERE ? = 0 OR chat_id = ? ORDER BY id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getSubscriptionsStmt -> %v", err)
+	}
+
+	updateSubscriptionAmountStmt, err := prepare("UPDATE subscription SET last_amount = ? WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("updateSubscriptionAmountStmt -> %v", err)
+	}
+
+	deleteSubscriptionStmt, err := prepare("DELETE FROM subscription WHERE id = ? AND chat_id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("deleteSubscriptionStmt -> %v", err)
+	}
+
+	saveExchangeRateStmt, err := prepare(`INSERT INTO exchange_rate (date, usd_kzt, aed_kzt, rub_kzt) VALUES (?, ?, ?, ?)
+		ON CONFLICT (date) DO UPDATE SET usd_kzt = excluded.usd_kzt, aed_kzt = excluded.aed_kzt, rub_kzt = excluded.rub_kzt;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("saveExchangeRateStmt -> %v", err)
+	}
+
+	getPreviousExchangeRateStmt, err := prepare("SELECT date, usd_kzt, aed_kzt, rub_kzt FROM exchange_rate WHERE date < ? ORDER BY date DESC LIMIT 1;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getPreviousExchangeRateStmt -> %v", err)
+	}
+
+	const leadColumns = "id, created_at, updated_at, name, phone, telegram_user, mark, model, volume, year, assessment, status"
+
+	createLeadStmt, err := prepare("INSERT INTO lead (name, phone, telegram_user, mark, model, volume, year, assessment) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createLeadStmt -> %v", err)
+	}
+
+	getLeadStmt, err := prepare("SELECT " + leadColumns + " FROM lead WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getLeadStmt -> %v", err)
+	}
+
+	getLeadsStmt, err := prepare("SELECT " + leadColumns + " FROM lead WHERE ? = '' OR status = ? ORDER BY id DESC LIMIT ? OFFSET ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getLeadsStmt -> %v", err)
+	}
+
+	countLeadsStmt, err := prepare("SELECT COUNT(*) FROM lead WHERE ? = '' OR status = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("countLeadsStmt -> %v", err)
+	}
+
+	updateLeadStatusStmt, err := prepare("UPDATE lead SET status = ?, updated_at = " + dialect.unixNow() + " WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("updateLeadStatusStmt -> %v", err)
+	}
+
+	createAssessmentStmt, err := prepare("INSERT INTO assessment (id, input, rates, rules, result) VALUES (?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createAssessmentStmt -> %v", err)
+	}
+
+	getAssessmentStmt, err := prepare("SELECT id, created_at, input, rates, rules, result FROM assessment WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getAssessmentStmt -> %v", err)
+	}
+
+	getVehicleNamesStmt, err := prepare("SELECT DISTINCT mark, model FROM data ORDER BY mark ASC, model ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getVehicleNamesStmt -> %v", err)
+	}
+
+	getSearchAliasesStmt, err := prepare("SELECT alias, replacement FROM search_alias;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getSearchAliasesStmt -> %v", err)
+	}
+
+	getModelAliasesStmt, err := prepare("SELECT mark, alias, model, created_at FROM model_alias WHERE ? = '' OR mark = ? ORDER BY mark ASC, model ASC, alias ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getModelAliasesStmt -> %v", err)
+	}
+
+	getPopularityStmt, err := prepare("SELECT mark, model, weight, requests, updated_at FROM popularity WHERE ? = '' OR mark = ? ORDER BY weight DESC, requests DESC, mark ASC, model ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getPopularityStmt -> %v", err)
+	}
+
+	setPopularityStmt, err := prepare(`INSERT INTO popularity (mark, model, weight) VALUES (?, ?, ?)
+		ON CONFLICT (mark, model) DO UPDATE SET weight = excluded.weight, updated_at = ` + dialect.unixNow() + `;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("setPopularityStmt -> %v", err)
+	}
+
+	deletePopularityStmt, err := prepare("DELETE FROM popularity WHERE mark = ? AND model = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("deletePopularityStmt -> %v", err)
+	}
+
+	getDeliveredsStmt, err := prepare("SELECT " + deliveredColumns + " FROM delivered WHERE country = ? AND (? = '' OR from_city = ?) AND " + validOn + " ORDER BY amount ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getDeliveredsStmt -> %v", err)
+	}
+
+	getBrokerAmountsStmt, err := prepare("SELECT " + brokerAmountColumns + " FROM broker_amount WHERE country = ? AND " + validOn + " ORDER BY amount ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getBrokerAmountsStmt -> %v", err)
+	}
+
+	getSOSPricesStmt, err := prepare("SELECT " + sosPriceColumns + " FROM sos_price WHERE country = ? AND " + validOn + " ORDER BY amount ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getSOSPricesStmt -> %v", err)
+	}
+
+	listDeliveredsStmt, err := prepare("SELECT " + deliveredColumns + " FROM delivered WHERE ? = '' OR country = ? ORDER BY country ASC, from_city ASC, to_city ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("listDeliveredsStmt -> %v", err)
+	}
+
+	getDeliveredStmt, err := prepare("SELECT " + deliveredColumns + " FROM delivered WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getDeliveredStmt -> %v", err)
+	}
+
+	createDeliveredStmt, err := prepare("INSERT INTO delivered (country, from_city, to_city, carrier, amount, currency, transit_days, valid_from, valid_to) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createDeliveredStmt -> %v", err)
+	}
+
+	updateDeliveredStmt, err := prepare(`UPDATE delivered SET country = ?, from_city = ?, to_city = ?, carrier = ?, amount = ?, currency = ?, transit_days = ?, valid_from = ?, valid_to = ?, updated_at = ` + dialect.unixNow() + ` WHERE id = ?;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("updateDeliveredStmt -> %v", err)
+	}
+
+	deleteDeliveredStmt, err := prepare("DELETE FROM delivered WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("deleteDeliveredStmt -> %v", err)
+	}
+
+	listBrokerAmountsStmt, err := prepare("SELECT " + brokerAmountColumns + " FROM broker_amount WHERE ? = '' OR country = ? ORDER BY country ASC, amount ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("listBrokerAmountsStmt -> %v", err)
+	}
+
+	getBrokerAmountStmt, err := prepare("SELECT " + brokerAmountColumns + " FROM broker_amount WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getBrokerAmountStmt -> %v", err)
+	}
+
+	createBrokerAmountStmt, err := prepare("INSERT INTO broker_amount (country, name, amount, valid_from, valid_to) VALUES (?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createBrokerAmountStmt -> %v", err)
+	}
+
+	updateBrokerAmountStmt, err := prepare(`UPDATE broker_amount SET country = ?, name = ?, amount = ?, valid_from = ?, valid_to = ?, updated_at = ` + dialect.unixNow() + ` WHERE id = ?;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("updateBrokerAmountStmt -> %v", err)
+	}
+
+	deleteBrokerAmountStmt, err := prepare("DELETE FROM broker_amount WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("deleteBrokerAmountStmt -> %v", err)
+	}
+
+	listSOSPricesStmt, err := prepare("SELECT " + sosPriceColumns + " FROM sos_price WHERE ? = '' OR country = ? ORDER BY country ASC, amount ASC, id ASC;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("listSOSPricesStmt -> %v", err)
+	}
+
+	getSOSPriceStmt, err := prepare("SELECT " + sosPriceColumns + " FROM sos_price WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("getSOSPriceStmt -> %v", err)
+	}
+
+	createSOSPriceStmt, err := prepare("INSERT INTO sos_price (country, name, amount, valid_from, valid_to) VALUES (?, ?, ?, ?, ?) RETURNING id;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("createSOSPriceStmt -> %v", err)
+	}
+
+	updateSOSPriceStmt, err := prepare(`UPDATE sos_price SET country = ?, name = ?, amount = ?, valid_from = ?, valid_to = ?, updated_at = ` + dialect.unixNow() + ` WHERE id = ?;`)
+	if err != nil {
+		return Repo{}, fmt.Errorf("updateSOSPriceStmt -> %v", err)
+	}
+
+	deleteSOSPriceStmt, err := prepare("DELETE FROM sos_price WHERE id = ?;")
+	if err != nil {
+		return Repo{}, fmt.Errorf("deleteSOSPriceStmt -> %v", err)
+	}
+
+	// fts5 есть только в SQLite, в PostgreSQL поиск работает перебором
+	var searchIndexStmt *sql.Stmt
+	if dialect == DialectSQLite {
+		if _, err := db.Exec(createSearchIndexQuery); err == nil {
+			searchIndexStmt, err = prepare("SELECT mark, model FROM vehicle_search WHERE vehicle_search MATCH ? ORDER BY rank LIMIT ?;")
+			if err != nil {
+				return Repo{}, fmt.Errorf("searchIndexStmt -> %v", err)
+			}
+		}
+	}
+
 	return Repo{
 		getMarksStmt:          getMarksStmt,
 		getModelsStmt:         getModelsStmt,
 		getVolumesStmt:        getVolumesStmt,
 		getSpecificationsStmt: getSpecificationsStmt,
-		db:                    db,
+		getDataRowsStmt:       getDataRowsStmt,
+		getCatalogRowsStmt:    getCatalogRowsStmt,
+		getLastImportIDStmt:   getLastImportIDStmt,
+		getLastKGDImportStmt:  getLastKGDImportStmt,
+		createKGDImportStmt:   createKGDImportStmt,
+		getStagingMarksStmt:   getStagingMarksStmt,
+		getStagingChangeStmt:  getStagingChangeStmt,
+		countPreviousDataStmt: countPreviousDataStmt,
+
+		getValuationHistoryStmt: getValuationHistoryStmt,
+
+		createSubscriptionStmt:       createSubscriptionStmt,
+		getSubscriptionsStmt:         getSubscriptionsStmt,
+		updateSubscriptionAmountStmt: updateSubscriptionAmountStmt,
+		deleteSubscriptionStmt:       deleteSubscriptionStmt,
+
+		saveExchangeRateStmt:        saveExchangeRateStmt,
+		getPreviousExchangeRateStmt: getPreviousExchangeRateStmt,
+
+		createLeadStmt:       createLeadStmt,
+		getLeadStmt:          getLeadStmt,
+		getLeadsStmt:         getLeadsStmt,
+		countLeadsStmt:       countLeadsStmt,
+		updateLeadStatusStmt: updateLeadStatusStmt,
+
+		createAssessmentStmt: createAssessmentStmt,
+		getAssessmentStmt:    getAssessmentStmt,
+
+		getVehicleNamesStmt:  getVehicleNamesStmt,
+		getSearchAliasesStmt: getSearchAliasesStmt,
+		getModelAliasesStmt:  getModelAliasesStmt,
+
+		getPopularityStmt:    getPopularityStmt,
+		setPopularityStmt:    setPopularityStmt,
+		deletePopularityStmt: deletePopularityStmt,
+
+		getDeliveredsStmt:      getDeliveredsStmt,
+		getBrokerAmountsStmt:   getBrokerAmountsStmt,
+		getSOSPricesStmt:       getSOSPricesStmt,
+		listDeliveredsStmt:     listDeliveredsStmt,
+		getDeliveredStmt:       getDeliveredStmt,
+		createDeliveredStmt:    createDeliveredStmt,
+		updateDeliveredStmt:    updateDeliveredStmt,
+		deleteDeliveredStmt:    deleteDeliveredStmt,
+		listBrokerAmountsStmt:  listBrokerAmountsStmt,
+		getBrokerAmountStmt:    getBrokerAmountStmt,
+		createBrokerAmountStmt: createBrokerAmountStmt,
+		updateBrokerAmountStmt: updateBrokerAmountStmt,
+		deleteBrokerAmountStmt: deleteBrokerAmountStmt,
+		listSOSPricesStmt:      listSOSPricesStmt,
+		getSOSPriceStmt:        getSOSPriceStmt,
+		createSOSPriceStmt:     createSOSPriceStmt,
+		updateSOSPriceStmt:     updateSOSPriceStmt,
+		deleteSOSPriceStmt:     deleteSOSPriceStmt,
+
+		searchIndexStmt: searchIndexStmt,
+
+		db:      db,
+		read:    read,
+		dialect: dialect,
 	}, nil
 }
+
+// WithQueryTimeout возвращает копию репозитория, в которой каждый запрос
+// прерывается через timeout, даже если ctx вызывающего без дедлайна.
+func (r Repo) WithQueryTimeout(timeout time.Duration) Repo {
+	r.queryTimeout = timeout
+	return r
+}
+
+// withTimeout ограничивает отдельный запрос queryTimeout. Транзакции по всему
+// справочнику или таблице расчетов (загрузка и перенос списка КГД, пересборка
+// поискового индекса, пересчет популярности) идут дольше, поэтому его не
+// вызывают: их ограничивает только ctx вызывающего.
+func (r Repo) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
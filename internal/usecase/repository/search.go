@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+)
+
+// createSearchIndexQuery создает полнотекстовый индекс по маркам и моделям.
+// Модуль fts5 есть только в сборке с тегом sqlite_fts5, поэтому таблица
+// создается при старте, а не миграцией: без модуля поиск работает перебором.
+const createSearchIndexQuery = `CREATE VIRTUAL TABLE IF NOT EXISTS vehicle_search USING fts5(
+	mark, model, latin, sound, tokenize = 'trigram'
+);`
+
+// VehicleName - уникальная пара марки и модели из справочника КГД.
+type VehicleName struct {
+	Mark  string `db:"mark"`
+	Model string `db:"model"`
+}
+
+// SearchDocument - строка полнотекстового индекса: Latin - марка и модель
+// латиницей, Sound - их фонетические ключи.
+type SearchDocument struct {
+	VehicleName
+	Latin string
+	Sound string
+}
+
+// SearchIndexEnabled сообщает, доступен ли полнотекстовый индекс в этой сборке.
+func (r Repo) SearchIndexEnabled() bool {
+	return r.searchIndexStmt != nil
+}
+
+// ReplaceSearchIndex пересобирает полнотекстовый индекс целиком в одной транзакции.
+func (r Repo) ReplaceSearchIndex(ctx context.Context, documents []SearchDocument) error {
+	if !r.SearchIndexEnabled() {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM vehicle_search;"); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO vehicle_search (mark, model, latin, sound) VALUES (?, ?, ?, ?);")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, d := range documents {
+		if _, err := stmt.ExecContext(ctx, d.Mark, d.Model, d.Latin, d.Sound); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchIndex ищет марки и модели по выражению FTS5 MATCH, лучшие совпадения по bm25 - первыми.
+func (r Repo) SearchIndex(ctx context.Context, match string, limit int) ([]VehicleName, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	names := make([]VehicleName, 0)
+	if !r.SearchIndexEnabled() {
+		return names, nil
+	}
+
+	rows, err := r.searchIndexStmt.QueryContext(ctx, match, limit)
+	if err != nil {
+		return names, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		name := VehicleName{}
+		err := rows.Scan(&name.Mark, &name.Model)
+		if err != nil {
+			return names, err
+		}
+
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// GetVehicleNames возвращает все уникальные пары марки и модели.
+func (r Repo) GetVehicleNames(ctx context.Context) ([]VehicleName, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	names := make([]VehicleName, 0)
+	rows, err := r.getVehicleNamesStmt.QueryContext(ctx)
+	if err != nil {
+		return names, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		name := VehicleName{}
+		err := rows.Scan(&name.Mark, &name.Model)
+		if err != nil {
+			return names, err
+		}
+
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// GetSearchAliases возвращает синонимы поиска: alias -> подстановка.
+func (r Repo) GetSearchAliases(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	aliases := make(map[string]string)
+	rows, err := r.getSearchAliasesStmt.QueryContext(ctx)
+	if err != nil {
+		return aliases, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alias, replacement string
+		err := rows.Scan(&alias, &replacement)
+		if err != nil {
+			return aliases, err
+		}
+
+		aliases[alias] = replacement
+	}
+	return aliases, rows.Err()
+}
@@ -0,0 +1,78 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSearchIndex проверяет полнотекстовый индекс; fts5 есть только в сборке
+// с тегом sqlite_fts5: go test -tags sqlite_fts5 ./...
+func TestSearchIndex(t *testing.T) {
+	repo := newSQLiteRepo(t)
+	ctx := context.Background()
+	seedData(t, repo)
+
+	if !repo.SearchIndexEnabled() {
+		t.Fatal("SearchIndexEnabled = false in a sqlite_fts5 build")
+	}
+
+	names, err := repo.GetVehicleNames(ctx)
+	if err != nil {
+		t.Fatalf("GetVehicleNames: %v", err)
+	}
+	if len(names) != 4 {
+		t.Fatalf("GetVehicleNames = %v, want 4 names", names)
+	}
+
+	documents := []SearchDocument{
+		{VehicleName{"TOYOTA", "CAMRY"}, "TOYOTA CAMRY", "TT KMR"},
+		{VehicleName{"TOYOTA", "LAND CRUISER 200"}, "TOYOTA LAND CRUISER 200", "TT LND KRSR 200"},
+		{VehicleName{"HYUNDAI", "SONATA"}, "HYUNDAI SONATA", "HND SNT"},
+	}
+	if err := repo.ReplaceSearchIndex(ctx, documents); err != nil {
+		t.Fatalf("ReplaceSearchIndex: %v", err)
+	}
+	// повторная пересборка не дублирует строки
+	if err := repo.ReplaceSearchIndex(ctx, documents); err != nil {
+		t.Fatalf("ReplaceSearchIndex: %v", err)
+	}
+
+	tests := []struct {
+		match string
+		want  []VehicleName
+	}{
+		// "крузер" после транслитерации дает тот же фонетический ключ
+		{`"KRSR"`, []VehicleName{{"TOYOTA", "LAND CRUISER 200"}}},
+		{`"CRUISER" OR "SONATA"`, []VehicleName{{"TOYOTA", "LAND CRUISER 200"}, {"HYUNDAI", "SONATA"}}},
+		{`"KIA"`, []VehicleName{}},
+	}
+	for _, tt := range tests {
+		got, err := repo.SearchIndex(ctx, tt.match, 10)
+		if err != nil {
+			t.Fatalf("SearchIndex(%s): %v", tt.match, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("SearchIndex(%s) = %v, want %v", tt.match, got, tt.want)
+			continue
+		}
+		for _, name := range tt.want {
+			found := false
+			for _, g := range got {
+				found = found || g == name
+			}
+			if !found {
+				t.Errorf("SearchIndex(%s) = %v, want %v", tt.match, got, tt.want)
+			}
+		}
+	}
+
+	aliases, err := repo.GetSearchAliases(ctx)
+	if err != nil {
+		t.Fatalf("GetSearchAliases: %v", err)
+	}
+	if got := aliases["LC200"]; got != "TOYOTA LAND CRUISER 200" {
+		t.Errorf("GetSearchAliases[LC200] = %q, want TOYOTA LAND CRUISER 200", got)
+	}
+}
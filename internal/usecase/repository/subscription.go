@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+)
+
+type Subscription struct {
+	ID         int64  `db:"id"`
+	ChatID     int64  `db:"chat_id"`
+	Mark       string `db:"mark"`
+	Model      string `db:"model"`
+	Volume     int    `db:"volume"`
+	Year       int    `db:"year"`
+	PriceUSD   int    `db:"price_usd"`
+	Threshold  int    `db:"threshold"`
+	LastAmount int    `db:"last_amount"`
+}
+
+func (r Repo) CreateSubscription(ctx context.Context, s Subscription) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createSubscriptionStmt.QueryRowContext(ctx, s.ChatID, s.Mark, s.Model, s.Volume, s.Year, s.PriceUSD, s.Threshold, s.LastAmount).Scan(&id)
+	return id, err
+}
+
+// GetSubscriptions возвращает подписки чата, а при chatID = 0 - все подписки.
+func (r Repo) GetSubscriptions(ctx context.Context, chatID int64) ([]Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	subscriptions := make([]Subscription, 0)
+	rows, err := r.getSubscriptionsStmt.QueryContext(ctx, chatID, chatID)
+	if err != nil {
+		return subscriptions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := Subscription{}
+		err := rows.Scan(&s.ID, &s.ChatID, &s.Mark, &s.Model, &s.Volume, &s.Year, &s.PriceUSD, &s.Threshold, &s.LastAmount)
+		if err != nil {
+			return subscriptions, err
+		}
+
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r Repo) UpdateSubscriptionAmount(ctx context.Context, id int64, amount int) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.updateSubscriptionAmountStmt.ExecContext(ctx, amount, id)
+	return err
+}
+
+// DeleteSubscription удаляет подписку только если она принадлежит чату.
+func (r Repo) DeleteSubscription(ctx context.Context, chatID, id int64) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.deleteSubscriptionStmt.ExecContext(ctx, id, chatID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
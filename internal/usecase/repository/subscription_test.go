@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscriptions(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		exec(t, repo, "DELETE FROM subscription;")
+
+		ids := make([]int64, 0, 3)
+		for _, s := range []Subscription{
+			{ChatID: 1, Mark: "TOYOTA", Model: "CAMRY", Volume: 2500, Year: 2020, Threshold: 100000, LastAmount: 15000000},
+			{ChatID: 1, Mark: "KIA", Model: "K5", Volume: 2000, Year: 2021, PriceUSD: 18000, Threshold: 50000, LastAmount: 12000000},
+			{ChatID: 2, Mark: "TOYOTA", Model: "CAMRY", Volume: 2500, Year: 2022, Threshold: 100000, LastAmount: 17000000},
+		} {
+			id, err := repo.CreateSubscription(ctx, s)
+			if err != nil {
+				t.Fatalf("CreateSubscription: %v", err)
+			}
+			// id возвращается через RETURNING в обоих диалектах
+			if len(ids) > 0 && id <= ids[len(ids)-1] {
+				t.Errorf("CreateSubscription id = %d after %d", id, ids[len(ids)-1])
+			}
+			ids = append(ids, id)
+		}
+
+		all, err := repo.GetSubscriptions(ctx, 0)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("GetSubscriptions(0) = %v, %v, want 3 subscriptions", all, err)
+		}
+		chat, err := repo.GetSubscriptions(ctx, 1)
+		if err != nil || len(chat) != 2 {
+			t.Fatalf("GetSubscriptions(1) = %v, %v, want 2 subscriptions", chat, err)
+		}
+		if chat[1].ID != ids[1] || chat[1].PriceUSD != 18000 || chat[1].Threshold != 50000 {
+			t.Errorf("GetSubscriptions(1)[1] = %+v", chat[1])
+		}
+
+		if err := repo.UpdateSubscriptionAmount(ctx, ids[0], 15500000); err != nil {
+			t.Fatalf("UpdateSubscriptionAmount: %v", err)
+		}
+		chat, err = repo.GetSubscriptions(ctx, 1)
+		if err != nil || chat[0].LastAmount != 15500000 {
+			t.Errorf("LastAmount after update = %v, %v, want 15500000", chat, err)
+		}
+
+		// чужую подписку удалить нельзя
+		deleted, err := repo.DeleteSubscription(ctx, 2, ids[0])
+		if err != nil || deleted {
+			t.Errorf("DeleteSubscription(other chat) = %v, %v, want false", deleted, err)
+		}
+		deleted, err = repo.DeleteSubscription(ctx, 1, ids[0])
+		if err != nil || !deleted {
+			t.Errorf("DeleteSubscription = %v, %v, want true", deleted, err)
+		}
+	})
+}
@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Delivered - маршрут доставки. Amount в валюте Currency, ValidFrom и ValidTo -
+// даты 2006-01-02 включительно, пустая строка - без ограничения.
+type Delivered struct {
+	ID          int64  `db:"id"`
+	Country     string `db:"country"`
+	FromCity    string `db:"from_city"`
+	ToCity      string `db:"to_city"`
+	Carrier     string `db:"carrier"`
+	Amount      int    `db:"amount"`
+	Currency    string `db:"currency"`
+	TransitDays int    `db:"transit_days"`
+	ValidFrom   string `db:"valid_from"`
+	ValidTo     string `db:"valid_to"`
+}
+
+// BrokerAmount - тариф брокера в тенге.
+type BrokerAmount struct {
+	ID        int64  `db:"id"`
+	Country   string `db:"country"`
+	Name      string `db:"name"`
+	Amount    int    `db:"amount"`
+	ValidFrom string `db:"valid_from"`
+	ValidTo   string `db:"valid_to"`
+}
+
+// SOSPrice - цена установки кнопки SOS/ЭВАК в тенге.
+type SOSPrice struct {
+	ID        int64  `db:"id"`
+	Country   string `db:"country"`
+	Name      string `db:"name"`
+	Amount    int    `db:"amount"`
+	ValidFrom string `db:"valid_from"`
+	ValidTo   string `db:"valid_to"`
+}
+
+const (
+	deliveredColumns    = "id, country, from_city, to_city, carrier, amount, currency, transit_days, valid_from, valid_to"
+	brokerAmountColumns = "id, country, name, amount, valid_from, valid_to"
+	sosPriceColumns     = "id, country, name, amount, valid_from, valid_to"
+
+	// validOn отбирает предложения, действующие на дату, переданную дважды
+	validOn = "(valid_from = '' OR valid_from <= ?) AND (valid_to = '' OR valid_to >= ?)"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDelivered(s scanner) (Delivered, error) {
+	d := Delivered{}
+	err := s.Scan(&d.ID, &d.Country, &d.FromCity, &d.ToCity, &d.Carrier, &d.Amount, &d.Currency, &d.TransitDays, &d.ValidFrom, &d.ValidTo)
+	return d, err
+}
+
+func scanBrokerAmount(s scanner) (BrokerAmount, error) {
+	b := BrokerAmount{}
+	err := s.Scan(&b.ID, &b.Country, &b.Name, &b.Amount, &b.ValidFrom, &b.ValidTo)
+	return b, err
+}
+
+func scanSOSPrice(s scanner) (SOSPrice, error) {
+	p := SOSPrice{}
+	err := s.Scan(&p.ID, &p.Country, &p.Name, &p.Amount, &p.ValidFrom, &p.ValidTo)
+	return p, err
+}
+
+// GetDelivereds возвращает маршруты доставки страны, действующие на дату date.
+// Пустой origin - маршруты из всех городов отправки.
+func (r Repo) GetDelivereds(ctx context.Context, country, origin, date string) ([]Delivered, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	delivereds := make([]Delivered, 0)
+	rows, err := r.getDeliveredsStmt.QueryContext(ctx, country, origin, origin, date, date)
+	if err != nil {
+		return delivereds, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivered, err := scanDelivered(rows)
+		if err != nil {
+			return delivereds, err
+		}
+
+		delivereds = append(delivereds, delivered)
+	}
+	return delivereds, rows.Err()
+}
+
+// GetBrokerAmounts возвращает тарифы брокера страны, действующие на дату date.
+func (r Repo) GetBrokerAmounts(ctx context.Context, country, date string) ([]BrokerAmount, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	amounts := make([]BrokerAmount, 0)
+	rows, err := r.getBrokerAmountsStmt.QueryContext(ctx, country, date, date)
+	if err != nil {
+		return amounts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		amount, err := scanBrokerAmount(rows)
+		if err != nil {
+			return amounts, err
+		}
+
+		amounts = append(amounts, amount)
+	}
+	return amounts, rows.Err()
+}
+
+// GetSOSPrices возвращает цены кнопки SOS страны, действующие на дату date.
+func (r Repo) GetSOSPrices(ctx context.Context, country, date string) ([]SOSPrice, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	prices := make([]SOSPrice, 0)
+	rows, err := r.getSOSPricesStmt.QueryContext(ctx, country, date, date)
+	if err != nil {
+		return prices, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price, err := scanSOSPrice(rows)
+		if err != nil {
+			return prices, err
+		}
+
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// ListDelivereds возвращает все маршруты доставки, в том числе недействующие;
+// пустая страна - все страны.
+func (r Repo) ListDelivereds(ctx context.Context, country string) ([]Delivered, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	delivereds := make([]Delivered, 0)
+	rows, err := r.listDeliveredsStmt.QueryContext(ctx, country, country)
+	if err != nil {
+		return delivereds, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivered, err := scanDelivered(rows)
+		if err != nil {
+			return delivereds, err
+		}
+
+		delivereds = append(delivereds, delivered)
+	}
+	return delivereds, rows.Err()
+}
+
+func (r Repo) GetDelivered(ctx context.Context, id int64) (Delivered, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	delivered, err := scanDelivered(r.getDeliveredStmt.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivered, false, nil
+	}
+	if err != nil {
+		return delivered, false, err
+	}
+	return delivered, true, nil
+}
+
+func (r Repo) CreateDelivered(ctx context.Context, d Delivered) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createDeliveredStmt.QueryRowContext(ctx, d.Country, d.FromCity, d.ToCity, d.Carrier, d.Amount, d.Currency, d.TransitDays, d.ValidFrom, d.ValidTo).Scan(&id)
+	return id, err
+}
+
+func (r Repo) UpdateDelivered(ctx context.Context, d Delivered) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.updateDeliveredStmt.ExecContext(ctx, d.Country, d.FromCity, d.ToCity, d.Carrier, d.Amount, d.Currency, d.TransitDays, d.ValidFrom, d.ValidTo, d.ID)
+	return affected(res, err)
+}
+
+func (r Repo) DeleteDelivered(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.deleteDeliveredStmt.ExecContext(ctx, id)
+	return affected(res, err)
+}
+
+// ListBrokerAmounts возвращает все тарифы брокера; пустая страна - все страны.
+func (r Repo) ListBrokerAmounts(ctx context.Context, country string) ([]BrokerAmount, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	amounts := make([]BrokerAmount, 0)
+	rows, err := r.listBrokerAmountsStmt.QueryContext(ctx, country, country)
+	if err != nil {
+		return amounts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		amount, err := scanBrokerAmount(rows)
+		if err != nil {
+			return amounts, err
+		}
+
+		amounts = append(amounts, amount)
+	}
+	return amounts, rows.Err()
+}
+
+func (r Repo) GetBrokerAmount(ctx context.Context, id int64) (BrokerAmount, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	amount, err := scanBrokerAmount(r.getBrokerAmountStmt.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return amount, false, nil
+	}
+	if err != nil {
+		return amount, false, err
+	}
+	return amount, true, nil
+}
+
+func (r Repo) CreateBrokerAmount(ctx context.Context, b BrokerAmount) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createBrokerAmountStmt.QueryRowContext(ctx, b.Country, b.Name, b.Amount, b.ValidFrom, b.ValidTo).Scan(&id)
+	return id, err
+}
+
+func (r Repo) UpdateBrokerAmount(ctx context.Context, b BrokerAmount) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.updateBrokerAmountStmt.ExecContext(ctx, b.Country, b.Name, b.Amount, b.ValidFrom, b.ValidTo, b.ID)
+	return affected(res, err)
+}
+
+func (r Repo) DeleteBrokerAmount(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.deleteBrokerAmountStmt.ExecContext(ctx, id)
+	return affected(res, err)
+}
+
+// ListSOSPrices возвращает все цены кнопки SOS; пустая страна - все страны.
+func (r Repo) ListSOSPrices(ctx context.Context, country string) ([]SOSPrice, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	prices := make([]SOSPrice, 0)
+	rows, err := r.listSOSPricesStmt.QueryContext(ctx, country, country)
+	if err != nil {
+		return prices, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price, err := scanSOSPrice(rows)
+		if err != nil {
+			return prices, err
+		}
+
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+func (r Repo) GetSOSPrice(ctx context.Context, id int64) (SOSPrice, bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	price, err := scanSOSPrice(r.getSOSPriceStmt.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return price, false, nil
+	}
+	if err != nil {
+		return price, false, err
+	}
+	return price, true, nil
+}
+
+func (r Repo) CreateSOSPrice(ctx context.Context, p SOSPrice) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.createSOSPriceStmt.QueryRowContext(ctx, p.Country, p.Name, p.Amount, p.ValidFrom, p.ValidTo).Scan(&id)
+	return id, err
+}
+
+func (r Repo) UpdateSOSPrice(ctx context.Context, p SOSPrice) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.updateSOSPriceStmt.ExecContext(ctx, p.Country, p.Name, p.Amount, p.ValidFrom, p.ValidTo, p.ID)
+	return affected(res, err)
+}
+
+func (r Repo) DeleteSOSPrice(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.deleteSOSPriceStmt.ExecContext(ctx, id)
+	return affected(res, err)
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
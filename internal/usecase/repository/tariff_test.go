@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetDelivereds(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		exec(t, repo, "DELETE FROM delivered;")
+
+		sharjah := Delivered{Country: "kz", FromCity: "Шарджа", ToCity: "Алматы", Amount: 9000, Currency: "AED", Carrier: "Sea", TransitDays: 40}
+		for _, d := range []Delivered{
+			{Country: "kz", FromCity: "Дубай", ToCity: "Алматы", Amount: 2500, Currency: "USD"},
+			{Country: "kz", FromCity: "Дубай", ToCity: "Актау", Amount: 2000, Currency: "USD", ValidFrom: "2024-01-01", ValidTo: "2024-12-31"},
+			{Country: "kz", FromCity: "Дубай", ToCity: "Шымкент", Amount: 2600, Currency: "USD", ValidTo: "2023-12-31"},
+			{Country: "kz", FromCity: "Дубай", ToCity: "Астана", Amount: 2700, Currency: "USD", ValidFrom: "2025-01-01"},
+			sharjah,
+			{Country: "ru", FromCity: "Дубай", ToCity: "Астрахань", Amount: 2200, Currency: "USD"},
+		} {
+			id, err := repo.CreateDelivered(ctx, d)
+			if err != nil {
+				t.Fatalf("CreateDelivered: %v", err)
+			}
+			if d == sharjah {
+				sharjah.ID = id
+			}
+		}
+
+		tests := []struct {
+			name    string
+			country string
+			origin  string
+			date    string
+			want    []string
+		}{
+			{"valid on date, cheapest first", "kz", "", "2024-06-01", []string{"Актау", "Алматы", "Алматы"}},
+			{"window bounds are inclusive", "kz", "", "2024-12-31", []string{"Актау", "Алматы", "Алматы"}},
+			{"expired and future offers are skipped", "kz", "", "2025-01-01", []string{"Алматы", "Астана", "Алматы"}},
+			{"valid_to before window", "kz", "", "2023-12-31", []string{"Алматы", "Шымкент", "Алматы"}},
+			{"filter by origin", "kz", "Шарджа", "2024-06-01", []string{"Алматы"}},
+			{"unknown origin", "kz", "Абу-Даби", "2024-06-01", []string{}},
+			{"other country", "ru", "", "2024-06-01", []string{"Астрахань"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				delivereds, err := repo.GetDelivereds(ctx, tt.country, tt.origin, tt.date)
+				if err != nil {
+					t.Fatalf("GetDelivereds: %v", err)
+				}
+
+				got := make([]string, 0, len(delivereds))
+				for _, d := range delivereds {
+					got = append(got, d.ToCity)
+				}
+				if !equalStrings(got, tt.want) {
+					t.Errorf("GetDelivereds(%q, %q, %q) = %v, want %v", tt.country, tt.origin, tt.date, got, tt.want)
+				}
+			})
+		}
+
+		delivereds, err := repo.GetDelivereds(ctx, "kz", "Шарджа", "2024-06-01")
+		if err != nil {
+			t.Fatalf("GetDelivereds: %v", err)
+		}
+		if len(delivereds) != 1 || delivereds[0] != sharjah {
+			t.Errorf("GetDelivereds columns = %+v, want %+v", delivereds, sharjah)
+		}
+	})
+}
+
+func TestGetBrokerAmounts(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		exec(t, repo, "DELETE FROM broker_amount;")
+
+		for _, b := range []BrokerAmount{
+			{Country: "kz", Name: "Стандарт", Amount: 30000},
+			{Country: "kz", Name: "Эконом", Amount: 20000, ValidTo: "2024-03-31"},
+			{Country: "kz", Name: "Премиум", Amount: 40000, ValidFrom: "2024-04-01"},
+			{Country: "kg", Name: "Бишкек", Amount: 15000},
+		} {
+			if _, err := repo.CreateBrokerAmount(ctx, b); err != nil {
+				t.Fatalf("CreateBrokerAmount: %v", err)
+			}
+		}
+
+		tests := []struct {
+			date string
+			want []int
+		}{
+			{"2024-03-31", []int{20000, 30000}},
+			{"2024-04-01", []int{30000, 40000}},
+		}
+		for _, tt := range tests {
+			amounts, err := repo.GetBrokerAmounts(ctx, "kz", tt.date)
+			if err != nil {
+				t.Fatalf("GetBrokerAmounts: %v", err)
+			}
+
+			got := make([]int, 0, len(amounts))
+			for _, a := range amounts {
+				got = append(got, a.Amount)
+			}
+			if !equalInts(got, tt.want) {
+				t.Errorf("GetBrokerAmounts(kz, %s) = %v, want %v", tt.date, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestGetSOSPrices(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		exec(t, repo, "DELETE FROM sos_price;")
+		exec(t, repo, `INSERT INTO sos_price (country, name, amount, valid_from, valid_to) VALUES
+		('kz', 'Эра-Глонасс', 220000, '', ''),
+		('kz', 'Старая', 200000, '', '2024-01-31'),
+		('ru', 'Эра-Глонасс', 25000, '', '');`)
+
+		prices, err := repo.GetSOSPrices(ctx, "kz", "2024-02-01")
+		if err != nil {
+			t.Fatalf("GetSOSPrices: %v", err)
+		}
+		if len(prices) != 1 || prices[0].Name != "Эра-Глонасс" || prices[0].Amount != 220000 {
+			t.Errorf("GetSOSPrices = %+v, want only the current kz price", prices)
+		}
+	})
+}
+
+func TestDeliveredCRUD(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+
+		route := Delivered{Country: "kz", FromCity: "Дубай", ToCity: "Караганда", Carrier: "Auto", Amount: 2800, Currency: "USD", TransitDays: 25, ValidFrom: "2024-01-01"}
+		id, err := repo.CreateDelivered(ctx, route)
+		if err != nil {
+			t.Fatalf("CreateDelivered: %v", err)
+		}
+
+		got, ok, err := repo.GetDelivered(ctx, id)
+		route.ID = id
+		if err != nil || !ok || got != route {
+			t.Fatalf("GetDelivered = %+v, %v, %v, want %+v", got, ok, err, route)
+		}
+
+		route.Amount = 3000
+		route.ValidTo = "2024-12-31"
+		updated, err := repo.UpdateDelivered(ctx, route)
+		if err != nil || !updated {
+			t.Fatalf("UpdateDelivered = %v, %v", updated, err)
+		}
+		got, _, _ = repo.GetDelivered(ctx, id)
+		if got != route {
+			t.Errorf("after update GetDelivered = %+v, want %+v", got, route)
+		}
+
+		all, err := repo.ListDelivereds(ctx, "kz")
+		if err != nil {
+			t.Fatalf("ListDelivereds: %v", err)
+		}
+		if len(all) != 4 {
+			t.Errorf("ListDelivereds(kz) returned %d routes, want 3 seeded + 1 created", len(all))
+		}
+
+		deleted, err := repo.DeleteDelivered(ctx, id)
+		if err != nil || !deleted {
+			t.Fatalf("DeleteDelivered = %v, %v", deleted, err)
+		}
+		if _, ok, err := repo.GetDelivered(ctx, id); err != nil || ok {
+			t.Errorf("GetDelivered after delete = %v, %v, want not found", ok, err)
+		}
+		if deleted, err := repo.DeleteDelivered(ctx, id); err != nil || deleted {
+			t.Errorf("second DeleteDelivered = %v, %v, want false", deleted, err)
+		}
+		if updated, err := repo.UpdateDelivered(ctx, route); err != nil || updated {
+			t.Errorf("UpdateDelivered of deleted route = %v, %v, want false", updated, err)
+		}
+	})
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
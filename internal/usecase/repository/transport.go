@@ -9,23 +9,32 @@ type Mark struct {
 }
 
 type Model struct {
-	Name string `db:"model"`
+	Name    string `db:"model"`
+	Variant string `db:"variant"`
 }
 type Volume struct {
 	Value int `db:"volume"`
 }
 
 type Specification struct {
-	Year   int `db:"year"`
-	Amount int `db:"amount"`
+	Year            int     `db:"year"`
+	Amount          int     `db:"amount"`
+	EngineType      string  `db:"engine_type"`
+	BatteryCapacity float64 `db:"battery_capacity"`
+	Power           int     `db:"power"`
+	VehicleClass    string  `db:"vehicle_class"`
 }
 
 func (r Repo) GetMarks(ctx context.Context) ([]Mark, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	marks := make([]Mark, 0)
-	rows, err := r.getMarksStmt.Query()
+	rows, err := r.getMarksStmt.QueryContext(ctx)
 	if err != nil {
 		return marks, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		mark := Mark{}
@@ -36,35 +45,43 @@ func (r Repo) GetMarks(ctx context.Context) ([]Mark, error) {
 
 		marks = append(marks, mark)
 	}
-
-	return marks, nil
+	return marks, rows.Err()
 }
 
+// GetModels возвращает пары каноническая модель + написание из файла КГД.
 func (r Repo) GetModels(ctx context.Context, mark string) ([]Model, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	models := make([]Model, 0)
-	rows, err := r.getModelsStmt.Query(mark)
+	rows, err := r.getModelsStmt.QueryContext(ctx, mark)
 	if err != nil {
 		return models, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		model := Model{}
-		err := rows.Scan(&model.Name)
+		err := rows.Scan(&model.Name, &model.Variant)
 		if err != nil {
 			return models, err
 		}
 
 		models = append(models, model)
 	}
-	return models, nil
+	return models, rows.Err()
 }
 
 func (r Repo) GetVolumes(ctx context.Context, mark, model string) ([]Volume, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	volumes := make([]Volume, 0)
-	rows, err := r.getVolumesStmt.Query(mark, model)
+	rows, err := r.getVolumesStmt.QueryContext(ctx, mark, model)
 	if err != nil {
 		return volumes, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		volume := Volume{}
@@ -75,70 +92,125 @@ func (r Repo) GetVolumes(ctx context.Context, mark, model string) ([]Volume, err
 
 		volumes = append(volumes, volume)
 	}
-	return volumes, nil
+	return volumes, rows.Err()
 }
 
+// GetSpecifications возвращает годы объема по убыванию. После объединения
+// написаний на один год и тип двигателя может прийтись несколько строк:
+// остается строка канонического написания, а без нее - первого по алфавиту.
 func (r Repo) GetSpecifications(ctx context.Context, mark, model string, volume int) ([]Specification, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	specifications := make([]Specification, 0)
-	rows, err := r.getSpecificationsStmt.Query(mark, model, volume)
+	rows, err := r.getSpecificationsStmt.QueryContext(ctx, mark, model, volume)
 	if err != nil {
 		return specifications, err
 	}
+	defer rows.Close()
 
+	type yearKey struct {
+		year       int
+		engineType string
+	}
+	seen := make(map[yearKey]bool)
 	for rows.Next() {
 		specification := Specification{}
-		err := rows.Scan(&specification.Year, &specification.Amount)
+		err := rows.Scan(&specification.Year, &specification.Amount, &specification.EngineType, &specification.BatteryCapacity, &specification.Power, &specification.VehicleClass)
 		if err != nil {
 			return specifications, err
 		}
 
+		key := yearKey{year: specification.Year, engineType: specification.EngineType}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
 		specifications = append(specifications, specification)
 	}
-	return specifications, nil
+	return specifications, rows.Err()
 }
 
-type Delivered struct {
-	FromCity string
-	ToCity   string
-	Amount   int
+type Data struct {
+	ID           int    `db:"id"`
+	Mark         string `db:"mark"`
+	Model        string `db:"model"`
+	Volume       int    `db:"volume"`
+	Year         int    `db:"year"`
+	Amount       int    `db:"amount"`
+	EngineType   string `db:"engine_type"`
+	VehicleClass string `db:"vehicle_class"`
 }
 
-func (r Repo) GetDelivereds(ctx context.Context, country string) ([]Delivered, error) {
-	delivereds := make([]Delivered, 0)
-	rows, err := r.db.Query("SELECT from_city, to_city, amount FROM delivered FROM country = ?", country)
+// DataFilter - разобранный свободный запрос: Terms - слова марки и модели,
+// Year и Volume влияют только на сортировку, чтобы ближайшие по году и объему
+// строки оказывались выше.
+type DataFilter struct {
+	Terms  []string
+	Year   int
+	Volume int
+}
+
+// GetDataRows возвращает строки КГД по марке, модели и диапазону лет.
+// Пустые строки и нулевые годы означают отсутствие фильтра.
+func (r Repo) GetDataRows(ctx context.Context, mark, model string, yearFrom, yearTo int) ([]Data, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	data := make([]Data, 0)
+	rows, err := r.getDataRowsStmt.QueryContext(ctx, mark, mark, model, model, yearFrom, yearFrom, yearTo, yearTo)
 	if err != nil {
-		return delivereds, err
+		return data, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		delivered := Delivered{}
-		err := rows.Scan(&delivered.FromCity, &delivered.ToCity, &delivered.Amount)
+		d := Data{}
+		err := rows.Scan(&d.ID, &d.Mark, &d.Model, &d.Volume, &d.Year, &d.Amount, &d.EngineType, &d.VehicleClass)
 		if err != nil {
-			return delivereds, err
+			return data, err
 		}
 
-		delivereds = append(delivereds, delivered)
+		data = append(data, d)
 	}
+	return data, rows.Err()
+}
 
-	return delivereds, nil
+// CatalogRow - строка КГД для справочника в памяти.
+type CatalogRow struct {
+	Mark            string  `db:"mark"`
+	Model           string  `db:"model"`
+	Variant         string  `db:"variant"`
+	Volume          int     `db:"volume"`
+	Year            int     `db:"year"`
+	Amount          int     `db:"amount"`
+	EngineType      string  `db:"engine_type"`
+	BatteryCapacity float64 `db:"battery_capacity"`
+	Power           int     `db:"power"`
+	VehicleClass    string  `db:"vehicle_class"`
 }
 
-func (r Repo) GetBrokerAmounts(ctx context.Context, country string) ([]int, error) {
-	amounts := make([]int, 0)
-	rows, err := r.db.Query("SELECT amount FROM broker_amount FROM country = ?", country)
+// GetCatalogRows возвращает весь справочник КГД по марке, модели, написанию и
+// объему, внутри объема новые годы сверху.
+func (r Repo) GetCatalogRows(ctx context.Context) ([]CatalogRow, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	catalog := make([]CatalogRow, 0)
+	rows, err := r.getCatalogRowsStmt.QueryContext(ctx)
 	if err != nil {
-		return amounts, err
+		return catalog, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var amount int
-		err := rows.Scan(&amount)
+		c := CatalogRow{}
+		err := rows.Scan(&c.Mark, &c.Model, &c.Variant, &c.Volume, &c.Year, &c.Amount, &c.EngineType, &c.BatteryCapacity, &c.Power, &c.VehicleClass)
 		if err != nil {
-			return amounts, err
+			return catalog, err
 		}
 
-		amounts = append(amounts, amount)
+		catalog = append(catalog, c)
 	}
-
-	return amounts, nil
+	return catalog, rows.Err()
 }
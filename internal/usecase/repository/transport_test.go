@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// seedData заполняет справочник КГД небольшим набором строк.
+func seedData(t *testing.T, repo Repo) {
+	t.Helper()
+	exec(t, repo, "DELETE FROM data;")
+	exec(t, repo, `INSERT INTO data (id, mark, model, variant, volume, year, amount) VALUES
+		(1, 'TOYOTA', 'CAMRY', 'CAMRY', 2500, 2020, 20000),
+		(2, 'TOYOTA', 'CAMRY', 'CAMRY', 2500, 2022, 25000),
+		(3, 'TOYOTA', 'CAMRY', 'CAMRY', 3500, 2022, 30000),
+		(4, 'TOYOTA', 'LAND CRUISER 200', 'LC200', 4600, 2015, 40000),
+		(5, 'TOYOTA', 'LAND CRUISER 200', 'LAND CRUISER 200', 4600, 2018, 50000),
+		(6, 'HYUNDAI', 'SONATA', 'SONATA', 2000, 2021, 18000),
+		(7, 'ACURA', 'MDX', 'MDX', 3500, 2019, 35000);`)
+}
+
+func TestCatalog(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		seedData(t, repo)
+
+		marks, err := repo.GetMarks(ctx)
+		if err != nil {
+			t.Fatalf("GetMarks: %v", err)
+		}
+		// TOYOTA и HYUNDAI подняты весами из миграции, остальные - по алфавиту
+		if want := []Mark{{"TOYOTA"}, {"HYUNDAI"}, {"ACURA"}}; !reflect.DeepEqual(marks, want) {
+			t.Errorf("GetMarks = %v, want %v", marks, want)
+		}
+
+		models, err := repo.GetModels(ctx, "TOYOTA")
+		if err != nil {
+			t.Fatalf("GetModels: %v", err)
+		}
+		wantModels := []Model{{"CAMRY", "CAMRY"}, {"LAND CRUISER 200", "LAND CRUISER 200"}, {"LAND CRUISER 200", "LC200"}}
+		if !reflect.DeepEqual(models, wantModels) {
+			t.Errorf("GetModels = %v, want %v", models, wantModels)
+		}
+
+		volumes, err := repo.GetVolumes(ctx, "TOYOTA", "CAMRY")
+		if err != nil {
+			t.Fatalf("GetVolumes: %v", err)
+		}
+		if want := []Volume{{2500}, {3500}}; !reflect.DeepEqual(volumes, want) {
+			t.Errorf("GetVolumes = %v, want %v", volumes, want)
+		}
+
+		specifications, err := repo.GetSpecifications(ctx, "TOYOTA", "CAMRY", 2500)
+		if err != nil {
+			t.Fatalf("GetSpecifications: %v", err)
+		}
+		if want := []Specification{{2022, 25000, "ice", 0, 0, "M1"}, {2020, 20000, "ice", 0, 0, "M1"}}; !reflect.DeepEqual(specifications, want) {
+			t.Errorf("GetSpecifications = %v, want %v", specifications, want)
+		}
+
+		catalog, err := repo.GetCatalogRows(ctx)
+		if err != nil {
+			t.Fatalf("GetCatalogRows: %v", err)
+		}
+		if len(catalog) != 7 {
+			t.Fatalf("GetCatalogRows returned %d rows, want 7", len(catalog))
+		}
+		// по марке, модели и написанию, внутри объема новые годы сверху
+		if want := (CatalogRow{"TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000, "ice", 0, 0, "M1"}); catalog[2] != want {
+			t.Errorf("GetCatalogRows[2] = %v, want %v", catalog[2], want)
+		}
+		if want := (CatalogRow{"TOYOTA", "LAND CRUISER 200", "LAND CRUISER 200", 4600, 2018, 50000, "ice", 0, 0, "M1"}); catalog[5] != want {
+			t.Errorf("GetCatalogRows[5] = %v, want %v", catalog[5], want)
+		}
+
+		// у электромобиля объем нулевой, тип двигателя и батарея - из отдельных колонок
+		exec(t, repo, `INSERT INTO data (id, mark, model, variant, volume, year, amount, engine_type, battery_capacity, power) VALUES
+			(8, 'HYUNDAI', 'IONIQ 5', 'IONIQ 5', 0, 2023, 45000, 'ev', 77.4, 239);`)
+		specifications, err = repo.GetSpecifications(ctx, "HYUNDAI", "IONIQ 5", 0)
+		if err != nil {
+			t.Fatalf("GetSpecifications: %v", err)
+		}
+		if want := []Specification{{2023, 45000, "ev", 77.4, 239, "M1"}}; !reflect.DeepEqual(specifications, want) {
+			t.Errorf("GetSpecifications(IONIQ 5) = %v, want %v", specifications, want)
+		}
+
+		// пикап из грузового списка
+		exec(t, repo, `INSERT INTO data (id, mark, model, variant, volume, year, amount, vehicle_class) VALUES
+			(9, 'TOYOTA', 'HILUX', 'HILUX', 2800, 2021, 30000, 'N1');`)
+		specifications, err = repo.GetSpecifications(ctx, "TOYOTA", "HILUX", 2800)
+		if err != nil {
+			t.Fatalf("GetSpecifications: %v", err)
+		}
+		if want := []Specification{{2021, 30000, "ice", 0, 0, "N1"}}; !reflect.DeepEqual(specifications, want) {
+			t.Errorf("GetSpecifications(HILUX) = %v, want %v", specifications, want)
+		}
+	})
+}
+
+func TestGetDataRows(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		seedData(t, repo)
+
+		tests := []struct {
+			mark, model      string
+			yearFrom, yearTo int
+			want             int
+		}{
+			{"", "", 0, 0, 7},
+			{"TOYOTA", "", 0, 0, 5},
+			{"TOYOTA", "CAMRY", 2021, 0, 2},
+			{"", "", 2019, 2020, 2},
+			{"KIA", "", 0, 0, 0},
+		}
+		for _, tt := range tests {
+			rows, err := repo.GetDataRows(ctx, tt.mark, tt.model, tt.yearFrom, tt.yearTo)
+			if err != nil {
+				t.Fatalf("GetDataRows: %v", err)
+			}
+			if len(rows) != tt.want {
+				t.Errorf("GetDataRows(%q, %q, %d, %d) returned %d rows, want %d", tt.mark, tt.model, tt.yearFrom, tt.yearTo, len(rows), tt.want)
+			}
+		}
+	})
+}
+
+func TestMergeModels(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo Repo) {
+		ctx := context.Background()
+		seedData(t, repo)
+		exec(t, repo, "INSERT INTO data (id, mark, model, variant, volume, year, amount) VALUES (8, 'HYUNDAI', 'SONATA NEW', 'SONATA NEW', 2000, 2023, 22000), (9, 'HYUNDAI', 'SONATA NEW', 'SONATA NEW', 2000, 2021, 19000);")
+
+		updated, err := repo.MergeModels(ctx, "HYUNDAI", "SONATA", []string{"SONATA NEW"})
+		if err != nil {
+			t.Fatalf("MergeModels: %v", err)
+		}
+		if updated != 2 {
+			t.Errorf("MergeModels updated %d rows, want 2", updated)
+		}
+		models, err := repo.GetModels(ctx, "HYUNDAI")
+		if err != nil {
+			t.Fatalf("GetModels: %v", err)
+		}
+		if want := []Model{{"SONATA", "SONATA"}, {"SONATA", "SONATA NEW"}}; !reflect.DeepEqual(models, want) {
+			t.Errorf("GetModels after merge = %v, want %v", models, want)
+		}
+		// 2021 есть у обоих написаний: остается сумма канонического
+		specifications, err := repo.GetSpecifications(ctx, "HYUNDAI", "SONATA", 2000)
+		if err != nil {
+			t.Fatalf("GetSpecifications: %v", err)
+		}
+		years := make([][2]int, 0, len(specifications))
+		for _, s := range specifications {
+			years = append(years, [2]int{s.Year, s.Amount})
+		}
+		if want := [][2]int{{2023, 22000}, {2021, 18000}}; !reflect.DeepEqual(years, want) {
+			t.Errorf("GetSpecifications after merge = %v, want %v", years, want)
+		}
+
+		deleted, err := repo.DeleteModelAlias(ctx, "HYUNDAI", "SONATA NEW")
+		if err != nil || !deleted {
+			t.Fatalf("DeleteModelAlias = %v, %v", deleted, err)
+		}
+		rows, err := repo.GetDataRows(ctx, "HYUNDAI", "SONATA NEW", 0, 0)
+		if err != nil || len(rows) != 2 {
+			t.Errorf("GetDataRows after unmerge = %v, %v, want the rows back", rows, err)
+		}
+	})
+}
@@ -0,0 +1,410 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// searchNameLimit - сколько лучших пар марка+модель раскрывается в строки КГД
+	searchNameLimit = 10
+	// searchIndexLimit - сколько кандидатов берется из полнотекстового индекса
+	searchIndexLimit = 50
+	minSearchScore   = 0.5
+)
+
+// SearchCandidate - строка КГД, найденная по свободному запросу; Score от 0
+// до 1.1, чем больше, тем точнее совпали марка и модель.
+type SearchCandidate struct {
+	Mark         string
+	Model        string
+	Volume       int
+	Year         int
+	Amount       int
+	EngineType   string
+	VehicleClass string
+	Score        float64
+}
+
+// RebuildSearchIndex пересобирает полнотекстовый индекс по справочнику КГД.
+// Вызывается после загрузки данных; без fts5 ничего не делает.
+func (u UseCase) RebuildSearchIndex(ctx context.Context) error {
+	if !u.repo.SearchIndexEnabled() {
+		return nil
+	}
+
+	names, err := u.repo.GetVehicleNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	documents := make([]repository.SearchDocument, 0, len(names))
+	for _, name := range names {
+		latin := transliterate(name.Mark + " " + name.Model)
+		sounds := make([]string, 0)
+		for _, word := range splitSearchWords(latin) {
+			sounds = append(sounds, soundKey(word))
+		}
+		documents = append(documents, repository.SearchDocument{
+			VehicleName: name,
+			Latin:       latin,
+			Sound:       strings.Join(sounds, " "),
+		})
+	}
+	return u.repo.ReplaceSearchIndex(ctx, documents)
+}
+
+// Search ищет авто по свободному запросу вида "ленд крузер 2015 4.6" или
+// "LC200". Кириллица переводится в латиницу, синонимы из search_alias
+// раскрываются, опечатки прощаются по расстоянию Левенштейна и фонетическому
+// ключу. Год и объем из запроса только поднимают ближайшие строки выше.
+func (u UseCase) Search(ctx context.Context, query string, limit int) ([]SearchCandidate, error) {
+	if limit < 1 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	candidates := make([]SearchCandidate, 0)
+	aliases, err := u.repo.GetSearchAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	terms, filter := parseSearchQuery(query, aliases)
+	if len(terms) == 0 {
+		return candidates, nil
+	}
+
+	names, err := u.searchNames(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		rows, err := u.repo.GetDataRows(ctx, name.Mark, name.Model, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			candidates = append(candidates, SearchCandidate{
+				Mark:         r.Mark,
+				Model:        r.Model,
+				Volume:       r.Volume,
+				Year:         r.Year,
+				Amount:       r.Amount,
+				EngineType:   engineTypeOrICE(r.EngineType),
+				VehicleClass: vehicleClassOrM1(r.VehicleClass),
+				Score:        name.score,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if filter.Year != 0 && abs(a.Year-filter.Year) != abs(b.Year-filter.Year) {
+			return abs(a.Year-filter.Year) < abs(b.Year-filter.Year)
+		}
+		if filter.Volume != 0 && abs(a.Volume-filter.Volume) != abs(b.Volume-filter.Volume) {
+			return abs(a.Volume-filter.Volume) < abs(b.Volume-filter.Volume)
+		}
+		if a.Year != b.Year {
+			return a.Year > b.Year
+		}
+		return a.Volume < b.Volume
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+type scoredName struct {
+	repository.VehicleName
+	score float64
+}
+
+// searchNames сначала берет кандидатов из полнотекстового индекса, а если
+// там нет ни одного уверенного совпадения (опечатка, нет fts5), перебирает
+// весь справочник.
+func (u UseCase) searchNames(ctx context.Context, terms []searchTerm) ([]scoredName, error) {
+	var names []repository.VehicleName
+	if match := searchMatch(terms); match != "" && u.repo.SearchIndexEnabled() {
+		hits, err := u.repo.SearchIndex(ctx, match, searchIndexLimit)
+		if err != nil {
+			return nil, fmt.Errorf("SearchIndex -> %v", err)
+		}
+		names = hits
+	}
+
+	scored := scoreNames(terms, names)
+	if len(scored) == 0 {
+		all, err := u.repo.GetVehicleNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		scored = scoreNames(terms, all)
+	}
+	if len(scored) > searchNameLimit {
+		scored = scored[:searchNameLimit]
+	}
+	return scored, nil
+}
+
+func scoreNames(terms []searchTerm, names []repository.VehicleName) []scoredName {
+	scored := make([]scoredName, 0)
+	for _, name := range names {
+		score := scoreName(terms, name)
+		if score < minSearchScore {
+			continue
+		}
+		scored = append(scored, scoredName{VehicleName: name, score: score})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		if scored[i].Mark != scored[j].Mark {
+			return scored[i].Mark < scored[j].Mark
+		}
+		return scored[i].Model < scored[j].Model
+	})
+	return scored
+}
+
+// searchTerm - слово запроса латиницей и его фонетический ключ.
+type searchTerm struct {
+	latin string
+	sound string
+}
+
+func newSearchTerm(word string) searchTerm {
+	latin := transliterate(word)
+	return searchTerm{latin: latin, sound: soundKey(latin)}
+}
+
+// parseSearchQuery раскрывает синонимы (в том числе из двух слов, "LC 200")
+// и отделяет год и объем так же, как быстрый поиск в боте.
+func parseSearchQuery(query string, aliases map[string]string) ([]searchTerm, repository.DataFilter) {
+	tokens := quoteSeparators.Split(strings.TrimSpace(query), -1)
+	words := make([]string, 0, len(tokens))
+	rest := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == "" {
+			continue
+		}
+		if i+1 < len(tokens) {
+			if replacement, ok := aliases[aliasKey(tokens[i]+tokens[i+1])]; ok {
+				words = append(words, splitSearchWords(replacement)...)
+				i++
+				continue
+			}
+		}
+		if replacement, ok := aliases[aliasKey(tokens[i])]; ok {
+			words = append(words, splitSearchWords(replacement)...)
+			continue
+		}
+		rest = append(rest, tokens[i])
+	}
+
+	filter := parseQuoteQuery(strings.Join(rest, " "))
+	words = append(words, filter.Terms...)
+
+	terms := make([]searchTerm, 0, len(words))
+	for _, word := range words {
+		if term := newSearchTerm(word); term.latin != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms, filter
+}
+
+func aliasKey(s string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(s))
+}
+
+func splitSearchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '/' || r == '.' || r == ','
+	})
+}
+
+// searchMatch собирает выражение FTS5 MATCH: любое из слов или их фонетических
+// ключей. Индекс на триграммах, поэтому слова короче трех букв пропускаются.
+func searchMatch(terms []searchTerm) string {
+	parts := make([]string, 0, len(terms)*2)
+	seen := make(map[string]bool)
+	for _, t := range terms {
+		for _, v := range []string{t.latin, t.sound} {
+			if len([]rune(v)) < 3 || seen[v] {
+				continue
+			}
+			seen[v] = true
+			parts = append(parts, `"`+strings.ReplaceAll(v, `"`, `""`)+`"`)
+		}
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// scoreName оценивает, насколько пара марка+модель подходит под запрос:
+// среднее по словам запроса плюс небольшой бонус, если в названии нет лишних
+// слов, чтобы LAND CRUISER был выше LAND CRUISER PRADO.
+func scoreName(terms []searchTerm, name repository.VehicleName) float64 {
+	words := make([]searchTerm, 0)
+	for _, word := range splitSearchWords(transliterate(name.Mark + " " + name.Model)) {
+		words = append(words, searchTerm{latin: word, sound: soundKey(word)})
+	}
+	if len(words) == 0 {
+		return 0
+	}
+	real := len(words)
+	// слитное написание модели: RAV4, LANDCRUISER
+	if compact := aliasKey(transliterate(name.Model)); compact != "" {
+		words = append(words, searchTerm{latin: compact, sound: soundKey(compact)})
+	}
+
+	total := 0.0
+	matched := make(map[int]bool)
+	for _, t := range terms {
+		best, bestWord := 0.0, -1
+		for i, w := range words {
+			if s := termScore(t, w); s > best {
+				best, bestWord = s, i
+			}
+		}
+		total += best
+		if bestWord >= 0 && bestWord < real {
+			matched[bestWord] = true
+		}
+	}
+	return total/float64(len(terms)) + 0.1*float64(len(matched))/float64(real)
+}
+
+func termScore(t, w searchTerm) float64 {
+	switch {
+	case t.latin == w.latin:
+		return 1
+	case len(t.latin) >= 2 && strings.HasPrefix(w.latin, t.latin):
+		return 0.9
+	case len(t.sound) >= 2 && t.sound == w.sound:
+		return 0.8
+	case len(t.latin) >= 3 && strings.Contains(w.latin, t.latin):
+		return 0.75
+	}
+
+	allowed := 0
+	switch n := len(t.latin); {
+	case n > 6:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	}
+	if d := levenshtein(t.latin, w.latin); d <= allowed {
+		return 0.7 - 0.1*float64(d)
+	}
+	return 0
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+var cyrillicToLatin = map[rune]string{
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "E",
+	'Ж': "ZH", 'З': "Z", 'И': "I", 'Й': "Y", 'К': "K", 'Л': "L", 'М': "M",
+	'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U",
+	'Ф': "F", 'Х': "KH", 'Ц': "TS", 'Ч': "CH", 'Ш': "SH", 'Щ': "SCH", 'Ъ': "",
+	'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "YU", 'Я': "YA",
+	// казахские буквы
+	'Ә': "A", 'Ғ': "G", 'Қ': "K", 'Ң': "N", 'Ө': "O", 'Ұ': "U", 'Ү': "U",
+	'Һ': "H", 'І': "I",
+}
+
+// transliterate переводит строку в верхний регистр латиницей, чтобы и запрос,
+// и справочник сравнивались в одном алфавите.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var soundReplacer = strings.NewReplacer(
+	"DZH", "J", "SCH", "S", "SH", "S", "CH", "K", "KH", "K", "ZH", "J",
+	"PH", "F", "TS", "S", "CK", "K", "X", "KS",
+)
+
+// soundKey - упрощенный фонетический ключ латинского слова: похожие по звуку
+// согласные склеены, гласные после первой буквы выброшены. "KRUZER" и
+// "CRUISER" дают один ключ KRSR, "LEND" и "LAND" - LND.
+func soundKey(word string) string {
+	runes := []rune(soundReplacer.Replace(word))
+	var b strings.Builder
+	var last rune
+	for i, r := range runes {
+		switch r {
+		case 'C':
+			r = 'K'
+			if i+1 < len(runes) && strings.ContainsRune("EIY", runes[i+1]) {
+				r = 'S'
+			}
+		case 'Q':
+			r = 'K'
+		case 'Z':
+			r = 'S'
+		case 'W':
+			r = 'V'
+		case 'Y', 'J':
+			r = 'I'
+		case 'H':
+			continue
+		}
+		if i > 0 && strings.ContainsRune("AEIOU", r) {
+			continue
+		}
+		if r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ленд крузер", "LEND KRUZER"},
+		{"Тойота Камри", "TOYOTA KAMRI"},
+		{"Хёндай", "KHENDAY"},
+		{"Қамри", "KAMRI"},
+		{"LC200", "LC200"},
+		{"lc 200", "LC 200"},
+	}
+	for _, tt := range tests {
+		if got := transliterate(tt.in); got != tt.want {
+			t.Errorf("transliterate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSoundKey(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"KRUZER", "KRSR"},
+		{"CRUISER", "KRSR"},
+		{"LEND", "LND"},
+		{"LAND", "LND"},
+		{"KAMRI", "KMR"},
+		{"CAMRY", "KMR"},
+		{"SHEVROLE", "SVRL"},
+		{"200", "20"},
+	}
+	for _, tt := range tests {
+		if got := soundKey(tt.in); got != tt.want {
+			t.Errorf("soundKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"AB", "", 2},
+		{"CAMRY", "CAMRY", 0},
+		{"CAMRY", "CAMRI", 1},
+		{"CRUSER", "CRUISER", 1},
+		{"KITTEN", "SITTING", 3},
+		{"крузер", "кузер", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTermScore(t *testing.T) {
+	tests := []struct {
+		term, word string
+		want       float64
+	}{
+		{"LAND", "LAND", 1},
+		{"LAN", "LAND", 0.9},
+		{"кружер", "CRUISER", 0},
+		{"крузер", "CRUISER", 0.8},
+		{"ленд", "LAND", 0.8},
+		{"RUISER", "CRUISER", 0.75},
+		{"TOYOTS", "TOYOTA", 0.6},
+		{"PATHFIDNER", "PATHFINDER", 0.5},
+		{"BMW", "AUDI", 0},
+		// короткие слова опечаток не прощают
+		{"RAV", "RAV4", 0.9},
+		{"RAB", "RAV", 0},
+	}
+	for _, tt := range tests {
+		got := termScore(newSearchTerm(tt.term), newSearchTerm(tt.word))
+		if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("termScore(%q, %q) = %v, want %v", tt.term, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	aliases := map[string]string{
+		"LC200": "TOYOTA LAND CRUISER 200",
+		"LCP":   "TOYOTA LAND CRUISER PRADO",
+	}
+	tests := []struct {
+		query  string
+		terms  []string
+		year   int
+		volume int
+	}{
+		{"ленд крузер 2015 4.6", []string{"LEND", "KRUZER"}, 2015, 4600},
+		{"LC200", []string{"TOYOTA", "LAND", "CRUISER", "200"}, 0, 0},
+		{"lc 200 2018", []string{"TOYOTA", "LAND", "CRUISER", "200"}, 2018, 0},
+		{"lcp 2700", []string{"TOYOTA", "LAND", "CRUISER", "PRADO"}, 0, 2700},
+		{"камри 2,5", []string{"KAMRI"}, 0, 2500},
+		{"2020", []string{}, 2020, 0},
+	}
+	for _, tt := range tests {
+		terms, filter := parseSearchQuery(tt.query, aliases)
+		latin := make([]string, 0, len(terms))
+		for _, term := range terms {
+			latin = append(latin, term.latin)
+		}
+		if !reflect.DeepEqual(latin, tt.terms) || filter.Year != tt.year || filter.Volume != tt.volume {
+			t.Errorf("parseSearchQuery(%q) = %v, year %d, volume %d, want %v, year %d, volume %d",
+				tt.query, latin, filter.Year, filter.Volume, tt.terms, tt.year, tt.volume)
+		}
+	}
+}
+
+func TestSearchMatch(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"ленд", "крузер"}, `"LEND" OR "LND" OR "KRUZER" OR "KRSR"`},
+		{[]string{"LAND", "LAND"}, `"LAND" OR "LND"`},
+		// короче трех букв триграммный индекс не ищет
+		{[]string{"LC"}, ""},
+		{[]string{`RAV"4`}, `"RAV""4" OR "RV""4"`},
+	}
+	for _, tt := range tests {
+		terms := make([]searchTerm, 0, len(tt.words))
+		for _, word := range tt.words {
+			terms = append(terms, newSearchTerm(word))
+		}
+		if got := searchMatch(terms); got != tt.want {
+			t.Errorf("searchMatch(%q) = %s, want %s", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2020, 20000),
+		catalogRow("TOYOTA", "LAND CRUISER 200", "LAND CRUISER 200", 4600, 2015, 40000),
+		catalogRow("TOYOTA", "LAND CRUISER 200", "LAND CRUISER 200", 4600, 2018, 50000),
+		catalogRow("TOYOTA", "LAND CRUISER PRADO", "LAND CRUISER PRADO", 2700, 2018, 30000),
+		catalogRow("HYUNDAI", "SONATA", "SONATA", 2000, 2021, 18000),
+	}
+	store.searchAliases = map[string]string{"LC200": "TOYOTA LAND CRUISER 200"}
+	u := newTestUseCase(store, newFakeRates())
+	ctx := context.Background()
+
+	tests := []struct {
+		query string
+		model string
+		year  int
+	}{
+		{"ленд крузер 2015", "LAND CRUISER 200", 2015},
+		{"LC200", "LAND CRUISER 200", 2018},
+		{"lc 200 2015", "LAND CRUISER 200", 2015},
+		{"ленд крузер прадо", "LAND CRUISER PRADO", 2018},
+		// опечатки
+		{"тайота камри", "CAMRY", 2020},
+		{"toyta camyr", "CAMRY", 2020},
+		{"hyundai sonta", "SONATA", 2021},
+	}
+	for _, tt := range tests {
+		candidates, err := u.Search(ctx, tt.query, 0)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		if len(candidates) == 0 || candidates[0].Model != tt.model || candidates[0].Year != tt.year {
+			t.Errorf("Search(%q) = %+v, want %s %d first", tt.query, candidates, tt.model, tt.year)
+		}
+	}
+
+	candidates, err := u.Search(ctx, "lamborghini", 0)
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("Search(lamborghini) = %+v, %v, want nothing", candidates, err)
+	}
+}
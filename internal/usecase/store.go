@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// Store - хранилище, с которым работает бизнес-логика. Его реализует
+// repository.Repo, в тестах - фейк в памяти.
+type Store interface {
+	GetMarks(ctx context.Context) ([]repository.Mark, error)
+	GetModels(ctx context.Context, mark string) ([]repository.Model, error)
+	GetVolumes(ctx context.Context, mark, model string) ([]repository.Volume, error)
+	GetSpecifications(ctx context.Context, mark, model string, volume int) ([]repository.Specification, error)
+	GetDataRows(ctx context.Context, mark, model string, yearFrom, yearTo int) ([]repository.Data, error)
+	GetCatalogRows(ctx context.Context) ([]repository.CatalogRow, error)
+	GetLastImportID(ctx context.Context) (int64, error)
+	GetValuationHistory(ctx context.Context, mark, model string, volume, year int) ([]repository.Valuation, error)
+
+	SearchIndexEnabled() bool
+	ReplaceSearchIndex(ctx context.Context, documents []repository.SearchDocument) error
+	SearchIndex(ctx context.Context, match string, limit int) ([]repository.VehicleName, error)
+	GetVehicleNames(ctx context.Context) ([]repository.VehicleName, error)
+	GetSearchAliases(ctx context.Context) (map[string]string, error)
+
+	GetModelAliases(ctx context.Context, mark string) ([]repository.ModelAlias, error)
+	MergeModels(ctx context.Context, mark, model string, aliases []string) (int64, error)
+	DeleteModelAlias(ctx context.Context, mark, alias string) (bool, error)
+
+	GetPopularity(ctx context.Context, mark string) ([]repository.Popularity, error)
+	SetPopularity(ctx context.Context, mark, model string, weight int) error
+	DeletePopularity(ctx context.Context, mark, model string) (bool, error)
+	RecalculatePopularity(ctx context.Context) (int64, error)
+
+	CreateSubscription(ctx context.Context, s repository.Subscription) (int64, error)
+	GetSubscriptions(ctx context.Context, chatID int64) ([]repository.Subscription, error)
+	UpdateSubscriptionAmount(ctx context.Context, id int64, amount int) error
+	DeleteSubscription(ctx context.Context, chatID, id int64) (bool, error)
+
+	SaveExchangeRate(ctx context.Context, rate repository.ExchangeRate) error
+	GetPreviousExchangeRate(ctx context.Context, date string) (repository.ExchangeRate, bool, error)
+
+	CreateLead(ctx context.Context, l repository.Lead) (int64, error)
+	GetLead(ctx context.Context, id int64) (repository.Lead, bool, error)
+	GetLeads(ctx context.Context, status string, limit, offset int) ([]repository.Lead, error)
+	CountLeads(ctx context.Context, status string) (int, error)
+	UpdateLeadStatus(ctx context.Context, id int64, status string) error
+
+	CreateAssessment(ctx context.Context, a repository.Assessment) (bool, error)
+	GetAssessment(ctx context.Context, id string) (repository.Assessment, bool, error)
+
+	GetDelivereds(ctx context.Context, country, origin, date string) ([]repository.Delivered, error)
+	GetBrokerAmounts(ctx context.Context, country, date string) ([]repository.BrokerAmount, error)
+	GetSOSPrices(ctx context.Context, country, date string) ([]repository.SOSPrice, error)
+	ListDelivereds(ctx context.Context, country string) ([]repository.Delivered, error)
+	GetDelivered(ctx context.Context, id int64) (repository.Delivered, bool, error)
+	CreateDelivered(ctx context.Context, d repository.Delivered) (int64, error)
+	UpdateDelivered(ctx context.Context, d repository.Delivered) (bool, error)
+	DeleteDelivered(ctx context.Context, id int64) (bool, error)
+	ListBrokerAmounts(ctx context.Context, country string) ([]repository.BrokerAmount, error)
+	GetBrokerAmount(ctx context.Context, id int64) (repository.BrokerAmount, bool, error)
+	CreateBrokerAmount(ctx context.Context, b repository.BrokerAmount) (int64, error)
+	UpdateBrokerAmount(ctx context.Context, b repository.BrokerAmount) (bool, error)
+	DeleteBrokerAmount(ctx context.Context, id int64) (bool, error)
+	ListSOSPrices(ctx context.Context, country string) ([]repository.SOSPrice, error)
+	GetSOSPrice(ctx context.Context, id int64) (repository.SOSPrice, bool, error)
+	CreateSOSPrice(ctx context.Context, p repository.SOSPrice) (int64, error)
+	UpdateSOSPrice(ctx context.Context, p repository.SOSPrice) (bool, error)
+	DeleteSOSPrice(ctx context.Context, id int64) (bool, error)
+}
+
+// RatesProvider отдает актуальные курсы валют к доллару.
+type RatesProvider interface {
+	GetCurrency(ctx context.Context) (external.OpenExchangeRatesResponse, error)
+}
+
+// KGDSource скачивает файл КГД.
+type KGDSource interface {
+	DownloadFile() ([]byte, error)
+}
+
+var (
+	_ Store         = repository.Repo{}
+	_ RatesProvider = external.Client{}
+	_ KGDSource     = external.Client{}
+)
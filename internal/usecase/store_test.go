@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// fakeStore - Store в памяти. Методы, которые тестам не нужны, достаются от
+// встроенного nil-интерфейса и паникуют, если их все же вызвать.
+type fakeStore struct {
+	Store
+
+	delivereds []repository.Delivered
+	brokers    []repository.BrokerAmount
+	sosPrices  []repository.SOSPrice
+	// mu защищает assessments: пакетный расчет сохраняет снимки параллельно
+	mu          sync.Mutex
+	assessments map[string]repository.Assessment
+	catalogRows []repository.CatalogRow
+	importID    int64
+	popularity  []repository.Popularity
+	// valuations - история по ключу "марка модель объем год"
+	valuations map[string][]repository.Valuation
+	// searchAliases - синонимы поиска, как в search_alias
+	searchAliases map[string]string
+	subscriptions []repository.Subscription
+
+	// dates - даты, на которые запрашивались действующие тарифы
+	dates []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{assessments: make(map[string]repository.Assessment)}
+}
+
+// validOn повторяет условие validOn из репозитория.
+func validOn(from, to, date string) bool {
+	return (from == "" || from <= date) && (to == "" || to >= date)
+}
+
+func (s *fakeStore) GetDelivereds(ctx context.Context, country, origin, date string) ([]repository.Delivered, error) {
+	s.dates = append(s.dates, date)
+	delivereds := make([]repository.Delivered, 0)
+	for _, d := range s.delivereds {
+		if d.Country == country && (origin == "" || d.FromCity == origin) && validOn(d.ValidFrom, d.ValidTo, date) {
+			delivereds = append(delivereds, d)
+		}
+	}
+	return delivereds, nil
+}
+
+func (s *fakeStore) GetBrokerAmounts(ctx context.Context, country, date string) ([]repository.BrokerAmount, error) {
+	amounts := make([]repository.BrokerAmount, 0)
+	for _, b := range s.brokers {
+		if b.Country == country && validOn(b.ValidFrom, b.ValidTo, date) {
+			amounts = append(amounts, b)
+		}
+	}
+	return amounts, nil
+}
+
+func (s *fakeStore) GetSOSPrices(ctx context.Context, country, date string) ([]repository.SOSPrice, error) {
+	prices := make([]repository.SOSPrice, 0)
+	for _, p := range s.sosPrices {
+		if p.Country == country && validOn(p.ValidFrom, p.ValidTo, date) {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
+}
+
+func (s *fakeStore) CreateAssessment(ctx context.Context, a repository.Assessment) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.assessments[a.ID]; ok {
+		return false, nil
+	}
+	s.assessments[a.ID] = a
+	return true, nil
+}
+
+func (s *fakeStore) GetAssessment(ctx context.Context, id string) (repository.Assessment, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.assessments[id]
+	return a, ok, nil
+}
+
+// GetCatalogRows отдает строки в порядке запроса репозитория.
+func (s *fakeStore) GetCatalogRows(ctx context.Context) ([]repository.CatalogRow, error) {
+	return s.catalogRows, nil
+}
+
+func (s *fakeStore) GetLastImportID(ctx context.Context) (int64, error) {
+	return s.importID, nil
+}
+
+func (s *fakeStore) GetValuationHistory(ctx context.Context, mark, model string, volume, year int) ([]repository.Valuation, error) {
+	return s.valuations[fmt.Sprintf("%s %s %d %d", mark, model, volume, year)], nil
+}
+
+// GetSpecifications отбирает годы из catalogRows, новые сверху, как репозиторий.
+func (s *fakeStore) GetSpecifications(ctx context.Context, mark, model string, volume int) ([]repository.Specification, error) {
+	specifications := make([]repository.Specification, 0)
+	for _, r := range s.catalogRows {
+		if r.Mark == mark && r.Model == model && r.Volume == volume {
+			specifications = append(specifications, repository.Specification{
+				Year: r.Year, Amount: r.Amount, EngineType: r.EngineType,
+				BatteryCapacity: r.BatteryCapacity, Power: r.Power, VehicleClass: r.VehicleClass,
+			})
+		}
+	}
+	slices.SortStableFunc(specifications, func(a, b repository.Specification) int {
+		return b.Year - a.Year
+	})
+	return specifications, nil
+}
+
+// GetDataRows отбирает строки из catalogRows; фильтр по годам тестам не нужен.
+func (s *fakeStore) GetDataRows(ctx context.Context, mark, model string, yearFrom, yearTo int) ([]repository.Data, error) {
+	data := make([]repository.Data, 0)
+	for i, r := range s.catalogRows {
+		if (mark == "" || r.Mark == mark) && (model == "" || r.Model == model) {
+			data = append(data, repository.Data{
+				ID: i + 1, Mark: r.Mark, Model: r.Model, Volume: r.Volume, Year: r.Year, Amount: r.Amount,
+				EngineType: r.EngineType, VehicleClass: r.VehicleClass,
+			})
+		}
+	}
+	return data, nil
+}
+
+// GetVehicleNames - уникальные пары марки и модели из catalogRows.
+func (s *fakeStore) GetVehicleNames(ctx context.Context) ([]repository.VehicleName, error) {
+	names := make([]repository.VehicleName, 0)
+	for _, r := range s.catalogRows {
+		name := repository.VehicleName{Mark: r.Mark, Model: r.Model}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *fakeStore) GetSearchAliases(ctx context.Context) (map[string]string, error) {
+	return s.searchAliases, nil
+}
+
+// SearchIndexEnabled - без fts5 поиск перебирает весь справочник.
+func (s *fakeStore) SearchIndexEnabled() bool {
+	return false
+}
+
+func (s *fakeStore) GetPopularity(ctx context.Context, mark string) ([]repository.Popularity, error) {
+	return s.popularity, nil
+}
+
+func (s *fakeStore) GetSubscriptions(ctx context.Context, chatID int64) ([]repository.Subscription, error) {
+	subscriptions := make([]repository.Subscription, 0)
+	for _, sub := range s.subscriptions {
+		if chatID == 0 || sub.ChatID == chatID {
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (s *fakeStore) UpdateSubscriptionAmount(ctx context.Context, id int64, amount int) error {
+	for i := range s.subscriptions {
+		if s.subscriptions[i].ID == id {
+			s.subscriptions[i].LastAmount = amount
+			return nil
+		}
+	}
+	return fmt.Errorf("subscription %d not found", id)
+}
+
+// fakeRates отдает заранее заданные курсы или ошибку.
+type fakeRates struct {
+	currency external.OpenExchangeRatesResponse
+	err      error
+	calls    int
+}
+
+func (r *fakeRates) GetCurrency(ctx context.Context) (external.OpenExchangeRatesResponse, error) {
+	r.calls++
+	return r.currency, r.err
+}
+
+var errRatesUnavailable = errors.New("rates unavailable")
+
+func newFakeRates() *fakeRates {
+	return &fakeRates{currency: external.OpenExchangeRatesResponse{
+		Base:      "USD",
+		Timestamp: 1700000000,
+		Rates:     external.Currency{KZT: 500, AED: 3.67, CNY: 7.1, RUB: 90},
+	}}
+}
+
+func newTestUseCase(store *fakeStore, rates *fakeRates) UseCase {
+	return NewUseCase(store, rates, nil, nil)
+}
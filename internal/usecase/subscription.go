@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+// DefaultSubscriptionThreshold - минимальное изменение цены под ключ в тенге,
+// о котором стоит уведомлять, если пользователь не указал свой порог.
+const DefaultSubscriptionThreshold = 100000
+
+type Subscription struct {
+	ID         int64
+	ChatID     int64
+	Mark       string
+	Model      string
+	Volume     int
+	Year       int
+	PriceUSD   int
+	Threshold  int
+	LastAmount int
+}
+
+// PriceChange - изменение цены под ключ по подписке, превысившее порог.
+type PriceChange struct {
+	Subscription Subscription
+	OldAmount    int
+	NewAmount    int
+}
+
+// Watch сохраняет подписку на авто и запоминает текущую цену под ключ,
+// от которой будут считаться изменения.
+func (u UseCase) Watch(ctx context.Context, s Subscription) (Subscription, error) {
+	s.Mark = strings.ToUpper(strings.TrimSpace(s.Mark))
+	s.Model = strings.ToUpper(strings.TrimSpace(s.Model))
+	if s.ChatID == 0 || s.Mark == "" || s.Model == "" || s.Year == 0 {
+		return s, fmt.Errorf("%w: chat_id, mark, model and year are required", ErrInvalidArgument)
+	}
+	if s.Volume < 0 || s.PriceUSD < 0 || s.Threshold < 0 {
+		return s, fmt.Errorf("%w: volume, price_usd and threshold must not be negative", ErrInvalidArgument)
+	}
+	if s.Threshold == 0 {
+		s.Threshold = DefaultSubscriptionThreshold
+	}
+
+	currency, err := u.rates.GetCurrency(ctx)
+	if err != nil {
+		return s, err
+	}
+
+	amount, err := u.subscriptionAmount(ctx, s, currency.Rates.KZT)
+	if err != nil {
+		return s, err
+	}
+	s.LastAmount = amount
+
+	s.ID, err = u.repo.CreateSubscription(ctx, repository.Subscription{
+		ChatID:     s.ChatID,
+		Mark:       s.Mark,
+		Model:      s.Model,
+		Volume:     s.Volume,
+		Year:       s.Year,
+		PriceUSD:   s.PriceUSD,
+		Threshold:  s.Threshold,
+		LastAmount: s.LastAmount,
+	})
+	if err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func (u UseCase) GetSubscriptions(ctx context.Context, chatID int64) ([]Subscription, error) {
+	subscriptions := make([]Subscription, 0)
+	subscriptionsData, err := u.repo.GetSubscriptions(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range subscriptionsData {
+		subscriptions = append(subscriptions, subscriptionFromData(s))
+	}
+	return subscriptions, nil
+}
+
+func (u UseCase) Unwatch(ctx context.Context, chatID, id int64) error {
+	deleted, err := u.repo.DeleteSubscription(ctx, chatID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: subscription %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// CheckSubscriptions пересчитывает цену под ключ по всем подпискам и
+// возвращает те, где изменение превысило порог пользователя. Новая цена не
+// сохраняется: после отправки уведомления нужно вызвать ConfirmPriceChange,
+// иначе изменение придет еще раз при следующей проверке.
+func (u UseCase) CheckSubscriptions(ctx context.Context) ([]PriceChange, error) {
+	changes := make([]PriceChange, 0)
+	subscriptionsData, err := u.repo.GetSubscriptions(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptionsData) == 0 {
+		return changes, nil
+	}
+
+	currency, err := u.rates.GetCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rate := currency.Rates.KZT
+
+	for _, s := range subscriptionsData {
+		subscription := subscriptionFromData(s)
+		amount, err := u.subscriptionAmount(ctx, subscription, rate)
+		if errors.Is(err, ErrNotFound) {
+			// авто пропало из нового списка КГД, пересчитывать нечего
+			continue
+		}
+		if err != nil {
+			return changes, fmt.Errorf("subscription %d -> %w", s.ID, err)
+		}
+
+		diff := amount - s.LastAmount
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < s.Threshold {
+			continue
+		}
+
+		changes = append(changes, PriceChange{
+			Subscription: subscription,
+			OldAmount:    s.LastAmount,
+			NewAmount:    amount,
+		})
+	}
+	return changes, nil
+}
+
+// ConfirmPriceChange запоминает новую цену подписки, чтобы не уведомлять
+// повторно об одном и том же изменении.
+func (u UseCase) ConfirmPriceChange(ctx context.Context, c PriceChange) error {
+	return u.repo.UpdateSubscriptionAmount(ctx, c.Subscription.ID, c.NewAmount)
+}
+
+func (u UseCase) subscriptionAmount(ctx context.Context, s Subscription, rate float64) (int, error) {
+	quote, err := u.carQuote(ctx, s.Mark, s.Model, s.Volume, s.Year, s.PriceUSD, rate)
+	if err != nil {
+		return 0, err
+	}
+	return quote.TurnkeyAmount, nil
+}
+
+func subscriptionFromData(s repository.Subscription) Subscription {
+	return Subscription{
+		ID:         s.ID,
+		ChatID:     s.ChatID,
+		Mark:       s.Mark,
+		Model:      s.Model,
+		Volume:     s.Volume,
+		Year:       s.Year,
+		PriceUSD:   s.PriceUSD,
+		Threshold:  s.Threshold,
+		LastAmount: s.LastAmount,
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestCheckSubscriptions(t *testing.T) {
+	year := time.Now().Year() - 2
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, year, 20000),
+	}
+	u := newTestUseCase(store, newFakeRates())
+	ctx := context.Background()
+	if err := u.RefreshCatalog(ctx); err != nil {
+		t.Fatalf("RefreshCatalog: %v", err)
+	}
+
+	amount := u.calcTurnkey(20000*500, vehicle{Class: ClassM1, EngineType: EngineICE, Volume: 2500, Year: year})
+	subscription := func(id int64, model string, lastAmount int) repository.Subscription {
+		return repository.Subscription{
+			ID: id, ChatID: 1, Mark: "TOYOTA", Model: model, Volume: 2500, Year: year,
+			Threshold: 100000, LastAmount: lastAmount,
+		}
+	}
+	store.subscriptions = []repository.Subscription{
+		subscription(1, "CAMRY", amount),
+		// изменение ровно на порог уже уведомляется
+		subscription(2, "CAMRY", amount-100000),
+		subscription(3, "CAMRY", amount-99999),
+		subscription(4, "CAMRY", amount+150000),
+		// авто пропало из списка КГД
+		subscription(5, "CORONA", amount-500000),
+	}
+
+	changes, err := u.CheckSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("CheckSubscriptions: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Subscription.ID != 2 || changes[1].Subscription.ID != 4 {
+		t.Fatalf("CheckSubscriptions = %+v, want subscriptions 2 and 4", changes)
+	}
+	if c := changes[1]; c.OldAmount != amount+150000 || c.NewAmount != amount {
+		t.Errorf("change = %+v, want %d -> %d", c, amount+150000, amount)
+	}
+
+	// до подтверждения цена не сохраняется, и изменение придет снова
+	if store.subscriptions[1].LastAmount != amount-100000 {
+		t.Errorf("CheckSubscriptions saved the amount before the notification was sent")
+	}
+	if err := u.ConfirmPriceChange(ctx, changes[0]); err != nil {
+		t.Fatalf("ConfirmPriceChange: %v", err)
+	}
+	changes, err = u.CheckSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("CheckSubscriptions: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Subscription.ID != 4 {
+		t.Errorf("CheckSubscriptions after confirm = %+v, want only subscription 4", changes)
+	}
+}
@@ -0,0 +1,423 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+const defaultTariffCountry = "kz"
+
+// tariffCurrencies - валюты, в которых можно задать цену доставки: курсы к
+// ним есть в снимке расчета.
+var tariffCurrencies = []string{"USD", "KZT", "AED", "CNY", "RUB"}
+
+// DeliveryRoute - маршрут доставки. Amount в валюте Currency, ValidFrom и
+// ValidTo - даты 2006-01-02 включительно, пустая строка - без ограничения.
+type DeliveryRoute struct {
+	ID          int64
+	Country     string
+	FromCity    string
+	ToCity      string
+	Carrier     string
+	Amount      int
+	Currency    string
+	TransitDays int
+	ValidFrom   string
+	ValidTo     string
+}
+
+// BrokerTier - тариф брокера в тенге.
+type BrokerTier struct {
+	ID        int64
+	Country   string
+	Name      string
+	Amount    int
+	ValidFrom string
+	ValidTo   string
+}
+
+// SOSPrice - цена установки кнопки SOS/ЭВАК в тенге.
+type SOSPrice struct {
+	ID        int64
+	Country   string
+	Name      string
+	Amount    int
+	ValidFrom string
+	ValidTo   string
+}
+
+// Tariffs - предложения, действующие на дату Date, для выбора на фронтенде.
+// Пустой Origin - доставка из всех городов отправки.
+type Tariffs struct {
+	Date     string
+	Country  string
+	Origin   string
+	Delivery []DeliveryRoute
+	Broker   []BrokerTier
+	SOS      []SOSPrice
+}
+
+// GetTariffs возвращает доставку из города origin, тарифы брокера и цены SOS,
+// действующие на момент now.
+func (u UseCase) GetTariffs(ctx context.Context, country, origin string, now time.Time) (Tariffs, error) {
+	tariffs := Tariffs{
+		Date:     now.Format(time.DateOnly),
+		Country:  normalizeCountry(country),
+		Origin:   strings.TrimSpace(origin),
+		Delivery: make([]DeliveryRoute, 0),
+		Broker:   make([]BrokerTier, 0),
+		SOS:      make([]SOSPrice, 0),
+	}
+	if tariffs.Country == "" {
+		tariffs.Country = defaultTariffCountry
+	}
+
+	// город сверяется без учета регистра, а SQLite не приводит к нижнему
+	// регистру кириллицу, поэтому маршруты фильтруются здесь
+	delivereds, err := u.repo.GetDelivereds(ctx, tariffs.Country, "", tariffs.Date)
+	if err != nil {
+		return tariffs, err
+	}
+	for _, d := range delivereds {
+		if tariffs.Origin != "" && !strings.EqualFold(d.FromCity, tariffs.Origin) {
+			continue
+		}
+		tariffs.Delivery = append(tariffs.Delivery, deliveryRouteFromData(d))
+	}
+
+	brokers, err := u.repo.GetBrokerAmounts(ctx, tariffs.Country, tariffs.Date)
+	if err != nil {
+		return tariffs, err
+	}
+	for _, b := range brokers {
+		tariffs.Broker = append(tariffs.Broker, brokerTierFromData(b))
+	}
+
+	prices, err := u.repo.GetSOSPrices(ctx, tariffs.Country, tariffs.Date)
+	if err != nil {
+		return tariffs, err
+	}
+	for _, p := range prices {
+		tariffs.SOS = append(tariffs.SOS, sosPriceFromData(p))
+	}
+	return tariffs, nil
+}
+
+// GetDeliveryRoutes возвращает все маршруты, включая недействующие; пустая страна - все страны.
+func (u UseCase) GetDeliveryRoutes(ctx context.Context, country string) ([]DeliveryRoute, error) {
+	delivereds, err := u.repo.ListDelivereds(ctx, normalizeCountry(country))
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]DeliveryRoute, 0, len(delivereds))
+	for _, d := range delivereds {
+		routes = append(routes, deliveryRouteFromData(d))
+	}
+	return routes, nil
+}
+
+func (u UseCase) CreateDeliveryRoute(ctx context.Context, route DeliveryRoute) (DeliveryRoute, error) {
+	route, err := validateDeliveryRoute(route)
+	if err != nil {
+		return route, err
+	}
+
+	id, err := u.repo.CreateDelivered(ctx, deliveredFromRoute(route))
+	if err != nil {
+		return route, err
+	}
+	return u.getDeliveryRoute(ctx, id)
+}
+
+func (u UseCase) UpdateDeliveryRoute(ctx context.Context, id int64, route DeliveryRoute) (DeliveryRoute, error) {
+	route, err := validateDeliveryRoute(route)
+	if err != nil {
+		return route, err
+	}
+
+	route.ID = id
+	updated, err := u.repo.UpdateDelivered(ctx, deliveredFromRoute(route))
+	if err != nil {
+		return route, err
+	}
+	if !updated {
+		return route, fmt.Errorf("%w: delivery route %d", ErrNotFound, id)
+	}
+	return u.getDeliveryRoute(ctx, id)
+}
+
+func (u UseCase) DeleteDeliveryRoute(ctx context.Context, id int64) error {
+	deleted, err := u.repo.DeleteDelivered(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: delivery route %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (u UseCase) getDeliveryRoute(ctx context.Context, id int64) (DeliveryRoute, error) {
+	d, ok, err := u.repo.GetDelivered(ctx, id)
+	if err != nil {
+		return DeliveryRoute{}, err
+	}
+	if !ok {
+		return DeliveryRoute{}, fmt.Errorf("%w: delivery route %d", ErrNotFound, id)
+	}
+	return deliveryRouteFromData(d), nil
+}
+
+// GetBrokerTiers возвращает все тарифы брокера; пустая страна - все страны.
+func (u UseCase) GetBrokerTiers(ctx context.Context, country string) ([]BrokerTier, error) {
+	amounts, err := u.repo.ListBrokerAmounts(ctx, normalizeCountry(country))
+	if err != nil {
+		return nil, err
+	}
+
+	tiers := make([]BrokerTier, 0, len(amounts))
+	for _, b := range amounts {
+		tiers = append(tiers, brokerTierFromData(b))
+	}
+	return tiers, nil
+}
+
+func (u UseCase) CreateBrokerTier(ctx context.Context, tier BrokerTier) (BrokerTier, error) {
+	tier, err := validateBrokerTier(tier)
+	if err != nil {
+		return tier, err
+	}
+
+	id, err := u.repo.CreateBrokerAmount(ctx, repository.BrokerAmount(tier))
+	if err != nil {
+		return tier, err
+	}
+	return u.getBrokerTier(ctx, id)
+}
+
+func (u UseCase) UpdateBrokerTier(ctx context.Context, id int64, tier BrokerTier) (BrokerTier, error) {
+	tier, err := validateBrokerTier(tier)
+	if err != nil {
+		return tier, err
+	}
+
+	tier.ID = id
+	updated, err := u.repo.UpdateBrokerAmount(ctx, repository.BrokerAmount(tier))
+	if err != nil {
+		return tier, err
+	}
+	if !updated {
+		return tier, fmt.Errorf("%w: broker tier %d", ErrNotFound, id)
+	}
+	return u.getBrokerTier(ctx, id)
+}
+
+func (u UseCase) DeleteBrokerTier(ctx context.Context, id int64) error {
+	deleted, err := u.repo.DeleteBrokerAmount(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: broker tier %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (u UseCase) getBrokerTier(ctx context.Context, id int64) (BrokerTier, error) {
+	b, ok, err := u.repo.GetBrokerAmount(ctx, id)
+	if err != nil {
+		return BrokerTier{}, err
+	}
+	if !ok {
+		return BrokerTier{}, fmt.Errorf("%w: broker tier %d", ErrNotFound, id)
+	}
+	return brokerTierFromData(b), nil
+}
+
+// GetSOSPrices возвращает все цены кнопки SOS; пустая страна - все страны.
+func (u UseCase) GetSOSPrices(ctx context.Context, country string) ([]SOSPrice, error) {
+	pricesData, err := u.repo.ListSOSPrices(ctx, normalizeCountry(country))
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]SOSPrice, 0, len(pricesData))
+	for _, p := range pricesData {
+		prices = append(prices, sosPriceFromData(p))
+	}
+	return prices, nil
+}
+
+func (u UseCase) CreateSOSPrice(ctx context.Context, price SOSPrice) (SOSPrice, error) {
+	price, err := validateSOSPrice(price)
+	if err != nil {
+		return price, err
+	}
+
+	id, err := u.repo.CreateSOSPrice(ctx, repository.SOSPrice(price))
+	if err != nil {
+		return price, err
+	}
+	return u.getSOSPrice(ctx, id)
+}
+
+func (u UseCase) UpdateSOSPrice(ctx context.Context, id int64, price SOSPrice) (SOSPrice, error) {
+	price, err := validateSOSPrice(price)
+	if err != nil {
+		return price, err
+	}
+
+	price.ID = id
+	updated, err := u.repo.UpdateSOSPrice(ctx, repository.SOSPrice(price))
+	if err != nil {
+		return price, err
+	}
+	if !updated {
+		return price, fmt.Errorf("%w: sos price %d", ErrNotFound, id)
+	}
+	return u.getSOSPrice(ctx, id)
+}
+
+func (u UseCase) DeleteSOSPrice(ctx context.Context, id int64) error {
+	deleted, err := u.repo.DeleteSOSPrice(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: sos price %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (u UseCase) getSOSPrice(ctx context.Context, id int64) (SOSPrice, error) {
+	p, ok, err := u.repo.GetSOSPrice(ctx, id)
+	if err != nil {
+		return SOSPrice{}, err
+	}
+	if !ok {
+		return SOSPrice{}, fmt.Errorf("%w: sos price %d", ErrNotFound, id)
+	}
+	return sosPriceFromData(p), nil
+}
+
+func validateDeliveryRoute(route DeliveryRoute) (DeliveryRoute, error) {
+	route.Country = normalizeCountry(route.Country)
+	route.FromCity = strings.TrimSpace(route.FromCity)
+	route.ToCity = strings.TrimSpace(route.ToCity)
+	route.Carrier = strings.TrimSpace(route.Carrier)
+	route.Currency = strings.ToUpper(strings.TrimSpace(route.Currency))
+	if route.Currency == "" {
+		route.Currency = "USD"
+	}
+
+	if route.Country == "" || route.FromCity == "" || route.ToCity == "" {
+		return route, fmt.Errorf("%w: country, from_city and to_city are required", ErrInvalidArgument)
+	}
+	if route.Amount <= 0 {
+		return route, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	if !isTariffCurrency(route.Currency) {
+		return route, fmt.Errorf("%w: currency must be one of %s", ErrInvalidArgument, strings.Join(tariffCurrencies, ", "))
+	}
+	if route.TransitDays < 0 {
+		return route, fmt.Errorf("%w: transit_days must not be negative", ErrInvalidArgument)
+	}
+	return route, validateValidity(route.ValidFrom, route.ValidTo)
+}
+
+func validateBrokerTier(tier BrokerTier) (BrokerTier, error) {
+	tier.Country = normalizeCountry(tier.Country)
+	tier.Name = strings.TrimSpace(tier.Name)
+	if tier.Country == "" {
+		return tier, fmt.Errorf("%w: country is required", ErrInvalidArgument)
+	}
+	if tier.Amount <= 0 {
+		return tier, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	return tier, validateValidity(tier.ValidFrom, tier.ValidTo)
+}
+
+func validateSOSPrice(price SOSPrice) (SOSPrice, error) {
+	price.Country = normalizeCountry(price.Country)
+	price.Name = strings.TrimSpace(price.Name)
+	if price.Country == "" {
+		return price, fmt.Errorf("%w: country is required", ErrInvalidArgument)
+	}
+	if price.Amount <= 0 {
+		return price, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	return price, validateValidity(price.ValidFrom, price.ValidTo)
+}
+
+// validateValidity проверяет формат дат и что период не пустой. Даты в
+// формате 2006-01-02 сравниваются в SQL как строки.
+func validateValidity(from, to string) error {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("%w: date %q must be in format 2006-01-02", ErrInvalidArgument, date)
+		}
+	}
+	if from != "" && to != "" && from > to {
+		return fmt.Errorf("%w: valid_from is after valid_to", ErrInvalidArgument)
+	}
+	return nil
+}
+
+func normalizeCountry(country string) string {
+	return strings.ToLower(strings.TrimSpace(country))
+}
+
+func isTariffCurrency(currency string) bool {
+	for _, c := range tariffCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// toKZT переводит сумму в тенге по курсам расчета. Доллар считается по целому
+// курсу, как и стоимость авто, остальные валюты - кросс-курсом через доллар.
+func (r AssessmentRates) toKZT(amount int, currency string) (int, error) {
+	var perUSD float64
+	switch currency {
+	case "KZT":
+		return amount, nil
+	case "USD":
+		return amount * int(r.KZT), nil
+	case "AED":
+		perUSD = r.AED
+	case "CNY":
+		perUSD = r.CNY
+	case "RUB":
+		perUSD = r.RUB
+	}
+	if perUSD == 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	return int(float64(amount) * r.KZT / perUSD), nil
+}
+
+func deliveryRouteFromData(d repository.Delivered) DeliveryRoute {
+	return DeliveryRoute(d)
+}
+
+func deliveredFromRoute(route DeliveryRoute) repository.Delivered {
+	return repository.Delivered(route)
+}
+
+func brokerTierFromData(b repository.BrokerAmount) BrokerTier {
+	return BrokerTier(b)
+}
+
+func sosPriceFromData(p repository.SOSPrice) SOSPrice {
+	return SOSPrice(p)
+}
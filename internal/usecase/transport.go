@@ -1,32 +1,58 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"strings"
 	"time"
-
-	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
 )
 
 type Mark struct {
 	Name string
 }
 
+// Model - каноническая модель и написания, под которыми она встречается в КГД.
 type Model struct {
-	Name string
+	Name     string
+	Variants []string
 }
+
+// Volume - объем двигателя модели и типы двигателей с этим объемом. У
+// электромобилей объем нулевой.
 type Volume struct {
-	Value int
+	Value       int
+	EngineTypes []string
 }
 
+// Specification - оценка КГД за год выпуска. BatteryCapacity в кВт*ч и Power
+// в кВт нулевые, если КГД их не указал.
 type Specification struct {
-	Year   int
-	Amount int
+	Year            int
+	Amount          int
+	EngineType      string
+	BatteryCapacity float64
+	Power           int
+	VehicleClass    string
 }
 
+// vehicle - комплектация объема volume этого года для расчета сборов.
+func (s Specification) vehicle(volume int) vehicle {
+	return vehicle{Class: s.VehicleClass, EngineType: s.EngineType, Volume: volume, Year: s.Year}
+}
+
+// Assessment - итог расчета. VehicleClass и EngineType - класс и тип
+// двигателя, по которым взяты ставки; в расчетах до их появления они пустые,
+// там всегда легковой с ДВС. KGDAmount - оценка авто по списку КГД в
+// долларах для сравнения с ценой клиента, 0 - авто в списке не нашлось.
 type Assessment struct {
+	ID                      string
+	VehicleClass            string
+	EngineType              string
+	KGDAmount               int
 	AmountKZT               int
 	USD                     int
-	Delivereds              []repository.Delivered
+	Delivereds              []Delivered
 	SBKTS                   int
 	CustomsCollectionAmount int
 	CustomsDutyAmount       int
@@ -37,13 +63,25 @@ type Assessment struct {
 	UtilAmount              int
 }
 
+// Delivered - маршрут доставки в расчете: Amount в валюте Currency, AmountKZT -
+// по курсу на момент расчета. В старых расчетах валюты нет, там всегда доллары.
 type Delivered struct {
-	FromCity string
-	ToCity   string
-	Amount   int
+	FromCity    string
+	ToCity      string
+	Carrier     string
+	Amount      int
+	Currency    string
+	AmountKZT   int
+	TransitDays int
 }
 
+// GetMarks и остальные списки справочника отдаются из снимка в памяти, а до
+// первого RefreshCatalog - из базы.
 func (u UseCase) GetMarks(ctx context.Context) ([]Mark, error) {
+	if c := u.catalog.current.Load(); c != nil {
+		return c.marks, nil
+	}
+
 	marks := make([]Mark, 0)
 	marksData, err := u.repo.GetMarks(ctx)
 	if err != nil {
@@ -59,6 +97,13 @@ func (u UseCase) GetMarks(ctx context.Context) ([]Mark, error) {
 }
 
 func (u UseCase) GetModels(ctx context.Context, mark string) ([]Model, error) {
+	if c := u.catalog.current.Load(); c != nil {
+		if models, ok := c.models[mark]; ok {
+			return models, nil
+		}
+		return []Model{}, nil
+	}
+
 	models := make([]Model, 0)
 	modelsData, err := u.repo.GetModels(ctx, mark)
 	if err != nil {
@@ -66,13 +111,27 @@ func (u UseCase) GetModels(ctx context.Context, mark string) ([]Model, error) {
 	}
 
 	for _, model := range modelsData {
-		models = append(models, Model{
-			Name: model.Name,
-		})
+		if len(models) == 0 || models[len(models)-1].Name != model.Name {
+			models = append(models, Model{
+				Name:     model.Name,
+				Variants: make([]string, 0),
+			})
+		}
+		if model.Variant != "" && model.Variant != model.Name {
+			last := &models[len(models)-1]
+			last.Variants = append(last.Variants, model.Variant)
+		}
 	}
 	return models, nil
 }
 func (u UseCase) GetVolumes(ctx context.Context, mark, model string) ([]Volume, error) {
+	if c := u.catalog.current.Load(); c != nil {
+		if volumes, ok := c.volumes[catalogKey{mark: mark, model: model}]; ok {
+			return volumes, nil
+		}
+		return []Volume{}, nil
+	}
+
 	volumes := make([]Volume, 0)
 	volumesData, err := u.repo.GetVolumes(ctx, mark, model)
 	if err != nil {
@@ -88,6 +147,13 @@ func (u UseCase) GetVolumes(ctx context.Context, mark, model string) ([]Volume,
 }
 
 func (u UseCase) GetSpecifications(ctx context.Context, mark, model string, volume int) ([]Specification, error) {
+	if c := u.catalog.current.Load(); c != nil {
+		if specifications, ok := c.specifications[catalogKey{mark: mark, model: model, volume: volume}]; ok {
+			return specifications, nil
+		}
+		return []Specification{}, nil
+	}
+
 	specifications := make([]Specification, 0)
 	specificationsData, err := u.repo.GetSpecifications(ctx, mark, model, volume)
 	if err != nil {
@@ -96,77 +162,220 @@ func (u UseCase) GetSpecifications(ctx context.Context, mark, model string, volu
 
 	for _, s := range specificationsData {
 		specifications = append(specifications, Specification{
-			Year:   s.Year,
-			Amount: s.Amount,
+			Year:            s.Year,
+			Amount:          s.Amount,
+			EngineType:      engineTypeOrICE(s.EngineType),
+			BatteryCapacity: s.BatteryCapacity,
+			Power:           s.Power,
+			VehicleClass:    vehicleClassOrM1(s.VehicleClass),
 		})
 	}
 	return specifications, nil
 }
 
-func (u UseCase) AssessmentAuto(ctx context.Context, amount, volume, year int) (Assessment, error) {
+type AssessmentInput struct {
+	Mark   string
+	Model  string
+	Amount int
+	Volume int
+	Year   int
+	// Origin - город отправки, пустой - доставка из всех городов
+	Origin string
+	// Currency - валюта Amount, пустая - доллары
+	Currency string
+	// Destination - город назначения, пустой - доставка во все города
+	Destination string
+	// EngineType - EngineICE, EngineHybrid или EngineEV; пустой - ДВС, а при
+	// нулевом объеме - тип из строки КГД
+	EngineType string
+	// VehicleClass - ClassM1, ClassN1 или ClassL; пустой - легковой
+	VehicleClass string
+}
+
+// assessmentEnv - курсы и тарифы на момент расчета. В пакетном расчете они
+// общие для всех позиций, ставки же зависят от класса каждой машины.
+type assessmentEnv struct {
+	rates   AssessmentRates
+	tariffs Tariffs
+}
 
-	currency, err := u.external.GetCurrency(ctx)
+// newAssessmentEnv запрашивает курсы и тарифы доставки из города origin,
+// пустой origin - из всех городов.
+func (u UseCase) newAssessmentEnv(ctx context.Context, origin string) (assessmentEnv, error) {
+	currency, err := u.rates.GetCurrency(ctx)
 	if err != nil {
-		return Assessment{}, err
+		return assessmentEnv{}, err
 	}
 
-	delivereds, err := u.repo.GetDelivereds(ctx, "kz")
+	// в расчет попадают только предложения, действующие сегодня
+	tariffs, err := u.GetTariffs(ctx, defaultTariffCountry, origin, time.Now())
+	if err != nil {
+		return assessmentEnv{}, err
+	}
+	return assessmentEnv{
+		rates:   ratesFromCurrency(currency),
+		tariffs: tariffs,
+	}, nil
+}
+
+// AssessmentAuto считает растаможку и сохраняет снимок расчета под коротким ID.
+func (u UseCase) AssessmentAuto(ctx context.Context, input AssessmentInput) (Assessment, error) {
+	input = normalizeAssessmentInput(input)
+	env, err := u.newAssessmentEnv(ctx, input.Origin)
 	if err != nil {
 		return Assessment{}, err
 	}
+	return u.assess(ctx, env, input)
+}
+
+func normalizeAssessmentInput(input AssessmentInput) AssessmentInput {
+	input.Mark = strings.ToUpper(strings.TrimSpace(input.Mark))
+	input.Model = strings.ToUpper(strings.TrimSpace(input.Model))
+	input.Origin = strings.TrimSpace(input.Origin)
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	input.Destination = strings.TrimSpace(input.Destination)
+	return input
+}
 
-	brokerAmouts, err := u.repo.GetBrokerAmounts(ctx, "kz")
+// assess считает одну позицию по готовым курсам и тарифам и сохраняет снимок.
+func (u UseCase) assess(ctx context.Context, env assessmentEnv, input AssessmentInput) (Assessment, error) {
+	rates, tariffs := env.rates, env.tariffs
+	class, err := normalizeVehicleClass(input.VehicleClass)
+	if err != nil {
+		return Assessment{}, err
+	}
+	engineType, err := normalizeEngineType(input.EngineType)
 	if err != nil {
 		return Assessment{}, err
 	}
+	kgd, err := u.kgdSpecification(ctx, input)
+	if err != nil {
+		return Assessment{}, err
+	}
+	if engineType, err = resolveEngineType(engineType, input.Volume, kgd); err != nil {
+		return Assessment{}, err
+	}
+	input.VehicleClass, input.EngineType = class, engineType
+	v := vehicle{Class: class, EngineType: engineType, Volume: input.Volume, Year: input.Year}
+	rules := u.rules(class)
 
-	var amountKZT = int(currency.Rates.KZT) * amount
-	customsDutyAmount := ((amountKZT) * 15) / 100
-	customsCollectionAmount := u.mrp * 6
+	delivereds := make([]Delivered, 0, len(tariffs.Delivery))
+	for _, route := range tariffs.Delivery {
+		if input.Origin != "" && !strings.EqualFold(route.FromCity, input.Origin) {
+			continue
+		}
+		if input.Destination != "" && !strings.EqualFold(route.ToCity, input.Destination) {
+			continue
+		}
+		amountKZT, err := rates.toKZT(route.Amount, route.Currency)
+		if err != nil {
+			return Assessment{}, fmt.Errorf("delivery route %d -> %v", route.ID, err)
+		}
+		delivereds = append(delivereds, Delivered{
+			FromCity:    route.FromCity,
+			ToCity:      route.ToCity,
+			Carrier:     route.Carrier,
+			Amount:      route.Amount,
+			Currency:    route.Currency,
+			AmountKZT:   amountKZT,
+			TransitDays: route.TransitDays,
+		})
+	}
+
+	brokerAmouts := make([]int, 0, len(tariffs.Broker))
+	for _, tier := range tariffs.Broker {
+		brokerAmouts = append(brokerAmouts, tier.Amount)
+	}
+
+	sosAmounts := make([]int, 0, len(tariffs.SOS))
+	for _, price := range tariffs.SOS {
+		sosAmounts = append(sosAmounts, price.Amount)
+	}
 
-	return Assessment{
+	amountKZT, err := rates.toKZT(input.Amount, cmp.Or(input.Currency, "USD"))
+	if err != nil {
+		return Assessment{}, fmt.Errorf("%w: price -> %v", ErrInvalidArgument, err)
+	}
+	customsDutyAmount := (amountKZT * rules.DutyPercent(engineType)) / 100
+	customsCollectionAmount := rules.MRP * rules.CustomsCollectionMRP
+	firstRegistrationAmount, utilAmount := rules.fees(v)
+
+	assessment := Assessment{
+		VehicleClass:            class,
+		EngineType:              engineType,
+		KGDAmount:               kgd.Amount,
 		AmountKZT:               amountKZT,
-		USD:                     int(currency.Rates.KZT),
+		USD:                     int(rates.KZT),
 		Delivereds:              delivereds,
 		SBKTS:                   0,
 		CustomsDutyAmount:       customsDutyAmount,
 		CustomsCollectionAmount: customsCollectionAmount,
+		ButtonSOSAmount:         sosAmounts,
 		BrokerAmouts:            brokerAmouts,
-		VATAmount:               ((amountKZT + customsDutyAmount + customsCollectionAmount) * 12) / 100,
-		FirstRegistrationAmount: u.calcFirstRegistration(year),
-		UtilAmount:              u.calcUtilAmount(float64(volume)),
-	}, nil
+		VATAmount:               ((amountKZT + customsDutyAmount + customsCollectionAmount) * rules.VATPercent) / 100,
+		FirstRegistrationAmount: firstRegistrationAmount,
+		UtilAmount:              utilAmount,
+	}
+
+	snapshot, err := u.saveAssessment(ctx, input, rates, rules, assessment)
+	if err != nil {
+		return Assessment{}, err
+	}
+	return snapshot.Result, nil
+}
+
+// kgdSpecification ищет строку КГД для авто из расчета; без марки, модели или
+// года искать нечего, как и без строки с таким годом - тогда она пустая.
+func (u UseCase) kgdSpecification(ctx context.Context, input AssessmentInput) (Specification, error) {
+	if input.Mark == "" || input.Model == "" || input.Year == 0 {
+		return Specification{}, nil
+	}
+	specifications, err := u.GetSpecifications(ctx, input.Mark, input.Model, input.Volume)
+	if err != nil {
+		return Specification{}, err
+	}
+	for _, spec := range specifications {
+		if spec.Year == input.Year {
+			return spec, nil
+		}
+	}
+	return Specification{}, nil
+}
+
+// KGDRow - строка справочника КГД, Amount - оценка в долларах.
+type KGDRow struct {
+	Mark         string
+	Model        string
+	VehicleClass string
+	EngineType   string
+	Volume       int
+	Year         int
+	Amount       int
 }
 
-func (u UseCase) calcUtilAmount(volume float64) int {
-	var mrpRate float64 = float64(u.mrp) * 50
-	if volume <= 1000 {
-		totalAmount := mrpRate * 1.5
-		return int(totalAmount)
-	} else if volume >= 1001 || volume <= 2000 {
-		totalAmount := mrpRate * 3.5
-		return int(totalAmount)
-	} else if volume >= 2001 || volume <= 3000 {
-		totalAmount := mrpRate * 5
-		return int(totalAmount)
-	} else if volume >= 300 {
-		totalAmount := mrpRate * 11.5
-		return int(totalAmount)
-	}
-	return 0
-}
-func (u UseCase) calcFirstRegistration(year int) int {
-	currentYear := time.Now().Year()
-	age := currentYear - year
-	if age >= 1 {
-		totalAmount := float64(u.mrp) * 0.25
-		return int(totalAmount)
-	} else if age >= 2 && age <= 3 {
-		totalAmount := float64(u.mrp) * 50
-		return int(totalAmount)
-	} else if age > 3 {
-		totalAmount := float64(u.mrp) * 500
-		return int(totalAmount)
-	}
-	return 0
+// GetKGDRows отбирает строки КГД для выгрузки: марка и модель точные,
+// годы включительно, нулевые значения фильтр не ограничивают.
+func (u UseCase) GetKGDRows(ctx context.Context, mark, model string, yearFrom, yearTo int) ([]KGDRow, error) {
+	if yearFrom != 0 && yearTo != 0 && yearFrom > yearTo {
+		return nil, fmt.Errorf("%w: year_from is greater than year_to", ErrInvalidArgument)
+	}
+
+	data, err := u.repo.GetDataRows(ctx, strings.ToUpper(mark), strings.ToUpper(model), yearFrom, yearTo)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]KGDRow, 0, len(data))
+	for _, d := range data {
+		rows = append(rows, KGDRow{
+			Mark:         d.Mark,
+			Model:        d.Model,
+			VehicleClass: vehicleClassOrM1(d.VehicleClass),
+			EngineType:   engineTypeOrICE(d.EngineType),
+			Volume:       d.Volume,
+			Year:         d.Year,
+			Amount:       d.Amount,
+		})
+	}
+	return rows, nil
 }
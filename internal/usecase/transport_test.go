@@ -0,0 +1,346 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestAssessmentAuto(t *testing.T) {
+	store := newFakeStore()
+	store.delivereds = []repository.Delivered{
+		{ID: 1, Country: "kz", FromCity: "Дубай", ToCity: "Алматы", Amount: 2500, Currency: "USD", TransitDays: 30},
+		{ID: 2, Country: "kz", FromCity: "Шарджа", ToCity: "Алматы", Carrier: "Sea", Amount: 9000, Currency: "AED"},
+		{ID: 3, Country: "kz", FromCity: "Дубай", ToCity: "Актау", Amount: 2000, Currency: "USD", ValidTo: "2000-01-01"},
+		{ID: 4, Country: "ru", FromCity: "Дубай", ToCity: "Астрахань", Amount: 2200, Currency: "USD"},
+	}
+	store.brokers = []repository.BrokerAmount{
+		{ID: 1, Country: "kz", Amount: 30000},
+		{ID: 2, Country: "kz", Amount: 50000, ValidFrom: "2999-01-01"},
+	}
+	store.sosPrices = []repository.SOSPrice{
+		{ID: 1, Country: "kz", Amount: 220000},
+	}
+	rates := newFakeRates()
+	u := newTestUseCase(store, rates)
+	ctx := context.Background()
+
+	input := AssessmentInput{Mark: " toyota ", Model: "camry", Amount: 10000, Volume: 900, Year: time.Now().Year()}
+	got, err := u.AssessmentAuto(ctx, input)
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+
+	want := Assessment{
+		ID:           got.ID,
+		VehicleClass: ClassM1,
+		EngineType:   EngineICE,
+		AmountKZT:    5000000,
+		USD:          500,
+		Delivereds: []Delivered{
+			{FromCity: "Дубай", ToCity: "Алматы", Amount: 2500, Currency: "USD", AmountKZT: 1250000, TransitDays: 30},
+			{FromCity: "Шарджа", ToCity: "Алматы", Carrier: "Sea", Amount: 9000, Currency: "AED", AmountKZT: 1226158},
+		},
+		CustomsDutyAmount:       750000,
+		CustomsCollectionAmount: 3692 * 6,
+		VATAmount:               (5000000 + 750000 + 3692*6) * 12 / 100,
+		ButtonSOSAmount:         []int{220000},
+		BrokerAmouts:            []int{30000},
+		FirstRegistrationAmount: 3692 / 4,
+		UtilAmount:              3692 * 50 * 3 / 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AssessmentAuto =\n%+v\nwant\n%+v", got, want)
+	}
+	if len(got.ID) != assessmentIDLength {
+		t.Errorf("assessment ID %q, want %d characters", got.ID, assessmentIDLength)
+	}
+	if rates.calls != 1 {
+		t.Errorf("GetCurrency called %d times, want 1", rates.calls)
+	}
+
+	snapshot, err := u.GetAssessment(ctx, got.ID)
+	if err != nil {
+		t.Fatalf("GetAssessment: %v", err)
+	}
+	if !reflect.DeepEqual(snapshot.Result, got) {
+		t.Errorf("saved result = %+v, want %+v", snapshot.Result, got)
+	}
+	if snapshot.Input.Mark != "TOYOTA" || snapshot.Input.Model != "CAMRY" {
+		t.Errorf("saved input = %+v, want normalized mark and model", snapshot.Input)
+	}
+	if snapshot.Rates.KZT != 500 || snapshot.Rates.AED != 3.67 {
+		t.Errorf("saved rates = %+v", snapshot.Rates)
+	}
+	if !reflect.DeepEqual(snapshot.Rules, u.rules(ClassM1)) {
+		t.Errorf("saved rules = %+v, want %+v", snapshot.Rules, u.rules(ClassM1))
+	}
+}
+
+func TestAssessmentKGDAmount(t *testing.T) {
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2020, 20000),
+		catalogRow("TOYOTA", "CAMRY", "CAMRY", 2500, 2022, 25000),
+	}
+	u := newTestUseCase(store, newFakeRates())
+	ctx := context.Background()
+
+	// цена клиента в дирхамах, оценка КГД - отдельно в долларах
+	got, err := u.AssessmentAuto(ctx, AssessmentInput{Mark: "toyota", Model: "camry", Amount: 80000, Currency: "AED", Volume: 2500, Year: 2020})
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+	if got.KGDAmount != 20000 {
+		t.Errorf("KGDAmount = %d, want 20000", got.KGDAmount)
+	}
+
+	got, err = u.AssessmentAuto(ctx, AssessmentInput{Mark: "TOYOTA", Model: "CAMRY", Amount: 20000, Volume: 2500, Year: 2015})
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+	if got.KGDAmount != 0 {
+		t.Errorf("KGDAmount for a year missing from KGD = %d, want 0", got.KGDAmount)
+	}
+}
+
+func TestAssessmentAutoOrigin(t *testing.T) {
+	store := newFakeStore()
+	store.delivereds = []repository.Delivered{
+		{ID: 1, Country: "kz", FromCity: "Дубай", ToCity: "Алматы", Amount: 2500, Currency: "USD"},
+		{ID: 2, Country: "kz", FromCity: "Шарджа", ToCity: "Алматы", Amount: 9000, Currency: "AED"},
+	}
+	u := newTestUseCase(store, newFakeRates())
+
+	got, err := u.AssessmentAuto(context.Background(), AssessmentInput{Mark: "TOYOTA", Model: "CAMRY", Amount: 1000, Volume: 2500, Origin: " шарджа "})
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+	if len(got.Delivereds) != 1 || got.Delivereds[0].FromCity != "Шарджа" {
+		t.Errorf("Delivereds = %+v, want only routes from Шарджа", got.Delivereds)
+	}
+}
+
+func TestAssessmentAutoEngineType(t *testing.T) {
+	old := time.Now().Year() - 5
+	store := newFakeStore()
+	tesla := catalogRow("TESLA", "MODEL 3", "MODEL 3", 0, old, 30000)
+	tesla.EngineType = EngineEV
+	store.catalogRows = []repository.CatalogRow{tesla}
+	u := newTestUseCase(store, newFakeRates())
+	ctx := context.Background()
+
+	// нулевой объем у строки КГД электромобиля: без пошлины и регистрации,
+	// утильсбор по нижней ставке
+	ev, err := u.AssessmentAuto(ctx, AssessmentInput{Mark: "TESLA", Model: "MODEL 3", Amount: 10000, Year: old})
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+	if ev.EngineType != EngineEV || ev.CustomsDutyAmount != 0 || ev.FirstRegistrationAmount != 0 || ev.UtilAmount != 3692*50*3/2 {
+		t.Errorf("EV assessment = %+v", ev)
+	}
+	if ev.VATAmount != (5000000+3692*6)*12/100 {
+		t.Errorf("EV VAT = %d, want VAT without duty", ev.VATAmount)
+	}
+	snapshot, err := u.GetAssessment(ctx, ev.ID)
+	if err != nil {
+		t.Fatalf("GetAssessment: %v", err)
+	}
+	if snapshot.Input.EngineType != EngineEV || !strings.Contains(snapshot.LineItems()[3].Name, "0%") {
+		t.Errorf("EV snapshot input = %+v, duty line %q", snapshot.Input, snapshot.LineItems()[3].Name)
+	}
+
+	// гибрид считается как ДВС того же объема
+	hybrid, err := u.AssessmentAuto(ctx, AssessmentInput{Mark: "TOYOTA", Model: "CAMRY", Amount: 10000, Volume: 2500, Year: old, EngineType: " Hybrid "})
+	if err != nil {
+		t.Fatalf("AssessmentAuto: %v", err)
+	}
+	if hybrid.EngineType != EngineHybrid || hybrid.CustomsDutyAmount != 750000 || hybrid.FirstRegistrationAmount != 3692*500 || hybrid.UtilAmount != 3692*50*5 {
+		t.Errorf("hybrid assessment = %+v", hybrid)
+	}
+
+	if _, err := u.AssessmentAuto(ctx, AssessmentInput{Amount: 10000, Volume: 2500, EngineType: "diesel"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("unknown engine type = %v, want ErrInvalidArgument", err)
+	}
+	// без объема электромобиль только по явному типу или строке КГД
+	for _, input := range []AssessmentInput{
+		{Mark: "TOYOTA", Model: "CAMRY", Amount: 10000, Year: old},
+		{Mark: "TESLA", Model: "MODEL 3", Amount: 10000, Year: old, EngineType: EngineICE},
+	} {
+		if _, err := u.AssessmentAuto(ctx, input); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("AssessmentAuto(%+v) without volume = %v, want ErrInvalidArgument", input, err)
+		}
+	}
+}
+
+func TestAssessmentAutoVehicleClass(t *testing.T) {
+	u := newTestUseCase(newFakeStore(), newFakeRates())
+	ctx := context.Background()
+	old := time.Now().Year() - 5
+	tests := []struct {
+		name  string
+		input AssessmentInput
+		want  Assessment
+	}{
+		{
+			"pickup",
+			AssessmentInput{Mark: "TOYOTA", Model: "HILUX", Amount: 10000, Volume: 2800, Year: old, VehicleClass: "n1"},
+			Assessment{VehicleClass: ClassN1, EngineType: EngineICE, CustomsDutyAmount: 500000, FirstRegistrationAmount: 3692 * 4, UtilAmount: 3692 * 50 * 4},
+		},
+		{
+			// льгота по пошлине на электромобили только у легковых
+			"electric van",
+			AssessmentInput{Amount: 10000, Year: old, VehicleClass: ClassN1, EngineType: EngineEV},
+			Assessment{VehicleClass: ClassN1, EngineType: EngineEV, CustomsDutyAmount: 500000, UtilAmount: 3692 * 50 * 2},
+		},
+		{
+			"motorcycle",
+			AssessmentInput{Mark: "HONDA", Model: "CBR 600", Amount: 10000, Volume: 600, Year: old, VehicleClass: " L "},
+			Assessment{VehicleClass: ClassL, EngineType: EngineICE, CustomsDutyAmount: 250000, FirstRegistrationAmount: 3692 * 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.AssessmentAuto(ctx, tt.input)
+			if err != nil {
+				t.Fatalf("AssessmentAuto: %v", err)
+			}
+			if got.VehicleClass != tt.want.VehicleClass || got.EngineType != tt.want.EngineType ||
+				got.CustomsDutyAmount != tt.want.CustomsDutyAmount || got.FirstRegistrationAmount != tt.want.FirstRegistrationAmount || got.UtilAmount != tt.want.UtilAmount {
+				t.Errorf("AssessmentAuto = %+v, want %+v", got, tt.want)
+			}
+			snapshot, err := u.GetAssessment(ctx, got.ID)
+			if err != nil {
+				t.Fatalf("GetAssessment: %v", err)
+			}
+			if snapshot.Input.VehicleClass != tt.want.VehicleClass || !reflect.DeepEqual(snapshot.Rules, u.rules(tt.want.VehicleClass)) {
+				t.Errorf("snapshot input %+v, rules %+v", snapshot.Input, snapshot.Rules)
+			}
+		})
+	}
+
+	if _, err := u.AssessmentAuto(ctx, AssessmentInput{Amount: 10000, Volume: 2500, VehicleClass: "M3"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("unknown vehicle class = %v, want ErrInvalidArgument", err)
+	}
+}
+
+// TestGetAssessmentOldRules - снимок, сохраненный до ставок сборов в Rules,
+// читается как есть: суммы берутся из Result, а не пересчитываются.
+func TestGetAssessmentOldRules(t *testing.T) {
+	store := newFakeStore()
+	store.assessments["OLD00001"] = repository.Assessment{
+		ID:     "OLD00001",
+		Input:  `{"Mark":"TOYOTA","Model":"CAMRY","Amount":20000,"Volume":2500,"Year":2018}`,
+		Rates:  `{"Base":"USD","KZT":450}`,
+		Rules:  `{"MRP":3450,"CustomsDutyPercent":15,"CustomsCollectionMRP":6,"VATPercent":12}`,
+		Result: `{"AmountKZT":9000000,"FirstRegistrationAmount":1725000,"UtilAmount":862500}`,
+	}
+	u := newTestUseCase(store, newFakeRates())
+
+	snapshot, err := u.GetAssessment(context.Background(), "OLD00001")
+	if err != nil {
+		t.Fatalf("GetAssessment: %v", err)
+	}
+	if snapshot.Rules.MRP != 3450 || snapshot.Rules.UtilRates != nil || snapshot.Rules.RegistrationRates != nil {
+		t.Errorf("old rules = %+v", snapshot.Rules)
+	}
+	if snapshot.Result.FirstRegistrationAmount != 1725000 || snapshot.Result.UtilAmount != 862500 {
+		t.Errorf("old result = %+v, want the saved fees", snapshot.Result)
+	}
+}
+
+func TestAssessmentAutoErrors(t *testing.T) {
+	t.Run("rates unavailable", func(t *testing.T) {
+		store := newFakeStore()
+		rates := newFakeRates()
+		rates.err = errRatesUnavailable
+		u := newTestUseCase(store, rates)
+
+		_, err := u.AssessmentAuto(context.Background(), AssessmentInput{Amount: 1000})
+		if !errors.Is(err, errRatesUnavailable) {
+			t.Errorf("AssessmentAuto error = %v, want %v", err, errRatesUnavailable)
+		}
+		if len(store.assessments) != 0 {
+			t.Errorf("saved %d assessments, want none", len(store.assessments))
+		}
+	})
+
+	t.Run("no rate for route currency", func(t *testing.T) {
+		store := newFakeStore()
+		store.delivereds = []repository.Delivered{
+			{ID: 7, Country: "kz", FromCity: "Шанхай", ToCity: "Алматы", Amount: 50000, Currency: "CNY"},
+		}
+		rates := newFakeRates()
+		rates.currency.Rates.CNY = 0
+		u := newTestUseCase(store, rates)
+
+		if _, err := u.AssessmentAuto(context.Background(), AssessmentInput{Amount: 1000}); err == nil {
+			t.Error("AssessmentAuto succeeded without a CNY rate")
+		}
+		if len(store.assessments) != 0 {
+			t.Errorf("saved %d assessments, want none", len(store.assessments))
+		}
+	})
+}
+
+func TestGetTariffs(t *testing.T) {
+	store := newFakeStore()
+	store.delivereds = []repository.Delivered{
+		{ID: 1, Country: "kz", FromCity: "Дубай", Amount: 2500, Currency: "USD", ValidFrom: "2024-01-01", ValidTo: "2024-06-30"},
+		{ID: 2, Country: "kz", FromCity: "Дубай", Amount: 2700, Currency: "USD", ValidFrom: "2024-07-01"},
+	}
+	u := newTestUseCase(store, newFakeRates())
+
+	now := time.Date(2024, 6, 30, 23, 0, 0, 0, time.UTC)
+	tariffs, err := u.GetTariffs(context.Background(), " KZ ", "", now)
+	if err != nil {
+		t.Fatalf("GetTariffs: %v", err)
+	}
+	if tariffs.Date != "2024-06-30" || tariffs.Country != "kz" {
+		t.Errorf("GetTariffs date and country = %q, %q", tariffs.Date, tariffs.Country)
+	}
+	if len(tariffs.Delivery) != 1 || tariffs.Delivery[0].ID != 1 {
+		t.Errorf("Delivery = %+v, want route 1", tariffs.Delivery)
+	}
+
+	tariffs, err = u.GetTariffs(context.Background(), "", "", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetTariffs: %v", err)
+	}
+	if tariffs.Country != defaultTariffCountry || len(tariffs.Delivery) != 1 || tariffs.Delivery[0].ID != 2 {
+		t.Errorf("next day tariffs = %+v, want route 2 in %s", tariffs, defaultTariffCountry)
+	}
+}
+
+func TestToKZT(t *testing.T) {
+	rates := AssessmentRates{KZT: 500.7, AED: 3.67, CNY: 7.1, RUB: 90}
+
+	tests := []struct {
+		amount   int
+		currency string
+		want     int
+		wantErr  bool
+	}{
+		{150000, "KZT", 150000, false},
+		{2500, "USD", 1250000, false},
+		{9000, "AED", 1227874, false},
+		{71000, "CNY", 5007000, false},
+		{900000, "RUB", 5007000, false},
+		{100, "EUR", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := rates.toKZT(tt.amount, tt.currency)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("toKZT(%d, %s) error = %v, wantErr %v", tt.amount, tt.currency, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("toKZT(%d, %s) = %d, want %d", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
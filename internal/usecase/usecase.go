@@ -1,23 +1,26 @@
 package usecase
 
-import (
-	"github.com/omekov/dubaicarkzv2/internal/usecase/external"
-	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
-)
-
 type UseCase struct {
-	repo     repository.Repo
-	external external.Client
+	repo     Store
+	rates    RatesProvider
+	kgd      KGDSource
+	notifier Notifier
+	catalog  *catalogCache
 	mrp      int
 }
 
-func NewUseCase(repo repository.Repo) UseCase {
+// NewUseCase собирает бизнес-логику; notifier может быть nil, если бот недоступен.
+func NewUseCase(repo Store, rates RatesProvider, kgd KGDSource, notifier Notifier) UseCase {
 	return UseCase{
-		repo: repo,
-		mrp:  3692,
+		repo:     repo,
+		rates:    rates,
+		kgd:      kgd,
+		notifier: notifier,
+		catalog:  &catalogCache{},
+		mrp:      3692,
 	}
 }
 
 func (u UseCase) GetKGDFile() ([]byte, error) {
-	return u.external.DownloadFile()
+	return u.kgd.DownloadFile()
 }
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Классы транспорта по ТР ТС. У каждого класса свои ставки пошлины,
+// утильсбора и сбора за регистрацию, см. classRules.
+const (
+	// ClassM1 - легковые автомобили
+	ClassM1 = "M1"
+	// ClassN1 - легкие грузовые до 3,5 т, в том числе пикапы
+	ClassN1 = "N1"
+	// ClassL - мотоциклы, мопеды и квадроциклы
+	ClassL = "L"
+)
+
+// vehicle - то, от чего зависят пошлина и сборы помимо стоимости авто.
+type vehicle struct {
+	Class      string
+	EngineType string
+	Volume     int
+	Year       int
+}
+
+// normalizeVehicleClass проверяет класс транспорта из запроса, пустой - легковой.
+func normalizeVehicleClass(class string) (string, error) {
+	switch class = strings.ToUpper(strings.TrimSpace(class)); class {
+	case ClassM1, ClassN1, ClassL:
+		return class, nil
+	case "":
+		return ClassM1, nil
+	}
+	return "", fmt.Errorf("%w: vehicle class %q, want %s, %s or %s", ErrInvalidArgument, class, ClassM1, ClassN1, ClassL)
+}
+
+// vehicleClassOrM1 возвращает класс строки справочника; у строк, прочитанных
+// до разметки, он пустой.
+func vehicleClassOrM1(class string) string {
+	if class == "" {
+		return ClassM1
+	}
+	return class
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/omekov/dubaicarkzv2/pkg/vin"
+)
+
+// VINDecoding - расшифровка VIN для предзаполнения расчета. Mark - марка
+// справочника КГД, пустая, если производителя нет в таблице WMI или в
+// справочнике. Candidates - комплектации этой марки за год выпуска из VIN;
+// модель и объем по VIN не определить, их выбирает клиент.
+type VINDecoding struct {
+	VIN          string
+	WMI          string
+	Manufacturer string
+	Region       string
+	Year         int
+	CheckDigit   bool
+	Mark         string
+	Candidates   []VINCandidate
+}
+
+type VINCandidate struct {
+	Model  string
+	Volume int
+	Year   int
+	Amount int
+}
+
+// DecodeVIN проверяет VIN и подбирает марку и строки справочника КГД.
+func (u UseCase) DecodeVIN(ctx context.Context, number string) (VINDecoding, error) {
+	decoded, err := vin.Decode(number, time.Now())
+	if err != nil {
+		return VINDecoding{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	decoding := VINDecoding{
+		VIN:          decoded.Number,
+		WMI:          decoded.WMI,
+		Manufacturer: decoded.Manufacturer,
+		Region:       decoded.Region,
+		Year:         decoded.Year,
+		CheckDigit:   decoded.CheckDigit,
+		Candidates:   make([]VINCandidate, 0),
+	}
+	if decoded.Manufacturer == "" {
+		return decoding, nil
+	}
+
+	c, err := u.loadCatalog(ctx)
+	if err != nil {
+		return VINDecoding{}, err
+	}
+	for _, mark := range c.marks {
+		if markKey(mark.Name) == markKey(decoded.Manufacturer) {
+			decoding.Mark = mark.Name
+			break
+		}
+	}
+	if decoding.Mark == "" || decoding.Year == 0 {
+		return decoding, nil
+	}
+
+	for _, model := range c.models[decoding.Mark] {
+		for _, volume := range c.volumes[catalogKey{mark: decoding.Mark, model: model.Name}] {
+			for _, s := range c.specifications[catalogKey{mark: decoding.Mark, model: model.Name, volume: volume.Value}] {
+				if s.Year == decoding.Year {
+					decoding.Candidates = append(decoding.Candidates, VINCandidate{
+						Model:  model.Name,
+						Volume: volume.Value,
+						Year:   s.Year,
+						Amount: s.Amount,
+					})
+				}
+			}
+		}
+	}
+	return decoding, nil
+}
+
+// markKey сравнивает марки без учета пробелов и дефисов: КГД пишет и
+// "MERCEDES-BENZ", и "MERCEDES BENZ".
+func markKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, name)
+}
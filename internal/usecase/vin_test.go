@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/omekov/dubaicarkzv2/internal/usecase/repository"
+)
+
+func TestDecodeVIN(t *testing.T) {
+	store := newFakeStore()
+	store.catalogRows = []repository.CatalogRow{
+		catalogRow("HONDA", "ACCORD", "ACCORD", 2400, 2003, 9000),
+		catalogRow("HONDA", "ACCORD", "ACCORD", 2400, 2004, 9500),
+		catalogRow("HONDA", "ACCORD", "ACCORD", 3000, 2003, 11000),
+		catalogRow("HONDA", "CR-V", "CR-V", 2400, 2003, 10000),
+		catalogRow("MERCEDES BENZ", "E 200", "E 200", 2000, 2019, 40000),
+	}
+	uc := NewUseCase(store, nil, nil, nil)
+	ctx := context.Background()
+
+	decoding, err := uc.DecodeVIN(ctx, "1hgcm82633a004352")
+	if err != nil {
+		t.Fatalf("DecodeVIN: %v", err)
+	}
+	want := []VINCandidate{
+		{Model: "ACCORD", Volume: 2400, Year: 2003, Amount: 9000},
+		{Model: "ACCORD", Volume: 3000, Year: 2003, Amount: 11000},
+		{Model: "CR-V", Volume: 2400, Year: 2003, Amount: 10000},
+	}
+	if decoding.VIN != "1HGCM82633A004352" || decoding.Mark != "HONDA" || decoding.Year != 2003 || !decoding.CheckDigit {
+		t.Errorf("DecodeVIN = %+v", decoding)
+	}
+	if !reflect.DeepEqual(decoding.Candidates, want) {
+		t.Errorf("candidates = %+v, want %+v", decoding.Candidates, want)
+	}
+
+	// в справочнике марка записана без дефиса
+	decoding, err = uc.DecodeVIN(ctx, "WDD213042KA000001")
+	if err != nil {
+		t.Fatalf("DecodeVIN: %v", err)
+	}
+	if decoding.Manufacturer != "MERCEDES-BENZ" || decoding.Mark != "MERCEDES BENZ" || len(decoding.Candidates) != 1 {
+		t.Errorf("DecodeVIN = %+v, want MERCEDES BENZ with one candidate", decoding)
+	}
+
+	// производителя нет в справочнике - год все равно расшифрован
+	decoding, err = uc.DecodeVIN(ctx, "JTEBU5JR2K5610413")
+	if err != nil {
+		t.Fatalf("DecodeVIN: %v", err)
+	}
+	if decoding.Mark != "" || decoding.Year != 2019 || len(decoding.Candidates) != 0 {
+		t.Errorf("DecodeVIN = %+v, want no mark and no candidates", decoding)
+	}
+
+	if _, err := uc.DecodeVIN(ctx, "1HGCM82643A004352"); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("DecodeVIN with a wrong check digit = %v, want ErrInvalidArgument", err)
+	}
+}
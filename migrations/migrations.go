@@ -0,0 +1,10 @@
+package migrations
+
+import "embed"
+
+// FS содержит SQL-миграции, вшитые в бинарник, чтобы не зависеть от рабочей директории.
+// Миграции лежат в каталоге своего диалекта: sqlite3 и postgres. Номера версий
+// в каталогах совпадают, новая миграция добавляется в оба.
+//
+//go:embed sqlite3/*.sql postgres/*.sql
+var FS embed.FS
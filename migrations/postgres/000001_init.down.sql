@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS broker_amount;
+DROP TABLE IF EXISTS delivered;
+DROP TABLE IF EXISTS data;
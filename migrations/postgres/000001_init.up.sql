@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS data (
+    id BIGSERIAL PRIMARY KEY,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    year BIGINT,
+    volume BIGINT,
+    amount BIGINT,
+    popular_rate BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS delivered (
+    id BIGSERIAL PRIMARY KEY,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    from_city TEXT,
+    to_city TEXT,
+    amount BIGINT,
+    country TEXT
+);
+
+CREATE TABLE IF NOT EXISTS broker_amount (
+    id BIGSERIAL PRIMARY KEY,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    amount BIGINT,
+    country TEXT
+);
+
+-- Вставка данных
+INSERT INTO delivered (from_city, to_city, amount, country) VALUES ('Дубай', 'Алматы', 2500, 'kz');
+INSERT INTO delivered (from_city, to_city, amount, country) VALUES ('Дубай', 'Шымкент', 2600, 'kz');
+INSERT INTO delivered (from_city, to_city, amount, country) VALUES ('Дубай', 'Актау', 2000, 'kz');
+INSERT INTO delivered (from_city, to_city, amount, country) VALUES ('Дубай', 'Астрахань', 2200, 'ru');
+INSERT INTO delivered (from_city, to_city, amount, country) VALUES ('Дубай', 'Бишкек', 2600, 'kg');
+
+INSERT INTO broker_amount (amount, country) VALUES (25000, 'kz');
+INSERT INTO broker_amount (amount, country) VALUES (30000, 'kz');
+INSERT INTO broker_amount (amount, country) VALUES (35000, 'kz');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS subscription_chat_id_idx;
+DROP TABLE IF EXISTS subscription;
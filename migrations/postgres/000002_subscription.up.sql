@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS subscription (
+    id BIGSERIAL PRIMARY KEY,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    chat_id BIGINT NOT NULL,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    volume BIGINT NOT NULL,
+    year BIGINT NOT NULL,
+    price_usd BIGINT NOT NULL DEFAULT 0,
+    threshold BIGINT NOT NULL,
+    last_amount BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS subscription_chat_id_idx ON subscription (chat_id);
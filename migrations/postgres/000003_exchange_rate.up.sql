@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS exchange_rate (
+    date TEXT PRIMARY KEY,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    usd_kzt DOUBLE PRECISION NOT NULL,
+    aed_kzt DOUBLE PRECISION NOT NULL,
+    rub_kzt DOUBLE PRECISION NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS lead_status_idx;
+DROP TABLE IF EXISTS lead;
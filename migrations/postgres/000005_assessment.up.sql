@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS assessment (
+    id TEXT PRIMARY KEY,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    input TEXT NOT NULL,
+    rates TEXT NOT NULL,
+    rules TEXT NOT NULL,
+    result TEXT NOT NULL
+);
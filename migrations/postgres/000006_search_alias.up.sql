@@ -0,0 +1,57 @@
+-- Синонимы для поиска: alias хранится в верхнем регистре без пробелов и
+-- дефисов, replacement подставляется в запрос вместо него.
+CREATE TABLE IF NOT EXISTS search_alias (
+    alias TEXT PRIMARY KEY,
+    replacement TEXT NOT NULL
+);
+
+INSERT INTO search_alias (alias, replacement) VALUES
+    ('LC', 'TOYOTA LAND CRUISER'),
+    ('LC70', 'TOYOTA LAND CRUISER 70'),
+    ('LC76', 'TOYOTA LAND CRUISER 76'),
+    ('LC79', 'TOYOTA LAND CRUISER 79'),
+    ('LC100', 'TOYOTA LAND CRUISER 100'),
+    ('LC105', 'TOYOTA LAND CRUISER 105'),
+    ('LC200', 'TOYOTA LAND CRUISER 200'),
+    ('LC300', 'TOYOTA LAND CRUISER 300'),
+    ('LC120', 'TOYOTA LAND CRUISER PRADO'),
+    ('LC150', 'TOYOTA LAND CRUISER PRADO'),
+    ('LC250', 'TOYOTA LAND CRUISER PRADO'),
+    ('LCP', 'TOYOTA LAND CRUISER PRADO'),
+    ('PRADO', 'TOYOTA LAND CRUISER PRADO'),
+    ('ПРАДО', 'TOYOTA LAND CRUISER PRADO'),
+    ('КРУЗАК', 'TOYOTA LAND CRUISER'),
+    ('КРУЗАК200', 'TOYOTA LAND CRUISER 200'),
+    ('КРУЗАК300', 'TOYOTA LAND CRUISER 300'),
+    ('ЛК200', 'TOYOTA LAND CRUISER 200'),
+    ('ЛК300', 'TOYOTA LAND CRUISER 300'),
+    ('ТАЙОТА', 'TOYOTA'),
+    ('МЕРС', 'MERCEDES-BENZ'),
+    ('МЕРИН', 'MERCEDES-BENZ'),
+    ('MERCEDES', 'MERCEDES-BENZ'),
+    ('MB', 'MERCEDES-BENZ'),
+    ('БМВ', 'BMW'),
+    ('БЭХА', 'BMW'),
+    ('ХЕНДАЙ', 'HYUNDAI'),
+    ('ХУНДАЙ', 'HYUNDAI'),
+    ('ХЁНДЭ', 'HYUNDAI'),
+    ('ХЕНДЭ', 'HYUNDAI'),
+    ('ФОЛЬКСВАГЕН', 'VOLKSWAGEN'),
+    ('VW', 'VOLKSWAGEN'),
+    ('ФВ', 'VOLKSWAGEN'),
+    ('ЛЕКСУС', 'LEXUS'),
+    ('РАВ4', 'TOYOTA RAV4'),
+    ('RAV', 'TOYOTA RAV4'),
+    ('ЛАНДИК', 'TOYOTA LAND CRUISER'),
+    ('ГЕЛИК', 'MERCEDES-BENZ G'),
+    ('ГЕЛЕНДВАГЕН', 'MERCEDES-BENZ G'),
+    ('GWAGON', 'MERCEDES-BENZ G'),
+    ('ШЕВРОЛЕ', 'CHEVROLET'),
+    ('ШЕВИ', 'CHEVROLET'),
+    ('ЧЕРИ', 'CHERY'),
+    ('ДЖИЛИ', 'GEELY'),
+    ('ХАВАЛ', 'HAVAL'),
+    ('ХАВЕЙЛ', 'HAVAL'),
+    ('ЛИКСИАНГ', 'LI AUTO'),
+    ('ЗИКР', 'ZEEKR')
+ON CONFLICT DO NOTHING;
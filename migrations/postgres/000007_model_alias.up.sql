@@ -0,0 +1,36 @@
+-- Написания модели, которые сводятся к одной канонической модели марки.
+-- alias хранится нормализованным: верхний регистр, одиночные пробелы.
+CREATE TABLE IF NOT EXISTS model_alias (
+    mark TEXT NOT NULL,
+    alias TEXT NOT NULL,
+    model TEXT NOT NULL,
+    created_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    PRIMARY KEY (mark, alias)
+);
+
+-- variant - написание модели из файла КГД, model - каноническая модель
+ALTER TABLE data ADD COLUMN variant TEXT NOT NULL DEFAULT '';
+UPDATE data SET variant = model;
+
+INSERT INTO model_alias (mark, alias, model) VALUES
+    ('TOYOTA', 'LC PRADO', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LC PRADO 120', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LC PRADO 150', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LANDCRUISER PRADO', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LAND CRUISER PRADO 120', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LAND CRUISER PRADO 150', 'LAND CRUISER PRADO'),
+    ('TOYOTA', 'LC 200', 'LAND CRUISER 200'),
+    ('TOYOTA', 'LC200', 'LAND CRUISER 200'),
+    ('TOYOTA', 'LANDCRUISER 200', 'LAND CRUISER 200'),
+    ('TOYOTA', 'LC 300', 'LAND CRUISER 300'),
+    ('TOYOTA', 'LC300', 'LAND CRUISER 300'),
+    ('TOYOTA', 'LANDCRUISER 300', 'LAND CRUISER 300')
+ON CONFLICT DO NOTHING;
+
+UPDATE data SET model = (
+    SELECT a.model FROM model_alias a WHERE a.mark = data.mark AND a.alias = data.model
+) WHERE EXISTS (
+    SELECT 1 FROM model_alias a WHERE a.mark = data.mark AND a.alias = data.model
+);
+
+CREATE INDEX IF NOT EXISTS model_alias_model_idx ON model_alias (mark, model);
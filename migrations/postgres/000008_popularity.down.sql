@@ -0,0 +1,2 @@
+ALTER TABLE data ADD COLUMN popular_rate BIGINT;
+DROP TABLE IF EXISTS popularity;
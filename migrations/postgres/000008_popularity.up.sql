@@ -0,0 +1,24 @@
+-- Популярность марок и моделей для сортировки списков. model = '' - вся марка.
+-- weight задается вручную в админке, requests пересчитывается из количества
+-- расчетов; сначала сортируем по weight, затем по requests.
+CREATE TABLE IF NOT EXISTS popularity (
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    weight BIGINT NOT NULL DEFAULT 0,
+    requests BIGINT NOT NULL DEFAULT 0,
+    updated_at BIGINT NOT NULL DEFAULT (EXTRACT(EPOCH FROM now())::BIGINT),
+    PRIMARY KEY (mark, model)
+);
+
+-- порядок, который раньше был зашит в импорт
+INSERT INTO popularity (mark, model, weight) VALUES
+    ('TOYOTA', '', 7),
+    ('HYUNDAI', '', 6),
+    ('MERCEDES-BENZ', '', 5),
+    ('VOLKSWAGEN', '', 4),
+    ('KIA', '', 3),
+    ('NISSAN', '', 2),
+    ('BMW', '', 1)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE data DROP COLUMN popular_rate;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS sos_price;
+
+ALTER TABLE broker_amount DROP COLUMN updated_at;
+ALTER TABLE broker_amount DROP COLUMN valid_to;
+ALTER TABLE broker_amount DROP COLUMN valid_from;
+ALTER TABLE broker_amount DROP COLUMN name;
+
+ALTER TABLE delivered DROP COLUMN updated_at;
+ALTER TABLE delivered DROP COLUMN valid_to;
+ALTER TABLE delivered DROP COLUMN valid_from;
+ALTER TABLE delivered DROP COLUMN transit_days;
+ALTER TABLE delivered DROP COLUMN currency;
+ALTER TABLE delivered DROP COLUMN carrier;
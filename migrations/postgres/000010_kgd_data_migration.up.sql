@@ -0,0 +1,6 @@
+-- Загруженные файлы КГД.
+CREATE TABLE IF NOT EXISTS kgd_data_migration (
+    id BIGSERIAL PRIMARY KEY,
+    kgd_url TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (to_char(localtimestamp, 'YYYY-MM-DD HH24:MI:SS'))
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS data_mark_model_volume_year_idx;
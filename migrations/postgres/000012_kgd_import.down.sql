@@ -0,0 +1,7 @@
+DELETE FROM kgd_data_migration WHERE status <> 'imported';
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS row_count;
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS error;
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS status;
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS sha256;
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS last_modified;
+ALTER TABLE kgd_data_migration DROP COLUMN IF EXISTS etag;
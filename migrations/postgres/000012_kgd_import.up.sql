@@ -0,0 +1,9 @@
+-- Итог каждой загрузки КГД: заголовки и хеш файла для проверки обновлений,
+-- статус и ошибка. У прежних записей заголовков нет, поэтому их файл один раз
+-- скачается заново и получит хеш.
+ALTER TABLE kgd_data_migration ADD COLUMN etag TEXT NOT NULL DEFAULT '';
+ALTER TABLE kgd_data_migration ADD COLUMN last_modified TEXT NOT NULL DEFAULT '';
+ALTER TABLE kgd_data_migration ADD COLUMN sha256 TEXT NOT NULL DEFAULT '';
+ALTER TABLE kgd_data_migration ADD COLUMN status TEXT NOT NULL DEFAULT 'imported';
+ALTER TABLE kgd_data_migration ADD COLUMN error TEXT NOT NULL DEFAULT '';
+ALTER TABLE kgd_data_migration ADD COLUMN row_count BIGINT NOT NULL DEFAULT 0;
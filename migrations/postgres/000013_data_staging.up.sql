@@ -0,0 +1,22 @@
+-- Новый список КГД сначала загружается в data_staging и проверяется, затем
+-- заменяет data в одной транзакции. Прежний справочник остается в
+-- data_previous, чтобы вернуть его командой kgd rollback.
+CREATE TABLE IF NOT EXISTS data_staging (
+    id BIGINT PRIMARY KEY,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    variant TEXT NOT NULL DEFAULT '',
+    year BIGINT,
+    volume BIGINT,
+    amount BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS data_previous (
+    id BIGINT PRIMARY KEY,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    variant TEXT NOT NULL DEFAULT '',
+    year BIGINT,
+    volume BIGINT,
+    amount BIGINT
+);
@@ -0,0 +1,12 @@
+ALTER TABLE data_history DROP COLUMN IF EXISTS power;
+ALTER TABLE data_history DROP COLUMN IF EXISTS battery_capacity;
+ALTER TABLE data_history DROP COLUMN IF EXISTS engine_type;
+ALTER TABLE data_previous DROP COLUMN IF EXISTS power;
+ALTER TABLE data_previous DROP COLUMN IF EXISTS battery_capacity;
+ALTER TABLE data_previous DROP COLUMN IF EXISTS engine_type;
+ALTER TABLE data_staging DROP COLUMN IF EXISTS power;
+ALTER TABLE data_staging DROP COLUMN IF EXISTS battery_capacity;
+ALTER TABLE data_staging DROP COLUMN IF EXISTS engine_type;
+ALTER TABLE data DROP COLUMN IF EXISTS power;
+ALTER TABLE data DROP COLUMN IF EXISTS battery_capacity;
+ALTER TABLE data DROP COLUMN IF EXISTS engine_type;
@@ -0,0 +1,4 @@
+ALTER TABLE data_history DROP COLUMN IF EXISTS vehicle_class;
+ALTER TABLE data_previous DROP COLUMN IF EXISTS vehicle_class;
+ALTER TABLE data_staging DROP COLUMN IF EXISTS vehicle_class;
+ALTER TABLE data DROP COLUMN IF EXISTS vehicle_class;
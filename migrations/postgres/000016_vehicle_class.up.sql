@@ -0,0 +1,6 @@
+-- Класс транспорта по ТР ТС: M1 - легковые, N1 - легкие грузовые и пикапы,
+-- L - мототехника. Прежний список КГД был только легковым.
+ALTER TABLE data ADD COLUMN vehicle_class TEXT NOT NULL DEFAULT 'M1';
+ALTER TABLE data_staging ADD COLUMN vehicle_class TEXT NOT NULL DEFAULT 'M1';
+ALTER TABLE data_previous ADD COLUMN vehicle_class TEXT NOT NULL DEFAULT 'M1';
+ALTER TABLE data_history ADD COLUMN vehicle_class TEXT NOT NULL DEFAULT 'M1';
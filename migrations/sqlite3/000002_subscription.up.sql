@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS subscription (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    chat_id INTEGER NOT NULL,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    volume INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    price_usd INTEGER NOT NULL DEFAULT 0,
+    threshold INTEGER NOT NULL,
+    last_amount INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS subscription_chat_id_idx ON subscription (chat_id);
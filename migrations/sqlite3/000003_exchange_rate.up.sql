@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS exchange_rate (
+    date TEXT PRIMARY KEY,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    usd_kzt REAL NOT NULL,
+    aed_kzt REAL NOT NULL,
+    rub_kzt REAL NOT NULL
+);
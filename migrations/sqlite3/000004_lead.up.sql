@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS lead (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    name TEXT NOT NULL,
+    phone TEXT NOT NULL,
+    telegram_user TEXT NOT NULL DEFAULT '',
+    mark TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    volume INTEGER NOT NULL DEFAULT 0,
+    year INTEGER NOT NULL DEFAULT 0,
+    assessment TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'contacted', 'closed'))
+);
+
+CREATE INDEX IF NOT EXISTS lead_status_idx ON lead (status, id);
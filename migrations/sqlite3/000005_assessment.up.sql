@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS assessment (
+    id TEXT PRIMARY KEY,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    input TEXT NOT NULL,
+    rates TEXT NOT NULL,
+    rules TEXT NOT NULL,
+    result TEXT NOT NULL
+);
@@ -0,0 +1,4 @@
+UPDATE data SET model = variant WHERE variant != '';
+ALTER TABLE data DROP COLUMN variant;
+DROP INDEX IF EXISTS model_alias_model_idx;
+DROP TABLE IF EXISTS model_alias;
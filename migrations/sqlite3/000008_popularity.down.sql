@@ -0,0 +1,2 @@
+ALTER TABLE data ADD COLUMN popular_rate INTEGER;
+DROP TABLE IF EXISTS popularity;
@@ -0,0 +1,34 @@
+-- Маршруты доставки, тарифы брокера и цены кнопки SOS редактируются в админке.
+-- valid_from и valid_to - даты 2006-01-02 включительно, пустая строка - без ограничения.
+ALTER TABLE delivered ADD COLUMN carrier TEXT NOT NULL DEFAULT '';
+ALTER TABLE delivered ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE delivered ADD COLUMN transit_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE delivered ADD COLUMN valid_from TEXT NOT NULL DEFAULT '';
+ALTER TABLE delivered ADD COLUMN valid_to TEXT NOT NULL DEFAULT '';
+ALTER TABLE delivered ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+UPDATE delivered SET updated_at = created_at;
+
+ALTER TABLE broker_amount ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE broker_amount ADD COLUMN valid_from TEXT NOT NULL DEFAULT '';
+ALTER TABLE broker_amount ADD COLUMN valid_to TEXT NOT NULL DEFAULT '';
+ALTER TABLE broker_amount ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+UPDATE broker_amount SET updated_at = created_at;
+
+CREATE TABLE IF NOT EXISTS sos_price (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    country TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    amount INTEGER NOT NULL,
+    valid_from TEXT NOT NULL DEFAULT '',
+    valid_to TEXT NOT NULL DEFAULT ''
+);
+
+-- цены, которые раньше были зашиты во фронтенде
+INSERT INTO sos_price (country, amount) VALUES ('kz', 200000);
+INSERT INTO sos_price (country, amount) VALUES ('kz', 210000);
+INSERT INTO sos_price (country, amount) VALUES ('kz', 220000);
+INSERT INTO sos_price (country, amount) VALUES ('kz', 230000);
+INSERT INTO sos_price (country, amount) VALUES ('kz', 240000);
+INSERT INTO sos_price (country, amount) VALUES ('kz', 250000);
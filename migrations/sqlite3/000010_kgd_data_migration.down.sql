@@ -0,0 +1 @@
+DROP TABLE IF EXISTS kgd_data_migration;
@@ -0,0 +1,7 @@
+-- Загруженные файлы КГД. Раньше таблица создавалась при старте приложения,
+-- поэтому в существующих базах она уже есть.
+CREATE TABLE IF NOT EXISTS kgd_data_migration (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kgd_url TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
@@ -0,0 +1,2 @@
+-- Справочник читается по марке, модели, объему и году: каталог, расчет и поиск.
+CREATE INDEX IF NOT EXISTS data_mark_model_volume_year_idx ON data (mark, model, volume, year);
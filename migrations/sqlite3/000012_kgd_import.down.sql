@@ -0,0 +1,7 @@
+DELETE FROM kgd_data_migration WHERE status <> 'imported';
+ALTER TABLE kgd_data_migration DROP COLUMN row_count;
+ALTER TABLE kgd_data_migration DROP COLUMN error;
+ALTER TABLE kgd_data_migration DROP COLUMN status;
+ALTER TABLE kgd_data_migration DROP COLUMN sha256;
+ALTER TABLE kgd_data_migration DROP COLUMN last_modified;
+ALTER TABLE kgd_data_migration DROP COLUMN etag;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS data_previous;
+DROP TABLE IF EXISTS data_staging;
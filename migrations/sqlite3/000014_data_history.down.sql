@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS data_history_vehicle_idx;
+DROP TABLE IF EXISTS data_history;
@@ -0,0 +1,20 @@
+-- Стоимости из каждой загрузки КГД. Строки загрузки ссылаются на ее запись в
+-- kgd_data_migration; отмененные загрузки отсеиваются по ее статусу.
+CREATE TABLE IF NOT EXISTS data_history (
+    import_id INTEGER NOT NULL REFERENCES kgd_data_migration (id),
+    id INTEGER NOT NULL,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    variant TEXT NOT NULL DEFAULT '',
+    year INTEGER,
+    volume INTEGER,
+    amount INTEGER,
+    PRIMARY KEY (import_id, id)
+);
+
+CREATE INDEX IF NOT EXISTS data_history_vehicle_idx ON data_history (mark, model, volume, year);
+
+-- текущий справочник становится первой версией истории
+INSERT INTO data_history (import_id, id, mark, model, variant, volume, year, amount)
+SELECT m.id, d.id, d.mark, d.model, d.variant, d.volume, d.year, d.amount
+FROM data d JOIN (SELECT MAX(id) AS id FROM kgd_data_migration WHERE status = 'imported') m ON m.id IS NOT NULL;
@@ -0,0 +1,12 @@
+ALTER TABLE data_history DROP COLUMN power;
+ALTER TABLE data_history DROP COLUMN battery_capacity;
+ALTER TABLE data_history DROP COLUMN engine_type;
+ALTER TABLE data_previous DROP COLUMN power;
+ALTER TABLE data_previous DROP COLUMN battery_capacity;
+ALTER TABLE data_previous DROP COLUMN engine_type;
+ALTER TABLE data_staging DROP COLUMN power;
+ALTER TABLE data_staging DROP COLUMN battery_capacity;
+ALTER TABLE data_staging DROP COLUMN engine_type;
+ALTER TABLE data DROP COLUMN power;
+ALTER TABLE data DROP COLUMN battery_capacity;
+ALTER TABLE data DROP COLUMN engine_type;
@@ -0,0 +1,19 @@
+-- Тип двигателя (ice, hybrid, ev) и, где КГД их указывает, емкость батареи в
+-- кВт*ч и мощность в кВт. Раньше у электромобилей в списке КГД был только
+-- нулевой объем, по нему они и размечаются; гибриды - по названию модели.
+ALTER TABLE data ADD COLUMN engine_type TEXT NOT NULL DEFAULT 'ice';
+ALTER TABLE data ADD COLUMN battery_capacity REAL NOT NULL DEFAULT 0;
+ALTER TABLE data ADD COLUMN power INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE data_staging ADD COLUMN engine_type TEXT NOT NULL DEFAULT 'ice';
+ALTER TABLE data_staging ADD COLUMN battery_capacity REAL NOT NULL DEFAULT 0;
+ALTER TABLE data_staging ADD COLUMN power INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE data_previous ADD COLUMN engine_type TEXT NOT NULL DEFAULT 'ice';
+ALTER TABLE data_previous ADD COLUMN battery_capacity REAL NOT NULL DEFAULT 0;
+ALTER TABLE data_previous ADD COLUMN power INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE data_history ADD COLUMN engine_type TEXT NOT NULL DEFAULT 'ice';
+ALTER TABLE data_history ADD COLUMN battery_capacity REAL NOT NULL DEFAULT 0;
+ALTER TABLE data_history ADD COLUMN power INTEGER NOT NULL DEFAULT 0;
+UPDATE data SET engine_type = 'ev' WHERE volume = 0;
+UPDATE data SET engine_type = 'hybrid' WHERE engine_type = 'ice' AND (variant LIKE '%HYBRID%' OR model LIKE '%HYBRID%');
+UPDATE data_history SET engine_type = 'ev' WHERE volume = 0;
+UPDATE data_history SET engine_type = 'hybrid' WHERE engine_type = 'ice' AND (variant LIKE '%HYBRID%' OR model LIKE '%HYBRID%');
@@ -0,0 +1,4 @@
+ALTER TABLE data_history DROP COLUMN vehicle_class;
+ALTER TABLE data_previous DROP COLUMN vehicle_class;
+ALTER TABLE data_staging DROP COLUMN vehicle_class;
+ALTER TABLE data DROP COLUMN vehicle_class;
@@ -1 +1,127 @@
+// Package sqlite3 открывает файл SQLite так, чтобы импорт КГД и чтение
+// справочника работали в одном процессе без ошибок "database is locked".
 package sqlite3
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const defaultBusyTimeout = 5 * time.Second
+
+// Config - настройки подключения. Нулевые значения заменяются значениями по умолчанию.
+type Config struct {
+	// BusyTimeout - сколько соединение ждет снятия блокировки, прежде чем вернуть SQLITE_BUSY.
+	BusyTimeout time.Duration
+	// MaxReadConns - размер пула чтения, по умолчанию по числу CPU, но не меньше 4.
+	MaxReadConns int
+}
+
+// DB - два пула к одному файлу. Write - единственное соединение для записи и
+// миграций: SQLite все равно пускает только одного писателя, а очередь в пуле
+// лучше, чем SQLITE_BUSY. Read - соединения только для чтения; в режиме WAL
+// они видят последнюю зафиксированную версию и не ждут писателя.
+type DB struct {
+	Write *sql.DB
+	Read  *sql.DB
+}
+
+// Open открывает файл path, в том числе в виде file:path?параметры. Параметры
+// из path имеют приоритет над настройками пакета.
+func Open(path string, cfg Config) (DB, error) {
+	if path == "" || path == ":memory:" || strings.Contains(path, "mode=memory") {
+		return DB{}, errors.New("sqlite3: a file database is required for separate read and write pools")
+	}
+	if cfg.BusyTimeout <= 0 {
+		cfg.BusyTimeout = defaultBusyTimeout
+	}
+	if cfg.MaxReadConns <= 0 {
+		cfg.MaxReadConns = max(4, runtime.NumCPU())
+	}
+
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_busy_timeout", strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", "on")
+	// в режиме WAL NORMAL не теряет целостность, а fsync на каждую строку импорта не нужен
+	params.Set("_synchronous", "NORMAL")
+
+	// транзакции записи сразу берут блокировку: иначе две транзакции, начавшие
+	// с чтения, не могут повысить блокировку и одна из них падает без ожидания
+	writeParams := cloneValues(params)
+	writeParams.Set("_txlock", "immediate")
+	write, err := open(path, writeParams)
+	if err != nil {
+		return DB{}, err
+	}
+	write.SetMaxOpenConns(1)
+
+	// журнал WAL переключает писатель, поэтому пул чтения открывается после него
+	if err := write.Ping(); err != nil {
+		write.Close()
+		return DB{}, fmt.Errorf("sqlite3: %v", err)
+	}
+
+	readParams := cloneValues(params)
+	readParams.Set("_query_only", "on")
+	read, err := open(path, readParams)
+	if err != nil {
+		write.Close()
+		return DB{}, err
+	}
+	read.SetMaxOpenConns(cfg.MaxReadConns)
+	read.SetMaxIdleConns(cfg.MaxReadConns)
+
+	return DB{Write: write, Read: read}, nil
+}
+
+// Close закрывает оба пула.
+func (db DB) Close() error {
+	return errors.Join(db.Read.Close(), db.Write.Close())
+}
+
+func open(path string, defaults url.Values) (*sql.DB, error) {
+	dsn, err := withParams(path, defaults)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: %v", err)
+	}
+	return db, nil
+}
+
+// withParams дописывает к path параметры, которых в нем еще нет.
+func withParams(path string, defaults url.Values) (string, error) {
+	name, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("sqlite3: parse %q: %v", path, err)
+	}
+	for key, values := range defaults {
+		if !params.Has(key) {
+			params[key] = values
+		}
+	}
+	if !strings.HasPrefix(name, "file:") {
+		name = "file:" + name
+	}
+	return name + "?" + params.Encode(), nil
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := make(url.Values, len(values))
+	for key, v := range values {
+		clone[key] = append([]string(nil), v...)
+	}
+	return clone
+}
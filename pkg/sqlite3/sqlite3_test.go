@@ -0,0 +1,90 @@
+package sqlite3
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithParams(t *testing.T) {
+	defaults := url.Values{"_journal_mode": {"WAL"}, "_busy_timeout": {"5000"}}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/data/kgd.db", "file:/data/kgd.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{"file:kgd.db", "file:kgd.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{"kgd.db?_busy_timeout=100", "file:kgd.db?_busy_timeout=100&_journal_mode=WAL"},
+	}
+	for _, tt := range tests {
+		got, err := withParams(tt.path, defaults)
+		if err != nil {
+			t.Fatalf("withParams(%q): %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("withParams(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRejectsMemory(t *testing.T) {
+	for _, path := range []string{"", ":memory:", "file:kgd?mode=memory&cache=shared"} {
+		if _, err := Open(path, Config{}); err == nil {
+			t.Errorf("Open(%q) error = nil, want an error", path)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "kgd.db"), Config{BusyTimeout: time.Second, MaxReadConns: 2})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.Read.QueryRow("PRAGMA journal_mode;").Scan(&mode); err != nil {
+		t.Fatalf("journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+	var timeout, foreignKeys int
+	if err := db.Write.QueryRow("PRAGMA busy_timeout;").Scan(&timeout); err != nil {
+		t.Fatalf("busy_timeout: %v", err)
+	}
+	if timeout != 1000 {
+		t.Errorf("busy_timeout = %d, want 1000", timeout)
+	}
+	if err := db.Write.QueryRow("PRAGMA foreign_keys;").Scan(&foreignKeys); err != nil {
+		t.Fatalf("foreign_keys: %v", err)
+	}
+	if foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, want 1", foreignKeys)
+	}
+
+	if _, err := db.Write.Exec("CREATE TABLE data (id INTEGER PRIMARY KEY, mark TEXT);"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := db.Read.Exec("INSERT INTO data (mark) VALUES ('TOYOTA');"); err == nil {
+		t.Error("insert through the read pool succeeded, want query_only error")
+	}
+
+	// открытая транзакция записи не мешает читать последнюю зафиксированную версию
+	tx, err := db.Write.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO data (mark) VALUES ('KIA');"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	var count int
+	if err := db.Read.QueryRow("SELECT COUNT(*) FROM data;").Scan(&count); err != nil {
+		t.Fatalf("read during write: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("read during write saw %d rows, want 0", count)
+	}
+}
@@ -0,0 +1,150 @@
+// Package vin проверяет и расшифровывает VIN по ISO 3779 без обращения к
+// внешним сервисам: производитель берется из встроенной таблицы WMI, год -
+// из десятого знака.
+package vin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Length - длина VIN машин с 1981 года.
+const Length = 17
+
+var (
+	// ErrInvalid - строка не может быть VIN: не та длина или запрещенные знаки.
+	ErrInvalid = errors.New("invalid VIN")
+	// ErrCheckDigit - контрольный знак не сходится там, где он обязателен.
+	ErrCheckDigit = errors.New("VIN check digit mismatch")
+)
+
+// VIN - расшифровка номера. Manufacturer пустой, если WMI нет в таблице; Year
+// 0, если десятый знак не обозначает год.
+type VIN struct {
+	Number       string
+	WMI          string
+	Manufacturer string
+	Region       string
+	Year         int
+	// CheckDigit - сошелся ли девятый знак. Обязателен он только для рынков
+	// Северной Америки и Китая, у остальных производителей там бывает что угодно.
+	CheckDigit bool
+}
+
+// transliteration - числовые значения знаков для контрольной суммы.
+var transliteration = map[rune]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+var weights = [Length]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// yearCodes - знаки года по порядку, цикл повторяется каждые 30 лет с 1980.
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// Normalize приводит номер к верхнему регистру и убирает пробелы и дефисы,
+// с которыми VIN копируют из объявлений.
+func Normalize(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(number)))
+}
+
+// Decode проверяет номер и расшифровывает производителя и год выпуска. now
+// нужен, чтобы из двух лет с одним знаком выбрать тот, что уже наступил.
+func Decode(number string, now time.Time) (VIN, error) {
+	number = Normalize(number)
+	if len(number) != Length {
+		return VIN{}, fmt.Errorf("%w: %d characters, want %d", ErrInvalid, len(number), Length)
+	}
+
+	sum := 0
+	for i, r := range number {
+		value, err := charValue(r)
+		if err != nil {
+			return VIN{}, fmt.Errorf("%w: position %d: %v", ErrInvalid, i+1, err)
+		}
+		sum += value * weights[i]
+	}
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+
+	v := VIN{
+		Number:     number,
+		WMI:        number[:3],
+		Region:     region(number[0]),
+		CheckDigit: number[8] == check,
+	}
+	if !v.CheckDigit && checkDigitRequired(number[0]) {
+		return VIN{}, fmt.Errorf("%w: position 9 is %c, want %c", ErrCheckDigit, number[8], check)
+	}
+	v.Manufacturer = manufacturer(number)
+	v.Year = modelYear(number, now)
+	return v, nil
+}
+
+func charValue(r rune) (int, error) {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0'), nil
+	case r == 'I' || r == 'O' || r == 'Q':
+		return 0, fmt.Errorf("%c is not used in VIN", r)
+	}
+	if value, ok := transliteration[r]; ok {
+		return value, nil
+	}
+	return 0, fmt.Errorf("unexpected character %q", r)
+}
+
+// checkDigitRequired - контрольный знак обязателен для США, Канады, Мексики и Китая.
+func checkDigitRequired(first byte) bool {
+	return (first >= '1' && first <= '5') || first == 'L'
+}
+
+// modelYear расшифровывает десятый знак. Один знак означает два года с
+// разницей в 30 лет: у североамериканских VIN их различает седьмой знак (цифра -
+// до 2009, буква - с 2010), у остальных берется последний наступивший год с
+// учетом того, что модели следующего года продаются заранее.
+func modelYear(number string, now time.Time) int {
+	i := strings.IndexByte(yearCodes, number[9])
+	if i < 0 {
+		return 0
+	}
+	year := 1980 + i
+	if number[0] >= '1' && number[0] <= '5' {
+		if number[6] < '0' || number[6] > '9' {
+			year += 30
+		}
+		return year
+	}
+	for year+30 <= now.Year()+1 {
+		year += 30
+	}
+	return year
+}
+
+func region(first byte) string {
+	switch {
+	case first >= 'A' && first <= 'H':
+		return "Africa"
+	case first >= 'J' && first <= 'R':
+		return "Asia"
+	case first >= 'S' && first <= 'Z':
+		return "Europe"
+	case first >= '1' && first <= '5':
+		return "North America"
+	case first >= '6' && first <= '7':
+		return "Oceania"
+	case first >= '8' && first <= '9':
+		return "South America"
+	}
+	return ""
+}
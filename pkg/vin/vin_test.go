@@ -0,0 +1,54 @@
+package vin
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		number string
+		want   VIN
+	}{
+		{"1HGCM82633A004352", VIN{Number: "1HGCM82633A004352", WMI: "1HG", Manufacturer: "HONDA", Region: "North America", Year: 2003, CheckDigit: true}},
+		// седьмой знак - буква, значит D - 2013, а не 1983
+		{"5YJSA1CN0DFP01843", VIN{Number: "5YJSA1CN0DFP01843", WMI: "5YJ", Manufacturer: "TESLA", Region: "North America", Year: 2013, CheckDigit: true}},
+		// японский VIN без контрольного знака, K - 2019, а не 1989
+		{" jtebu5jr2k5610413 ", VIN{Number: "JTEBU5JR2K5610413", WMI: "JTE", Manufacturer: "TOYOTA", Region: "Asia", Year: 2019}},
+		// модели следующего года продаются заранее
+		{"WDD-205042-VA000001", VIN{Number: "WDD205042VA000001", WMI: "WDD", Manufacturer: "MERCEDES-BENZ", Region: "Europe", Year: 2027}},
+		{"XXX12345600000000", VIN{Number: "XXX12345600000000", WMI: "XXX", Region: "Europe"}},
+	}
+	for _, tt := range tests {
+		got, err := Decode(tt.number, now)
+		if err != nil {
+			t.Errorf("Decode(%q): %v", tt.number, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Decode(%q) = %+v, want %+v", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		number string
+		want   error
+	}{
+		{"1HGCM82633A00435", ErrInvalid},
+		{"1HGCM82633A0043520", ErrInvalid},
+		{"1HGCM8263OA004352", ErrInvalid},
+		{"1HGCM82Я3A004352", ErrInvalid},
+		{"1HGCM82643A004352", ErrCheckDigit},
+		{"LRW3E7EK0NC000001", ErrCheckDigit},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(tt.number, now); !errors.Is(err, tt.want) {
+			t.Errorf("Decode(%q) = %v, want %v", tt.number, err, tt.want)
+		}
+	}
+}
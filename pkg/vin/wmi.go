@@ -0,0 +1,67 @@
+package vin
+
+// wmi - производители по первым трем знакам VIN. В таблице марки, которые
+// везут из Дубая; названия записаны так, как их пишет КГД.
+var wmi = map[string]string{
+	// Япония
+	"JTD": "TOYOTA", "JTE": "TOYOTA", "JTF": "TOYOTA", "JTM": "TOYOTA", "JTN": "TOYOTA",
+	"JTH": "LEXUS", "JTJ": "LEXUS",
+	"JN1": "NISSAN", "JN8": "NISSAN", "JNK": "INFINITI", "JNR": "INFINITI", "JNX": "INFINITI",
+	"JHM": "HONDA", "JHL": "HONDA", "JH4": "ACURA",
+	"JM1": "MAZDA", "JM3": "MAZDA", "JMZ": "MAZDA",
+	"JA3": "MITSUBISHI", "JA4": "MITSUBISHI", "JMB": "MITSUBISHI",
+	"JF1": "SUBARU", "JF2": "SUBARU",
+	"JS2": "SUZUKI", "JS3": "SUZUKI",
+	"JAA": "ISUZU", "JDA": "DAIHATSU",
+	// Корея
+	"KMH": "HYUNDAI", "KM8": "HYUNDAI", "KMT": "GENESIS",
+	"KNA": "KIA", "KND": "KIA",
+	"KL1": "CHEVROLET",
+	// Китай
+	"L6T": "GEELY", "LVV": "CHERY", "LGW": "HAVAL", "LS5": "CHANGAN",
+	"LGX": "BYD", "LC0": "BYD", "LSJ": "MG", "LRW": "TESLA",
+	// Таиланд, Турция, ЮАР - сборка японских марок
+	"MR0": "TOYOTA", "NMT": "TOYOTA", "AHT": "TOYOTA",
+	// Великобритания
+	"SAL": "LAND ROVER", "SAJ": "JAGUAR", "SCA": "ROLLS-ROYCE", "SCB": "BENTLEY",
+	"SCF": "ASTON MARTIN", "SCC": "LOTUS", "SBM": "MCLAREN", "SB1": "TOYOTA",
+	// Германия и Европа
+	"WBA": "BMW", "WBS": "BMW", "WBY": "BMW", "WBX": "BMW", "WMW": "MINI",
+	"WDB": "MERCEDES-BENZ", "WDC": "MERCEDES-BENZ", "WDD": "MERCEDES-BENZ", "WDF": "MERCEDES-BENZ",
+	"W1K": "MERCEDES-BENZ", "W1N": "MERCEDES-BENZ", "W1V": "MERCEDES-BENZ", "WMX": "MERCEDES-BENZ",
+	"WVW": "VOLKSWAGEN", "WVG": "VOLKSWAGEN", "WV1": "VOLKSWAGEN", "WV2": "VOLKSWAGEN",
+	"WAU": "AUDI", "WA1": "AUDI", "WUA": "AUDI", "TRU": "AUDI",
+	"WP0": "PORSCHE", "WP1": "PORSCHE",
+	"W0L": "OPEL", "WF0": "FORD",
+	"TMB": "SKODA", "VSS": "SEAT",
+	"VF1": "RENAULT", "VF3": "PEUGEOT", "VF7": "CITROEN",
+	"ZHW": "LAMBORGHINI", "ZFF": "FERRARI", "ZAM": "MASERATI", "ZAR": "ALFA ROMEO", "ZFA": "FIAT",
+	"YV1": "VOLVO", "YV4": "VOLVO",
+	"XTA": "LADA", "XTT": "UAZ", "XP7": "TESLA",
+	// Северная Америка
+	"1FA": "FORD", "1FM": "FORD", "1FT": "FORD", "2FM": "FORD", "3FA": "FORD",
+	"1LN": "LINCOLN", "5LM": "LINCOLN", "2LM": "LINCOLN",
+	"1G1": "CHEVROLET", "1GC": "CHEVROLET", "1GN": "CHEVROLET", "2G1": "CHEVROLET", "3GN": "CHEVROLET",
+	"1GT": "GMC", "1GK": "GMC", "2GT": "GMC", "3GT": "GMC",
+	"1G6": "CADILLAC", "1GY": "CADILLAC",
+	"1C4": "JEEP", "1J4": "JEEP", "1J8": "JEEP",
+	"2C3": "DODGE", "1B3": "DODGE", "2B3": "DODGE",
+	"1C6": "RAM", "3C6": "RAM",
+	"5YJ": "TESLA", "7SA": "TESLA",
+	"4T1": "TOYOTA", "4T3": "TOYOTA", "5TD": "TOYOTA", "5TE": "TOYOTA", "5TF": "TOYOTA",
+	"2T1": "TOYOTA", "2T3": "TOYOTA",
+	"2T2": "LEXUS", "58A": "LEXUS",
+	"1N4": "NISSAN", "1N6": "NISSAN", "5N1": "NISSAN", "3N1": "NISSAN", "5N3": "INFINITI",
+	"1HG": "HONDA", "2HG": "HONDA", "5FN": "HONDA", "5J6": "HONDA", "19U": "ACURA", "5J8": "ACURA",
+	"5NP": "HYUNDAI", "5NM": "HYUNDAI", "5XY": "KIA", "5XX": "KIA", "3KP": "KIA",
+	"4S3": "SUBARU", "4S4": "SUBARU",
+	"5UX": "BMW", "5YM": "BMW",
+	"4JG": "MERCEDES-BENZ", "55S": "MERCEDES-BENZ",
+	"3VW": "VOLKSWAGEN", "1VW": "VOLKSWAGEN",
+}
+
+// manufacturer ищет производителя по WMI. Мелкие производители (третий знак 9)
+// различаются по 12-14 знакам; их в таблице нет.
+func manufacturer(number string) string {
+	return wmi[number[:3]]
+}